@@ -0,0 +1,172 @@
+// Copyright (c) 2023 Benjamin Borbe All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bborbe/errors"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type command func(ctx context.Context, args []string, out io.Writer) error
+
+// commands available as first argument, e.g. `sample_oauth2 decode-cookie <token>`
+var commands = map[string]command{
+	"decode-cookie":    decodeCookieCommand,
+	"decode-state":     decodeStateCommand,
+	"mint-cookie":      mintCookieCommand,
+	"generate-api-key": generateAPIKeyCommand,
+	"preview-template": previewTemplateCommand,
+}
+
+func runCommand(ctx context.Context, name string, args []string) int {
+	if err := commands[name](ctx, args, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func newCommandFlagSet(name string) (*flag.FlagSet, *string) {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	signingKey := flagSet.String("jwt-signing-key", os.Getenv("JWT_SIGNING_KEY"), "Key to use for signing jwts")
+	return flagSet, signingKey
+}
+
+func parseTokenArgs(ctx context.Context, name string, args []string) (string, []byte, error) {
+	flagSet, signingKey := newCommandFlagSet(name)
+	if err := flagSet.Parse(args); err != nil {
+		return "", nil, errors.Wrapf(ctx, err, "parse args failed")
+	}
+	if len(*signingKey) == 0 {
+		return "", nil, errors.Errorf(ctx, "jwt-signing-key missing")
+	}
+	if flagSet.NArg() != 1 {
+		return "", nil, errors.Errorf(ctx, "usage: %s [-jwt-signing-key=KEY] TOKEN", name)
+	}
+	return flagSet.Arg(0), []byte(*signingKey), nil
+}
+
+func decodeCookieCommand(ctx context.Context, args []string, out io.Writer) error {
+	token, key, err := parseTokenArgs(ctx, "decode-cookie", args)
+	if err != nil {
+		return err
+	}
+	return writeInspection(ctx, out, pkg.InspectCookie(ctx, pkg.NewCookieGenerator(key, pkg.CookieOptions{}), token))
+}
+
+func decodeStateCommand(ctx context.Context, args []string, out io.Writer) error {
+	token, key, err := parseTokenArgs(ctx, "decode-state", args)
+	if err != nil {
+		return err
+	}
+	return writeInspection(ctx, out, pkg.InspectState(ctx, pkg.NewStateGenerator(key), token))
+}
+
+func writeInspection(ctx context.Context, out io.Writer, inspection pkg.TokenInspection) error {
+	content, err := inspection.MarshalIndent()
+	if err != nil {
+		return errors.Wrapf(ctx, err, "marshal inspection failed")
+	}
+	fmt.Fprintln(out, string(content))
+	if !inspection.Valid {
+		return errors.Errorf(ctx, "token invalid: %s", inspection.Reason)
+	}
+	return nil
+}
+
+func mintCookieCommand(ctx context.Context, args []string, out io.Writer) error {
+	flagSet, signingKey := newCommandFlagSet("mint-cookie")
+	user := flagSet.String("user", "", "user to mint the cookie for")
+	provider := flagSet.String("provider", pkg.ProviderGoogle, "provider the user logged in with")
+	groups := flagSet.String("groups", "", "comma separated groups of the user")
+	if err := flagSet.Parse(args); err != nil {
+		return errors.Wrapf(ctx, err, "parse args failed")
+	}
+	if len(*signingKey) == 0 {
+		return errors.Errorf(ctx, "jwt-signing-key missing")
+	}
+	if len(*user) == 0 {
+		return errors.Errorf(ctx, "user missing")
+	}
+	cookie, err := pkg.NewCookieGenerator([]byte(*signingKey), pkg.CookieOptions{}).Generate(ctx, pkg.Login{
+		User:     *user,
+		Provider: *provider,
+		Groups:   pkg.SplitList(*groups),
+	})
+	if err != nil {
+		return errors.Wrapf(ctx, err, "generate cookie failed")
+	}
+	fmt.Fprintln(out, cookie.String())
+	return nil
+}
+
+func generateAPIKeyCommand(ctx context.Context, args []string, out io.Writer) error {
+	key, err := pkg.GenerateAPIKey()
+	if err != nil {
+		return errors.Wrapf(ctx, err, "generate api key failed")
+	}
+	fmt.Fprintf(out, "key: %s\nkey_hash: %s\n", key, pkg.HashAPIKey(key))
+	return nil
+}
+
+func previewTemplateCommand(ctx context.Context, args []string, out io.Writer) error {
+	flagSet := flag.NewFlagSet("preview-template", flag.ContinueOnError)
+	templateDir := flagSet.String("template-dir", os.Getenv("TEMPLATE_DIR"), "Directory with html templates overriding the built-in pages")
+	var branding pkg.Branding
+	flagSet.StringVar(&branding.Title, "brand-title", os.Getenv("BRAND_TITLE"), "Title shown on the pages")
+	flagSet.StringVar(&branding.LogoURL, "brand-logo-url", os.Getenv("BRAND_LOGO_URL"), "Logo shown on the pages")
+	flagSet.StringVar(&branding.PrimaryColor, "brand-primary-color", os.Getenv("BRAND_PRIMARY_COLOR"), "Button color of the pages")
+	flagSet.StringVar(&branding.BackgroundColor, "brand-background-color", os.Getenv("BRAND_BACKGROUND_COLOR"), "Background color of the pages")
+	if err := flagSet.Parse(args); err != nil {
+		return errors.Wrapf(ctx, err, "parse args failed")
+	}
+	if flagSet.NArg() != 1 {
+		return errors.Errorf(ctx, "usage: preview-template [-template-dir=DIR] NAME, NAME is one of %v", pkg.TemplateNames)
+	}
+	templates, err := pkg.NewTemplates(ctx, *templateDir, branding)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "load templates failed")
+	}
+	name := pkg.TemplateName(flagSet.Arg(0))
+	title, data, ok := previewTemplatePage(name)
+	if !ok {
+		return errors.Errorf(ctx, "unknown template %s, expected one of %v", name, pkg.TemplateNames)
+	}
+	return templates.Execute(ctx, out, name, title, data)
+}
+
+// previewTemplatePage returns title and sample data to render the template
+func previewTemplatePage(name pkg.TemplateName) (string, interface{}, bool) {
+	loginError := pkg.LoginErrorPage{
+		Message:       "Your account is not allowed to access this site.",
+		Detail:        "Detail reported by the identity provider",
+		RetryURL:      "/",
+		CorrelationID: "00000000-0000-0000-0000-000000000000",
+	}
+	switch name {
+	case pkg.TemplateSignIn:
+		return "Sign in", pkg.SignInPage{
+			Origin: "/",
+			Providers: []pkg.SignInProvider{
+				{Name: "Google", URL: "/login/google"},
+			},
+		}, true
+	case pkg.TemplateSignedOut:
+		return "Signed out", pkg.SignedOutPage{User: "jdoe@example.com", SignInURL: "/"}, true
+	case pkg.TemplateForbidden:
+		return "Access denied", loginError, true
+	case pkg.TemplateError:
+		return "Login failed", loginError, true
+	default:
+		return "", nil, false
+	}
+}
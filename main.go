@@ -7,8 +7,11 @@ package main
 import (
 	"context"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/bborbe/errors"
@@ -18,60 +21,518 @@ import (
 	"github.com/bborbe/service"
 	"github.com/golang/glog"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/bborbe/sample_oauth2/pkg"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if _, ok := commands[os.Args[1]]; ok {
+			os.Exit(runCommand(context.Background(), os.Args[1], os.Args[2:]))
+		}
+	}
 	app := &application{}
 	os.Exit(service.Main(context.Background(), app, &app.SentryDSN, &app.SentryProxy))
 }
 
 type application struct {
-	SentryDSN          string `required:"true" arg:"sentry-dsn" env:"SENTRY_DSN" usage:"SentryDSN" display:"length"`
-	SentryProxy        string `required:"false" arg:"sentry-proxy" env:"SENTRY_PROXY" usage:"Sentry Proxy"`
-	Listen             string `required:"true" arg:"listen" env:"LISTEN" usage:"address to listen to"`
-	GoogleClientID     string `required:"false" arg:"google-client-id" env:"GOOGLE_CLIENT_ID" usage:"Google client id"`
-	GoogleClientSecret string `required:"false" arg:"google-client-secret" env:"GOOGLE_CLIENT_SECRET" usage:"Google client secret:" display:"length"`
-	GoogleHostedDomain string `required:"false" arg:"google-hosted-domain" env:"GOOGLE_HOSTED_DOMAIN" usage:"Domain name of the Google Instance (G Suite)"`
-	GoogleRedirectURL  string `required:"false" arg:"google-redirect-url" env:"GOOGLE_REDIRECT_URL" usage:"Google redirect url"`
-	JWTSigningKey      string `required:"false" arg:"jwt-signing-key" env:"JWT_SIGNING_KEY" usage:"Key to use for signing jwts" display:"length"`
+	SentryDSN          string        `required:"true" arg:"sentry-dsn" env:"SENTRY_DSN" usage:"SentryDSN" display:"length"`
+	SentryProxy        string        `required:"false" arg:"sentry-proxy" env:"SENTRY_PROXY" usage:"Sentry Proxy"`
+	Listen             string        `required:"true" arg:"listen" env:"LISTEN" usage:"address to listen to"`
+	ConfigFile         string        `required:"false" arg:"config" env:"CONFIG" usage:"YAML or JSON config file with providers, cookie, upstreams and policies, flags override its values"`
+	ConfigReload       time.Duration `required:"false" arg:"config-reload-interval" env:"CONFIG_RELOAD_INTERVAL" usage:"Interval to check the config file for changes" default:"10s"`
+	GoogleClientID     string        `required:"false" arg:"google-client-id" env:"GOOGLE_CLIENT_ID" usage:"Google client id"`
+	GoogleClientSecret string        `required:"false" arg:"google-client-secret" env:"GOOGLE_CLIENT_SECRET" usage:"Google client secret:" display:"length"`
+	GoogleHostedDomain string        `required:"false" arg:"google-hosted-domain" env:"GOOGLE_HOSTED_DOMAIN" usage:"Domain name of the Google Instance (G Suite)"`
+	GoogleRedirectURL  string        `required:"false" arg:"google-redirect-url" env:"GOOGLE_REDIRECT_URL" usage:"Google redirect url"`
+	GitHubClientID     string        `required:"false" arg:"github-client-id" env:"GITHUB_CLIENT_ID" usage:"GitHub client id, empty disables login with GitHub"`
+	GitHubClientSecret string        `required:"false" arg:"github-client-secret" env:"GITHUB_CLIENT_SECRET" usage:"GitHub client secret" display:"length"`
+	GitHubRedirectURL  string        `required:"false" arg:"github-redirect-url" env:"GITHUB_REDIRECT_URL" usage:"GitHub redirect url, may share the callback path with other providers"`
+	GitHubDomain       string        `required:"false" arg:"github-domain" env:"GITHUB_DOMAIN" usage:"Domain the primary email of GitHub users must belong to"`
+	JWTSigningKey      string        `required:"false" arg:"jwt-signing-key" env:"JWT_SIGNING_KEY" usage:"Key to use for signing jwts" display:"length"`
+	BearerJWKSURL      string        `required:"false" arg:"bearer-jwks-url" env:"BEARER_JWKS_URL" usage:"JWKS url to verify provider issued bearer ID tokens, empty disables" default:"https://www.googleapis.com/oauth2/v3/certs"`
+	BearerIssuers      string        `required:"false" arg:"bearer-issuers" env:"BEARER_ISSUERS" usage:"Comma separated list of allowed issuers of bearer ID tokens" default:"https://accounts.google.com,accounts.google.com"`
+	IntrospectionURL   string        `required:"false" arg:"introspection-url" env:"INTROSPECTION_URL" usage:"RFC 7662 endpoint to verify provider issued bearer access tokens, empty disables"`
+	APIPathPrefixes    string        `required:"false" arg:"api-path-prefixes" env:"API_PATH_PREFIXES" usage:"Comma separated path prefixes answered with 401 instead of a login redirect"`
+	ServiceAccounts    string        `required:"false" arg:"service-accounts" env:"SERVICE_ACCOUNTS" usage:"Path to JSON file with service accounts allowed to authenticate by API key"`
+	SessionStoreFile   string        `required:"false" arg:"session-store-file" env:"SESSION_STORE_FILE" usage:"Path to JSON file to persist sessions and access tokens, empty keeps them in memory"`
+	AuditSinks         string        `required:"false" arg:"audit-sinks" env:"AUDIT_SINKS" usage:"Comma separated audit log sinks: stdout, file:<path> or a webhook url" default:"stdout"`
+	ProviderTimeout    time.Duration `required:"false" arg:"provider-timeout" env:"PROVIDER_TIMEOUT" usage:"Timeout of requests to the OAuth provider" default:"10s"`
+	ProviderRetries    int           `required:"false" arg:"provider-retries" env:"PROVIDER_RETRIES" usage:"Retries of idempotent requests to the OAuth provider" default:"2"`
+	ProviderProxy      string        `required:"false" arg:"provider-proxy" env:"PROVIDER_PROXY" usage:"Proxy url for requests to the OAuth provider, defaults to HTTPS_PROXY"`
+	ProviderCABundle   string        `required:"false" arg:"provider-ca-bundle" env:"PROVIDER_CA_BUNDLE" usage:"PEM file with additional CA certificates trusted for the OAuth provider"`
+	SignInPage         bool          `required:"false" arg:"sign-in-page" env:"SIGN_IN_PAGE" usage:"Show a sign-in page listing the providers instead of redirecting to the provider immediately"`
+	SignInSkipSingle   bool          `required:"false" arg:"sign-in-skip-single-provider" env:"SIGN_IN_SKIP_SINGLE_PROVIDER" usage:"Skip the sign-in page if only one provider is configured"`
+	TemplateDir        string        `required:"false" arg:"template-dir" env:"TEMPLATE_DIR" usage:"Directory with html templates overriding the built-in pages"`
+	BrandTitle         string        `required:"false" arg:"brand-title" env:"BRAND_TITLE" usage:"Title shown on sign-in, sign-out and error pages"`
+	BrandLogoURL       string        `required:"false" arg:"brand-logo-url" env:"BRAND_LOGO_URL" usage:"Logo shown on sign-in, sign-out and error pages"`
+	BrandPrimaryColor  string        `required:"false" arg:"brand-primary-color" env:"BRAND_PRIMARY_COLOR" usage:"Button color of sign-in, sign-out and error pages"`
+	BrandBackground    string        `required:"false" arg:"brand-background-color" env:"BRAND_BACKGROUND_COLOR" usage:"Background color of sign-in, sign-out and error pages"`
+	ForwardToken       string        `required:"false" arg:"forward-access-token" env:"FORWARD_ACCESS_TOKEN" usage:"Forward the provider access token to upstreams: authorization or header (X-Forwarded-Access-Token), empty disables"`
+	TraceExporter      string        `required:"false" arg:"trace-exporter" env:"TRACE_EXPORTER" usage:"OpenTelemetry trace exporter: stdout or otlp, empty disables tracing"`
 }
 
 func (a *application) Run(ctx context.Context, sentryClient libsentry.Client) error {
+	shutdownTracing, err := pkg.SetupTracing(ctx, a.TraceExporter, "sample-oauth2")
+	if err != nil {
+		return errors.Wrapf(ctx, err, "setup tracing failed")
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			glog.Warningf("shutdown tracing failed: %v", err)
+		}
+	}()
 
 	router := mux.NewRouter()
 	router.Path("/healthz").Handler(libhttp.NewPrintHandler("OK"))
-	router.Path("/readiness").Handler(libhttp.NewPrintHandler("OK"))
-	router.Path("/metrics").Handler(promhttp.Handler())
-	router.Path("/setloglevel/{level}").Handler(log.NewSetLoglevelHandler(ctx, log.NewLogLevelSetter(2, 5*time.Minute)))
 
-	callbackUrl, err := url.Parse(a.GoogleRedirectURL)
+	providerClient, err := pkg.NewHTTPClient(ctx, pkg.HTTPClientOptions{
+		Timeout:      a.ProviderTimeout,
+		ProxyURL:     a.ProviderProxy,
+		CABundle:     a.ProviderCABundle,
+		MaxRetries:   a.ProviderRetries,
+		RetryBackoff: 200 * time.Millisecond,
+	})
+	if err != nil {
+		return errors.Wrapf(ctx, err, "create provider http client failed")
+	}
+
+	templates, err := pkg.NewTemplates(ctx, a.TemplateDir, pkg.Branding{
+		Title:           a.BrandTitle,
+		LogoURL:         a.BrandLogoURL,
+		PrimaryColor:    a.BrandPrimaryColor,
+		BackgroundColor: a.BrandBackground,
+	})
+	if err != nil {
+		return errors.Wrapf(ctx, err, "load templates failed")
+	}
+
+	sessionStore, err := a.createSessionStore(ctx)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "create session store failed")
+	}
+	prometheus.MustRegister(pkg.NewActiveSessionsCollector(sessionStore))
+	auditLogger, err := pkg.NewAuditLogger(ctx, http.DefaultClient, pkg.SplitList(a.AuditSinks))
+	if err != nil {
+		return errors.Wrapf(ctx, err, "create audit logger failed")
+	}
+	deps := dependencies{
+		providerClient: providerClient,
+		templates:      templates,
+		metrics:        pkg.NewMetrics(),
+		sessionStore:   sessionStore,
+		auditLogger:    auditLogger,
+		replayStore:    pkg.NewMemoryReplayStore(),
+	}
+	readinessChecks := pkg.ReadinessChecks{
+		"session_store": sessionStore,
+	}
+	if a.BearerJWKSURL != "" {
+		deps.jwks = pkg.NewJWKS(providerClient, a.BearerJWKSURL)
+		readinessChecks["jwks"] = deps.jwks
+	}
+	if a.ServiceAccounts != "" {
+		serviceAccounts, err := pkg.ReadServiceAccounts(ctx, a.ServiceAccounts)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "read service accounts failed")
+		}
+		deps.serviceAccountAuthenticator = pkg.NewServiceAccountAuthenticator(serviceAccounts)
+	}
+
+	gateway := pkg.NewReloadableHandler(func(ctx context.Context, config pkg.Config) (http.Handler, error) {
+		return a.createHandler(ctx, config, deps)
+	})
+	config, err := a.loadConfig(ctx)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "load config failed")
+	}
+	if err := gateway.Apply(ctx, config); err != nil {
+		return errors.Wrapf(ctx, err, "apply config failed")
+	}
+	if a.ConfigFile != "" {
+		watcher := pkg.NewConfigWatcher(a.ConfigFile, a.ConfigReload, a.loadConfig, gateway)
+		go func() {
+			if err := watcher.Run(ctx); err != nil {
+				glog.Warningf("watch config failed: %v", err)
+			}
+		}()
+	}
+	readinessChecks["signing_key"] = pkg.ReadinessCheckFunc(func(ctx context.Context) error {
+		return pkg.NewSigningKeyReadinessCheck([]byte(gateway.Config().SigningKey)).Check(ctx)
+	})
+	for id, url := range pkg.ProviderReadinessURLs {
+		check := pkg.NewProviderReadinessCheck(providerClient, url, 5*time.Minute)
+		readinessChecks["provider_"+id] = pkg.ReadinessCheckFunc(func(ctx context.Context) error {
+			// providers can be added and removed by config reloads
+			if !slices.ContainsFunc(gateway.Config().Providers, func(provider pkg.ProviderConfig) bool { return provider.ID == id }) {
+				return nil
+			}
+			return check.Check(ctx)
+		})
+	}
+	router.Path("/readiness").Handler(pkg.NewReadinessHandler(readinessChecks, 5*time.Second))
+
+	// all other routes require a login and are replaced on config changes
+	router.PathPrefix("/").Handler(gateway)
+
+	glog.V(2).Infof("starting http server listen on %s", a.Listen)
+	return libhttp.NewServer(
+		a.Listen,
+		router,
+	).Run(ctx)
+}
+
+// dependencies shared by the handlers of all configs
+type dependencies struct {
+	providerClient              *http.Client
+	templates                   pkg.Templates
+	metrics                     pkg.Metrics
+	sessionStore                pkg.SessionStore
+	auditLogger                 pkg.AuditLogger
+	jwks                        pkg.JWKS
+	serviceAccountAuthenticator pkg.ServiceAccountAuthenticator
+	// replayStore of handoff codes, kept on config changes so codes can not be replayed by a reload
+	replayStore pkg.ReplayStore
+}
+
+// loadConfig reads the config file and overrides its values with the flags that are set
+func (a *application) loadConfig(ctx context.Context) (pkg.Config, error) {
+	var config pkg.Config
+	if a.ConfigFile != "" {
+		var err error
+		if config, err = pkg.ReadConfig(ctx, a.ConfigFile); err != nil {
+			return pkg.Config{}, errors.Wrapf(ctx, err, "read config failed")
+		}
+	}
+	if a.JWTSigningKey != "" {
+		config.SigningKey = a.JWTSigningKey
+	}
+	config.Providers = overrideProvider(config.Providers, pkg.ProviderConfig{
+		ID:           pkg.ProviderGoogle,
+		ClientID:     a.GoogleClientID,
+		ClientSecret: a.GoogleClientSecret,
+		RedirectURL:  a.GoogleRedirectURL,
+		Domain:       a.GoogleHostedDomain,
+	})
+	config.Providers = overrideProvider(config.Providers, pkg.ProviderConfig{
+		ID:           pkg.ProviderGitHub,
+		ClientID:     a.GitHubClientID,
+		ClientSecret: a.GitHubClientSecret,
+		RedirectURL:  a.GitHubRedirectURL,
+		Domain:       a.GitHubDomain,
+	})
+	if a.APIPathPrefixes != "" {
+		config.APIPathPrefixes = pkg.SplitList(a.APIPathPrefixes)
+	}
+	if a.ForwardToken != "" {
+		config.ForwardAccessToken = a.ForwardToken
+	}
+	return config, nil
+}
+
+// overrideProvider sets the non empty fields of override on the provider with the same id,
+// the provider is added if it is not in the list yet
+func overrideProvider(providers []pkg.ProviderConfig, override pkg.ProviderConfig) []pkg.ProviderConfig {
+	if override.ClientID == "" && override.ClientSecret == "" && override.RedirectURL == "" && override.Domain == "" {
+		return providers
+	}
+	pos := slices.IndexFunc(providers, func(provider pkg.ProviderConfig) bool {
+		return provider.ID == override.ID
+	})
+	if pos == -1 {
+		return append(providers, override)
+	}
+	result := slices.Clone(providers)
+	provider := &result[pos]
+	if override.ClientID != "" {
+		provider.ClientID = override.ClientID
+	}
+	if override.ClientSecret != "" {
+		provider.ClientSecret = override.ClientSecret
+	}
+	if override.RedirectURL != "" {
+		provider.RedirectURL = override.RedirectURL
+	}
+	if override.Domain != "" {
+		provider.Domain = override.Domain
+	}
+	return result
+}
+
+// createHandler returns the routes that require a login, configured by config
+func (a *application) createHandler(ctx context.Context, config pkg.Config, deps dependencies) (http.Handler, error) {
+	cookieGenerator := pkg.NewCookieGenerator([]byte(config.SigningKey), config.Cookie)
+	stateGenerator := pkg.NewStateGenerator([]byte(config.SigningKey))
+	providers, callbackPaths, err := createProviders(ctx, config, deps.providerClient, deps.metrics)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create providers failed")
+	}
+	groupResolver, err := a.createGroupResolver(ctx, config.Groups, deps)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create group resolver failed")
+	}
+	// sessions of an app host behind a handoff keep the groups of the central auth host
+	var sessionGroupResolver pkg.GroupResolver
+	if config.Groups.CredentialsFile != "" {
+		sessionGroupResolver = groupResolver
+	}
+	signInPath := ""
+	if a.SignInPage || len(providers) > 1 {
+		signInPath = pkg.SignInPath
+	}
+	authenticator := pkg.Authenticators{
+		pkg.NewCookieAuthenticator(cookieGenerator, deps.sessionStore, sessionGroupResolver, deps.metrics),
+		pkg.NewAccessTokenAuthenticator(deps.sessionStore),
+	}
+	tokenVerifiers, err := a.createTokenVerifiers(ctx, config, deps, cookieGenerator, sessionGroupResolver)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create token verifiers failed")
+	}
+	authenticator = append(authenticator, pkg.NewBearerAuthenticator(tokenVerifiers...))
+	if deps.serviceAccountAuthenticator != nil {
+		authenticator = append(authenticator, deps.serviceAccountAuthenticator)
+	}
+	requestClassifier := pkg.NewRequestClassifier(config.APIPathPrefixes)
+	trustedProxies, err := pkg.ParseTrustedProxies(ctx, config.TrustedProxies)
 	if err != nil {
-		return errors.Wrapf(ctx, err, "parse callback url failed")
+		return nil, errors.Wrapf(ctx, err, "parse trusted proxies failed")
+	}
+	loginRateLimiter, callbackRateLimiter := createRateLimiters(config)
+	publicPaths := slices.Clone(callbackPaths)
+	for _, provider := range config.Providers {
+		if provider.BackChannelLogout.Issuer != "" {
+			publicPaths = append(publicPaths, pkg.BackChannelLogoutPathOf(provider.ID))
+		}
+	}
+	if config.Handoff.AuthorizeURL != "" {
+		publicPaths = append(publicPaths, pkg.HandoffExchangePath)
 	}
 
-	cookieGenerator := pkg.NewCookieGenerator([]byte(a.JWTSigningKey))
-	stateGenerator := pkg.NewStateGenerator([]byte(a.JWTSigningKey))
-	googleOAuth := pkg.NewGoogleOAuth(
-		a.GoogleClientID,
-		a.GoogleClientSecret,
-		a.GoogleRedirectURL,
-		a.GoogleHostedDomain,
+	router := mux.NewRouter()
+	router.Path("/metrics").Handler(promhttp.Handler())
+	router.Path("/setloglevel/{level}").Handler(log.NewSetLoglevelHandler(ctx, log.NewLogLevelSetter(2, 5*time.Minute)))
+	router.Use(pkg.NewLoginMiddleware(
+		authenticator,
+		stateGenerator,
+		providers,
+		config.Policies,
+		requestClassifier,
+		loginRateLimiter,
+		deps.metrics,
+		deps.auditLogger,
+		publicPaths,
+		signInPath,
+		config.CallbackHosts,
+		config.Handoff.AuthorizeURL,
+	).Middleware)
+	router.Use(pkg.NewPolicyMiddleware(config.Policies, requestClassifier, deps.auditLogger, deps.templates))
+	router.Use(pkg.NewSessionActivityMiddleware(deps.sessionStore))
+	callbackHandler := pkg.NewRateLimitMiddleware(callbackRateLimiter, deps.metrics, "callback")(
+		libhttp.NewErrorHandler(pkg.NewLoginCallbackHandler(cookieGenerator, stateGenerator, providers, groupResolver, deps.sessionStore, deps.metrics, deps.auditLogger, deps.templates, config.CallbackHosts)),
 	)
-	router.Use(pkg.NewLoginMiddleware(cookieGenerator, stateGenerator, googleOAuth, callbackUrl.Path).Middleware)
-	router.Path(callbackUrl.Path).Handler(libhttp.NewErrorHandler(pkg.NewLoginCallbackHandler(cookieGenerator, stateGenerator, googleOAuth)))
+	for _, callbackPath := range callbackPaths {
+		router.Path(callbackPath).Handler(callbackHandler)
+	}
+	if signInPath != "" {
+		router.Path(signInPath).Handler(pkg.NewRateLimitMiddleware(loginRateLimiter, deps.metrics, "login")(
+			libhttp.NewErrorHandler(pkg.NewSignInHandler(stateGenerator, providers, deps.metrics, deps.auditLogger, deps.templates, a.SignInSkipSingle, config.CallbackHosts)),
+		))
+	}
+	router.Path("/tokens").Handler(libhttp.NewErrorHandler(pkg.NewAccessTokenHandler(pkg.NewAccessTokenManager(deps.sessionStore), deps.auditLogger)))
+	for _, provider := range config.Providers {
+		if provider.BackChannelLogout.Issuer == "" {
+			continue
+		}
+		router.Path(pkg.BackChannelLogoutPathOf(provider.ID)).Handler(libhttp.NewErrorHandler(pkg.NewBackChannelLogoutHandler(provider.ID, createLogoutTokenVerifier(provider, deps), deps.sessionStore, deps.auditLogger)))
+	}
+	router.Path("/logout").Handler(libhttp.NewErrorHandler(pkg.NewLogoutHandler(cookieGenerator, config.Cookie, deps.sessionStore, deps.auditLogger, deps.templates)))
+
+	handoffGenerator := pkg.NewHandoffGenerator([]byte(config.SigningKey))
+	if len(config.Handoff.Hosts) > 0 {
+		router.Path(pkg.HandoffAuthorizePath).Handler(libhttp.NewErrorHandler(pkg.NewHandoffAuthorizeHandler(handoffGenerator, config.Handoff.Hosts, deps.sessionStore, deps.auditLogger)))
+	}
+	if config.Handoff.AuthorizeURL != "" {
+		router.Path(pkg.HandoffExchangePath).Handler(pkg.NewRateLimitMiddleware(callbackRateLimiter, deps.metrics, "handoff")(
+			libhttp.NewErrorHandler(pkg.NewHandoffExchangeHandler(handoffGenerator, deps.replayStore, cookieGenerator, deps.sessionStore, deps.metrics, deps.auditLogger, deps.templates)),
+		))
+	}
 
+	if len(config.AdminGroups) > 0 {
+		router.PathPrefix(pkg.AdminPathPrefix).Handler(pkg.NewAdminHandler(config.AdminGroups, deps.sessionStore, config, deps.auditLogger))
+	}
+
+	// longest prefix first, mux uses the first matching route
+	upstreams := slices.Clone(config.Upstreams)
+	slices.SortFunc(upstreams, func(a, b pkg.Upstream) int {
+		return len(b.PathPrefix) - len(a.PathPrefix)
+	})
+	upstreamTokens := pkg.NewUpstreamTokens(deps.sessionStore, providers, deps.auditLogger)
+	for _, upstream := range upstreams {
+		target, err := url.Parse(upstream.URL)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "parse upstream url %s failed", upstream.URL)
+		}
+		var proxy http.Handler = httputil.NewSingleHostReverseProxy(target)
+		// only upstreams receive the provider token, handlers of the gateway see the request as sent
+		if config.ForwardAccessToken != "" {
+			proxy = pkg.NewForwardAccessTokenMiddleware(upstreamTokens, config.ForwardAccessToken)(proxy)
+		}
+		router.PathPrefix(upstream.PathPrefix).Handler(pkg.NewStripCredentialsMiddleware()(proxy))
+	}
 	router.Path("/").Handler(libhttp.NewErrorHandler(libhttp.WithErrorFunc(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
 		user := req.Header.Get(pkg.LoginHeaderName)
 		libhttp.WriteAndGlog(resp, "login %s success", user)
 		return nil
 	})))
+	// resolve scheme, host and client IP before any route sees the request
+	return pkg.NewTrustedProxyMiddleware(trustedProxies)(router), nil
+}
 
-	glog.V(2).Infof("starting http server listen on %s", a.Listen)
-	return libhttp.NewServer(
-		a.Listen,
-		router,
-	).Run(ctx)
+// createRateLimiters returns the rate limiters of login redirects and callbacks, they allow all requests if not configured
+func createRateLimiters(config pkg.Config) (pkg.RateLimiter, pkg.RateLimiter) {
+	if config.RateLimit.RequestsPerMinute == 0 {
+		return pkg.NewNoRateLimiter(), pkg.NewNoRateLimiter()
+	}
+	return pkg.NewIPRateLimiter(config.RateLimit), pkg.NewIPRateLimiter(config.RateLimit)
+}
+
+// createGroupResolver returns the resolver of Google Workspace groups if configured
+func (a *application) createGroupResolver(ctx context.Context, config pkg.GroupsConfig, deps dependencies) (pkg.GroupResolver, error) {
+	if config.CredentialsFile == "" {
+		return pkg.NewNoGroupResolver(), nil
+	}
+	httpClient, err := pkg.NewDirectoryHTTPClient(ctx, deps.providerClient, config.CredentialsFile, config.AdminEmail)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create directory http client failed")
+	}
+	cacheTTL := config.CacheTTL
+	if cacheTTL == 0 {
+		cacheTTL = 5 * time.Minute
+	}
+	return pkg.NewCachedGroupResolver(pkg.NewDirectoryGroupResolver(httpClient, pkg.DirectoryAPIURL, deps.metrics), cacheTTL), nil
+}
+
+// createLogoutTokenVerifier returns the verifier of logout tokens the provider issues for its client
+func createLogoutTokenVerifier(provider pkg.ProviderConfig, deps dependencies) pkg.LogoutTokenVerifier {
+	return pkg.NewLogoutTokenVerifier(
+		pkg.NewJWKS(deps.providerClient, provider.BackChannelLogout.JWKSURL),
+		[]string{provider.BackChannelLogout.Issuer},
+		strings.ReplaceAll(provider.ClientID, "client_id: ", ""),
+	)
+}
+
+// googleProvider returns the config of the Google provider if configured
+func googleProvider(config pkg.Config) (pkg.ProviderConfig, bool) {
+	pos := slices.IndexFunc(config.Providers, func(provider pkg.ProviderConfig) bool {
+		return provider.ID == pkg.ProviderGoogle
+	})
+	if pos == -1 {
+		return pkg.ProviderConfig{}, false
+	}
+	return config.Providers[pos], true
+}
+
+func (a *application) createTokenVerifiers(
+	ctx context.Context,
+	config pkg.Config,
+	deps dependencies,
+	cookieGenerator pkg.CookieGenerator,
+	groupResolver pkg.GroupResolver,
+) ([]pkg.TokenVerifier, error) {
+	verifiers := []pkg.TokenVerifier{
+		pkg.NewSessionTokenVerifier(cookieGenerator, deps.sessionStore, groupResolver),
+	}
+	google, ok := googleProvider(config)
+	if !ok {
+		return verifiers, nil
+	}
+	clientID := strings.ReplaceAll(google.ClientID, "client_id: ", "")
+	if deps.jwks != nil {
+		verifier, err := pkg.NewJWKSTokenVerifier(
+			ctx,
+			deps.jwks,
+			pkg.SplitList(a.BearerIssuers),
+			clientID,
+			google.Domain,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "create jwks token verifier failed")
+		}
+		verifiers = append(verifiers, verifier)
+	}
+	if a.IntrospectionURL != "" {
+		verifier, err := pkg.NewIntrospectionTokenVerifier(
+			ctx,
+			deps.providerClient,
+			a.IntrospectionURL,
+			clientID,
+			google.ClientSecret,
+			google.Domain,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "create introspection token verifier failed")
+		}
+		verifiers = append(verifiers, verifier)
+	}
+	return verifiers, nil
+}
+
+// createProviders returns the configured providers and their distinct callback paths
+func createProviders(ctx context.Context, gatewayConfig pkg.Config, httpClient *http.Client, metrics pkg.Metrics) (pkg.Providers, []string, error) {
+	var providers pkg.Providers
+	var callbackPaths []string
+	for _, config := range gatewayConfig.Providers {
+		options := config.AuthCodeOptions
+		if gatewayConfig.ForwardAccessToken != "" && options.AccessType == "" {
+			// a refresh token is needed to forward valid access tokens
+			options.AccessType = "offline"
+		}
+		switch config.ID {
+		case pkg.ProviderGoogle:
+			providers = append(providers, pkg.NewGoogleOAuth(
+				httpClient,
+				config.ClientID,
+				config.ClientSecret,
+				config.RedirectURL,
+				config.Domain,
+				options,
+				metrics,
+			))
+		case pkg.ProviderGitHub:
+			if config.Domain == "" {
+				glog.Warningf("WARNING: github provider has no domain, every GitHub account with a verified email can login")
+			}
+			providers = append(providers, pkg.NewGitHubOAuth(
+				httpClient,
+				config.ClientID,
+				config.ClientSecret,
+				config.RedirectURL,
+				config.Domain,
+				options,
+				metrics,
+			))
+		default:
+			return nil, nil, errors.Errorf(ctx, "provider %s unknown", config.ID)
+		}
+		callbackURL, err := url.Parse(config.RedirectURL)
+		if err != nil {
+			return nil, nil, errors.Wrapf(ctx, err, "parse redirect url %s failed", config.RedirectURL)
+		}
+		if !slices.Contains(callbackPaths, callbackURL.Path) {
+			callbackPaths = append(callbackPaths, callbackURL.Path)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, nil, errors.Errorf(ctx, "no provider configured")
+	}
+	return providers, callbackPaths, nil
+}
+
+func (a *application) createSessionStore(ctx context.Context) (pkg.SessionStore, error) {
+	if a.SessionStoreFile == "" {
+		return pkg.NewMemorySessionStore(), nil
+	}
+	return pkg.NewFileSessionStore(ctx, a.SessionStoreFile)
 }
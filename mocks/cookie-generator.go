@@ -23,11 +23,11 @@ type CookieGenerator struct {
 		result1 pkg.Cookie
 		result2 error
 	}
-	GenerateStub        func(context.Context, string) (pkg.Cookie, error)
+	GenerateStub        func(context.Context, pkg.Login) (pkg.Cookie, error)
 	generateMutex       sync.RWMutex
 	generateArgsForCall []struct {
 		arg1 context.Context
-		arg2 string
+		arg2 pkg.Login
 	}
 	generateReturns struct {
 		result1 pkg.Cookie
@@ -106,12 +106,12 @@ func (fake *CookieGenerator) DecodeReturnsOnCall(i int, result1 pkg.Cookie, resu
 	}{result1, result2}
 }
 
-func (fake *CookieGenerator) Generate(arg1 context.Context, arg2 string) (pkg.Cookie, error) {
+func (fake *CookieGenerator) Generate(arg1 context.Context, arg2 pkg.Login) (pkg.Cookie, error) {
 	fake.generateMutex.Lock()
 	ret, specificReturn := fake.generateReturnsOnCall[len(fake.generateArgsForCall)]
 	fake.generateArgsForCall = append(fake.generateArgsForCall, struct {
 		arg1 context.Context
-		arg2 string
+		arg2 pkg.Login
 	}{arg1, arg2})
 	stub := fake.GenerateStub
 	fakeReturns := fake.generateReturns
@@ -132,13 +132,13 @@ func (fake *CookieGenerator) GenerateCallCount() int {
 	return len(fake.generateArgsForCall)
 }
 
-func (fake *CookieGenerator) GenerateCalls(stub func(context.Context, string) (pkg.Cookie, error)) {
+func (fake *CookieGenerator) GenerateCalls(stub func(context.Context, pkg.Login) (pkg.Cookie, error)) {
 	fake.generateMutex.Lock()
 	defer fake.generateMutex.Unlock()
 	fake.GenerateStub = stub
 }
 
-func (fake *CookieGenerator) GenerateArgsForCall(i int) (context.Context, string) {
+func (fake *CookieGenerator) GenerateArgsForCall(i int) (context.Context, pkg.Login) {
 	fake.generateMutex.RLock()
 	defer fake.generateMutex.RUnlock()
 	argsForCall := fake.generateArgsForCall[i]
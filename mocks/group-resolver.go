@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type GroupResolver struct {
+	GroupsStub        func(context.Context, string, string) ([]string, error)
+	groupsMutex       sync.RWMutex
+	groupsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	groupsReturns struct {
+		result1 []string
+		result2 error
+	}
+	groupsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *GroupResolver) Groups(arg1 context.Context, arg2 string, arg3 string) ([]string, error) {
+	fake.groupsMutex.Lock()
+	ret, specificReturn := fake.groupsReturnsOnCall[len(fake.groupsArgsForCall)]
+	fake.groupsArgsForCall = append(fake.groupsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.GroupsStub
+	fakeReturns := fake.groupsReturns
+	fake.recordInvocation("Groups", []interface{}{arg1, arg2, arg3})
+	fake.groupsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *GroupResolver) GroupsCallCount() int {
+	fake.groupsMutex.RLock()
+	defer fake.groupsMutex.RUnlock()
+	return len(fake.groupsArgsForCall)
+}
+
+func (fake *GroupResolver) GroupsCalls(stub func(context.Context, string, string) ([]string, error)) {
+	fake.groupsMutex.Lock()
+	defer fake.groupsMutex.Unlock()
+	fake.GroupsStub = stub
+}
+
+func (fake *GroupResolver) GroupsArgsForCall(i int) (context.Context, string, string) {
+	fake.groupsMutex.RLock()
+	defer fake.groupsMutex.RUnlock()
+	argsForCall := fake.groupsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *GroupResolver) GroupsReturns(result1 []string, result2 error) {
+	fake.groupsMutex.Lock()
+	defer fake.groupsMutex.Unlock()
+	fake.GroupsStub = nil
+	fake.groupsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *GroupResolver) GroupsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.groupsMutex.Lock()
+	defer fake.groupsMutex.Unlock()
+	fake.GroupsStub = nil
+	if fake.groupsReturnsOnCall == nil {
+		fake.groupsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.groupsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *GroupResolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *GroupResolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.GroupResolver = new(GroupResolver)
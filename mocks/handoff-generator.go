@@ -0,0 +1,206 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type HandoffGenerator struct {
+	DecodeStub        func(context.Context, string, string) (pkg.HandoffCode, error)
+	decodeMutex       sync.RWMutex
+	decodeArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	decodeReturns struct {
+		result1 pkg.HandoffCode
+		result2 error
+	}
+	decodeReturnsOnCall map[int]struct {
+		result1 pkg.HandoffCode
+		result2 error
+	}
+	GenerateStub        func(context.Context, pkg.Identity, pkg.Session, string, string, string) (pkg.HandoffCode, error)
+	generateMutex       sync.RWMutex
+	generateArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.Identity
+		arg3 pkg.Session
+		arg4 string
+		arg5 string
+		arg6 string
+	}
+	generateReturns struct {
+		result1 pkg.HandoffCode
+		result2 error
+	}
+	generateReturnsOnCall map[int]struct {
+		result1 pkg.HandoffCode
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *HandoffGenerator) Decode(arg1 context.Context, arg2 string, arg3 string) (pkg.HandoffCode, error) {
+	fake.decodeMutex.Lock()
+	ret, specificReturn := fake.decodeReturnsOnCall[len(fake.decodeArgsForCall)]
+	fake.decodeArgsForCall = append(fake.decodeArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.DecodeStub
+	fakeReturns := fake.decodeReturns
+	fake.recordInvocation("Decode", []interface{}{arg1, arg2, arg3})
+	fake.decodeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *HandoffGenerator) DecodeCallCount() int {
+	fake.decodeMutex.RLock()
+	defer fake.decodeMutex.RUnlock()
+	return len(fake.decodeArgsForCall)
+}
+
+func (fake *HandoffGenerator) DecodeCalls(stub func(context.Context, string, string) (pkg.HandoffCode, error)) {
+	fake.decodeMutex.Lock()
+	defer fake.decodeMutex.Unlock()
+	fake.DecodeStub = stub
+}
+
+func (fake *HandoffGenerator) DecodeArgsForCall(i int) (context.Context, string, string) {
+	fake.decodeMutex.RLock()
+	defer fake.decodeMutex.RUnlock()
+	argsForCall := fake.decodeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *HandoffGenerator) DecodeReturns(result1 pkg.HandoffCode, result2 error) {
+	fake.decodeMutex.Lock()
+	defer fake.decodeMutex.Unlock()
+	fake.DecodeStub = nil
+	fake.decodeReturns = struct {
+		result1 pkg.HandoffCode
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *HandoffGenerator) DecodeReturnsOnCall(i int, result1 pkg.HandoffCode, result2 error) {
+	fake.decodeMutex.Lock()
+	defer fake.decodeMutex.Unlock()
+	fake.DecodeStub = nil
+	if fake.decodeReturnsOnCall == nil {
+		fake.decodeReturnsOnCall = make(map[int]struct {
+			result1 pkg.HandoffCode
+			result2 error
+		})
+	}
+	fake.decodeReturnsOnCall[i] = struct {
+		result1 pkg.HandoffCode
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *HandoffGenerator) Generate(arg1 context.Context, arg2 pkg.Identity, arg3 pkg.Session, arg4 string, arg5 string, arg6 string) (pkg.HandoffCode, error) {
+	fake.generateMutex.Lock()
+	ret, specificReturn := fake.generateReturnsOnCall[len(fake.generateArgsForCall)]
+	fake.generateArgsForCall = append(fake.generateArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.Identity
+		arg3 pkg.Session
+		arg4 string
+		arg5 string
+		arg6 string
+	}{arg1, arg2, arg3, arg4, arg5, arg6})
+	stub := fake.GenerateStub
+	fakeReturns := fake.generateReturns
+	fake.recordInvocation("Generate", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6})
+	fake.generateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *HandoffGenerator) GenerateCallCount() int {
+	fake.generateMutex.RLock()
+	defer fake.generateMutex.RUnlock()
+	return len(fake.generateArgsForCall)
+}
+
+func (fake *HandoffGenerator) GenerateCalls(stub func(context.Context, pkg.Identity, pkg.Session, string, string, string) (pkg.HandoffCode, error)) {
+	fake.generateMutex.Lock()
+	defer fake.generateMutex.Unlock()
+	fake.GenerateStub = stub
+}
+
+func (fake *HandoffGenerator) GenerateArgsForCall(i int) (context.Context, pkg.Identity, pkg.Session, string, string, string) {
+	fake.generateMutex.RLock()
+	defer fake.generateMutex.RUnlock()
+	argsForCall := fake.generateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
+}
+
+func (fake *HandoffGenerator) GenerateReturns(result1 pkg.HandoffCode, result2 error) {
+	fake.generateMutex.Lock()
+	defer fake.generateMutex.Unlock()
+	fake.GenerateStub = nil
+	fake.generateReturns = struct {
+		result1 pkg.HandoffCode
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *HandoffGenerator) GenerateReturnsOnCall(i int, result1 pkg.HandoffCode, result2 error) {
+	fake.generateMutex.Lock()
+	defer fake.generateMutex.Unlock()
+	fake.GenerateStub = nil
+	if fake.generateReturnsOnCall == nil {
+		fake.generateReturnsOnCall = make(map[int]struct {
+			result1 pkg.HandoffCode
+			result2 error
+		})
+	}
+	fake.generateReturnsOnCall[i] = struct {
+		result1 pkg.HandoffCode
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *HandoffGenerator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *HandoffGenerator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.HandoffGenerator = new(HandoffGenerator)
@@ -0,0 +1,305 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type Metrics struct {
+	CallbackFailureStub        func(string, pkg.CallbackFailureReason)
+	callbackFailureMutex       sync.RWMutex
+	callbackFailureArgsForCall []struct {
+		arg1 string
+		arg2 pkg.CallbackFailureReason
+	}
+	CallbackSuccessStub        func(string)
+	callbackSuccessMutex       sync.RWMutex
+	callbackSuccessArgsForCall []struct {
+		arg1 string
+	}
+	CookieDecodeFailureStub        func(pkg.TokenErrorReason)
+	cookieDecodeFailureMutex       sync.RWMutex
+	cookieDecodeFailureArgsForCall []struct {
+		arg1 pkg.TokenErrorReason
+	}
+	LoginRedirectStub        func(string)
+	loginRedirectMutex       sync.RWMutex
+	loginRedirectArgsForCall []struct {
+		arg1 string
+	}
+	LoginUnauthorizedStub        func(string)
+	loginUnauthorizedMutex       sync.RWMutex
+	loginUnauthorizedArgsForCall []struct {
+		arg1 string
+	}
+	ProviderRequestStub        func(string, string, time.Duration, error)
+	providerRequestMutex       sync.RWMutex
+	providerRequestArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 time.Duration
+		arg4 error
+	}
+	RateLimitedStub        func(string)
+	rateLimitedMutex       sync.RWMutex
+	rateLimitedArgsForCall []struct {
+		arg1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *Metrics) CallbackFailure(arg1 string, arg2 pkg.CallbackFailureReason) {
+	fake.callbackFailureMutex.Lock()
+	fake.callbackFailureArgsForCall = append(fake.callbackFailureArgsForCall, struct {
+		arg1 string
+		arg2 pkg.CallbackFailureReason
+	}{arg1, arg2})
+	stub := fake.CallbackFailureStub
+	fake.recordInvocation("CallbackFailure", []interface{}{arg1, arg2})
+	fake.callbackFailureMutex.Unlock()
+	if stub != nil {
+		fake.CallbackFailureStub(arg1, arg2)
+	}
+}
+
+func (fake *Metrics) CallbackFailureCallCount() int {
+	fake.callbackFailureMutex.RLock()
+	defer fake.callbackFailureMutex.RUnlock()
+	return len(fake.callbackFailureArgsForCall)
+}
+
+func (fake *Metrics) CallbackFailureCalls(stub func(string, pkg.CallbackFailureReason)) {
+	fake.callbackFailureMutex.Lock()
+	defer fake.callbackFailureMutex.Unlock()
+	fake.CallbackFailureStub = stub
+}
+
+func (fake *Metrics) CallbackFailureArgsForCall(i int) (string, pkg.CallbackFailureReason) {
+	fake.callbackFailureMutex.RLock()
+	defer fake.callbackFailureMutex.RUnlock()
+	argsForCall := fake.callbackFailureArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Metrics) CallbackSuccess(arg1 string) {
+	fake.callbackSuccessMutex.Lock()
+	fake.callbackSuccessArgsForCall = append(fake.callbackSuccessArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CallbackSuccessStub
+	fake.recordInvocation("CallbackSuccess", []interface{}{arg1})
+	fake.callbackSuccessMutex.Unlock()
+	if stub != nil {
+		fake.CallbackSuccessStub(arg1)
+	}
+}
+
+func (fake *Metrics) CallbackSuccessCallCount() int {
+	fake.callbackSuccessMutex.RLock()
+	defer fake.callbackSuccessMutex.RUnlock()
+	return len(fake.callbackSuccessArgsForCall)
+}
+
+func (fake *Metrics) CallbackSuccessCalls(stub func(string)) {
+	fake.callbackSuccessMutex.Lock()
+	defer fake.callbackSuccessMutex.Unlock()
+	fake.CallbackSuccessStub = stub
+}
+
+func (fake *Metrics) CallbackSuccessArgsForCall(i int) string {
+	fake.callbackSuccessMutex.RLock()
+	defer fake.callbackSuccessMutex.RUnlock()
+	argsForCall := fake.callbackSuccessArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Metrics) CookieDecodeFailure(arg1 pkg.TokenErrorReason) {
+	fake.cookieDecodeFailureMutex.Lock()
+	fake.cookieDecodeFailureArgsForCall = append(fake.cookieDecodeFailureArgsForCall, struct {
+		arg1 pkg.TokenErrorReason
+	}{arg1})
+	stub := fake.CookieDecodeFailureStub
+	fake.recordInvocation("CookieDecodeFailure", []interface{}{arg1})
+	fake.cookieDecodeFailureMutex.Unlock()
+	if stub != nil {
+		fake.CookieDecodeFailureStub(arg1)
+	}
+}
+
+func (fake *Metrics) CookieDecodeFailureCallCount() int {
+	fake.cookieDecodeFailureMutex.RLock()
+	defer fake.cookieDecodeFailureMutex.RUnlock()
+	return len(fake.cookieDecodeFailureArgsForCall)
+}
+
+func (fake *Metrics) CookieDecodeFailureCalls(stub func(pkg.TokenErrorReason)) {
+	fake.cookieDecodeFailureMutex.Lock()
+	defer fake.cookieDecodeFailureMutex.Unlock()
+	fake.CookieDecodeFailureStub = stub
+}
+
+func (fake *Metrics) CookieDecodeFailureArgsForCall(i int) pkg.TokenErrorReason {
+	fake.cookieDecodeFailureMutex.RLock()
+	defer fake.cookieDecodeFailureMutex.RUnlock()
+	argsForCall := fake.cookieDecodeFailureArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Metrics) LoginRedirect(arg1 string) {
+	fake.loginRedirectMutex.Lock()
+	fake.loginRedirectArgsForCall = append(fake.loginRedirectArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LoginRedirectStub
+	fake.recordInvocation("LoginRedirect", []interface{}{arg1})
+	fake.loginRedirectMutex.Unlock()
+	if stub != nil {
+		fake.LoginRedirectStub(arg1)
+	}
+}
+
+func (fake *Metrics) LoginRedirectCallCount() int {
+	fake.loginRedirectMutex.RLock()
+	defer fake.loginRedirectMutex.RUnlock()
+	return len(fake.loginRedirectArgsForCall)
+}
+
+func (fake *Metrics) LoginRedirectCalls(stub func(string)) {
+	fake.loginRedirectMutex.Lock()
+	defer fake.loginRedirectMutex.Unlock()
+	fake.LoginRedirectStub = stub
+}
+
+func (fake *Metrics) LoginRedirectArgsForCall(i int) string {
+	fake.loginRedirectMutex.RLock()
+	defer fake.loginRedirectMutex.RUnlock()
+	argsForCall := fake.loginRedirectArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Metrics) LoginUnauthorized(arg1 string) {
+	fake.loginUnauthorizedMutex.Lock()
+	fake.loginUnauthorizedArgsForCall = append(fake.loginUnauthorizedArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LoginUnauthorizedStub
+	fake.recordInvocation("LoginUnauthorized", []interface{}{arg1})
+	fake.loginUnauthorizedMutex.Unlock()
+	if stub != nil {
+		fake.LoginUnauthorizedStub(arg1)
+	}
+}
+
+func (fake *Metrics) LoginUnauthorizedCallCount() int {
+	fake.loginUnauthorizedMutex.RLock()
+	defer fake.loginUnauthorizedMutex.RUnlock()
+	return len(fake.loginUnauthorizedArgsForCall)
+}
+
+func (fake *Metrics) LoginUnauthorizedCalls(stub func(string)) {
+	fake.loginUnauthorizedMutex.Lock()
+	defer fake.loginUnauthorizedMutex.Unlock()
+	fake.LoginUnauthorizedStub = stub
+}
+
+func (fake *Metrics) LoginUnauthorizedArgsForCall(i int) string {
+	fake.loginUnauthorizedMutex.RLock()
+	defer fake.loginUnauthorizedMutex.RUnlock()
+	argsForCall := fake.loginUnauthorizedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Metrics) ProviderRequest(arg1 string, arg2 string, arg3 time.Duration, arg4 error) {
+	fake.providerRequestMutex.Lock()
+	fake.providerRequestArgsForCall = append(fake.providerRequestArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 time.Duration
+		arg4 error
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.ProviderRequestStub
+	fake.recordInvocation("ProviderRequest", []interface{}{arg1, arg2, arg3, arg4})
+	fake.providerRequestMutex.Unlock()
+	if stub != nil {
+		fake.ProviderRequestStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *Metrics) ProviderRequestCallCount() int {
+	fake.providerRequestMutex.RLock()
+	defer fake.providerRequestMutex.RUnlock()
+	return len(fake.providerRequestArgsForCall)
+}
+
+func (fake *Metrics) ProviderRequestCalls(stub func(string, string, time.Duration, error)) {
+	fake.providerRequestMutex.Lock()
+	defer fake.providerRequestMutex.Unlock()
+	fake.ProviderRequestStub = stub
+}
+
+func (fake *Metrics) ProviderRequestArgsForCall(i int) (string, string, time.Duration, error) {
+	fake.providerRequestMutex.RLock()
+	defer fake.providerRequestMutex.RUnlock()
+	argsForCall := fake.providerRequestArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *Metrics) RateLimited(arg1 string) {
+	fake.rateLimitedMutex.Lock()
+	fake.rateLimitedArgsForCall = append(fake.rateLimitedArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.RateLimitedStub
+	fake.recordInvocation("RateLimited", []interface{}{arg1})
+	fake.rateLimitedMutex.Unlock()
+	if stub != nil {
+		fake.RateLimitedStub(arg1)
+	}
+}
+
+func (fake *Metrics) RateLimitedCallCount() int {
+	fake.rateLimitedMutex.RLock()
+	defer fake.rateLimitedMutex.RUnlock()
+	return len(fake.rateLimitedArgsForCall)
+}
+
+func (fake *Metrics) RateLimitedCalls(stub func(string)) {
+	fake.rateLimitedMutex.Lock()
+	defer fake.rateLimitedMutex.Unlock()
+	fake.RateLimitedStub = stub
+}
+
+func (fake *Metrics) RateLimitedArgsForCall(i int) string {
+	fake.rateLimitedMutex.RLock()
+	defer fake.rateLimitedMutex.RUnlock()
+	argsForCall := fake.rateLimitedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Metrics) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *Metrics) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.Metrics = new(Metrics)
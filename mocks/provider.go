@@ -0,0 +1,525 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+	"golang.org/x/oauth2"
+)
+
+type Provider struct {
+	AuthCodeURLStub        func(pkg.State, pkg.AuthRequest) string
+	authCodeURLMutex       sync.RWMutex
+	authCodeURLArgsForCall []struct {
+		arg1 pkg.State
+		arg2 pkg.AuthRequest
+	}
+	authCodeURLReturns struct {
+		result1 string
+	}
+	authCodeURLReturnsOnCall map[int]struct {
+		result1 string
+	}
+	IDStub        func() string
+	iDMutex       sync.RWMutex
+	iDArgsForCall []struct {
+	}
+	iDReturns struct {
+		result1 string
+	}
+	iDReturnsOnCall map[int]struct {
+		result1 string
+	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct {
+	}
+	nameReturns struct {
+		result1 string
+	}
+	nameReturnsOnCall map[int]struct {
+		result1 string
+	}
+	RedirectURLStub        func() string
+	redirectURLMutex       sync.RWMutex
+	redirectURLArgsForCall []struct {
+	}
+	redirectURLReturns struct {
+		result1 string
+	}
+	redirectURLReturnsOnCall map[int]struct {
+		result1 string
+	}
+	RefreshTokenStub        func(context.Context, *oauth2.Token) (*oauth2.Token, error)
+	refreshTokenMutex       sync.RWMutex
+	refreshTokenArgsForCall []struct {
+		arg1 context.Context
+		arg2 *oauth2.Token
+	}
+	refreshTokenReturns struct {
+		result1 *oauth2.Token
+		result2 error
+	}
+	refreshTokenReturnsOnCall map[int]struct {
+		result1 *oauth2.Token
+		result2 error
+	}
+	StepUpSupportedStub        func() bool
+	stepUpSupportedMutex       sync.RWMutex
+	stepUpSupportedArgsForCall []struct {
+	}
+	stepUpSupportedReturns struct {
+		result1 bool
+	}
+	stepUpSupportedReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	UserInfoStub        func(context.Context, pkg.Code, string) (*pkg.UserInfo, error)
+	userInfoMutex       sync.RWMutex
+	userInfoArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.Code
+		arg3 string
+	}
+	userInfoReturns struct {
+		result1 *pkg.UserInfo
+		result2 error
+	}
+	userInfoReturnsOnCall map[int]struct {
+		result1 *pkg.UserInfo
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *Provider) AuthCodeURL(arg1 pkg.State, arg2 pkg.AuthRequest) string {
+	fake.authCodeURLMutex.Lock()
+	ret, specificReturn := fake.authCodeURLReturnsOnCall[len(fake.authCodeURLArgsForCall)]
+	fake.authCodeURLArgsForCall = append(fake.authCodeURLArgsForCall, struct {
+		arg1 pkg.State
+		arg2 pkg.AuthRequest
+	}{arg1, arg2})
+	stub := fake.AuthCodeURLStub
+	fakeReturns := fake.authCodeURLReturns
+	fake.recordInvocation("AuthCodeURL", []interface{}{arg1, arg2})
+	fake.authCodeURLMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Provider) AuthCodeURLCallCount() int {
+	fake.authCodeURLMutex.RLock()
+	defer fake.authCodeURLMutex.RUnlock()
+	return len(fake.authCodeURLArgsForCall)
+}
+
+func (fake *Provider) AuthCodeURLCalls(stub func(pkg.State, pkg.AuthRequest) string) {
+	fake.authCodeURLMutex.Lock()
+	defer fake.authCodeURLMutex.Unlock()
+	fake.AuthCodeURLStub = stub
+}
+
+func (fake *Provider) AuthCodeURLArgsForCall(i int) (pkg.State, pkg.AuthRequest) {
+	fake.authCodeURLMutex.RLock()
+	defer fake.authCodeURLMutex.RUnlock()
+	argsForCall := fake.authCodeURLArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Provider) AuthCodeURLReturns(result1 string) {
+	fake.authCodeURLMutex.Lock()
+	defer fake.authCodeURLMutex.Unlock()
+	fake.AuthCodeURLStub = nil
+	fake.authCodeURLReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Provider) AuthCodeURLReturnsOnCall(i int, result1 string) {
+	fake.authCodeURLMutex.Lock()
+	defer fake.authCodeURLMutex.Unlock()
+	fake.AuthCodeURLStub = nil
+	if fake.authCodeURLReturnsOnCall == nil {
+		fake.authCodeURLReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.authCodeURLReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Provider) ID() string {
+	fake.iDMutex.Lock()
+	ret, specificReturn := fake.iDReturnsOnCall[len(fake.iDArgsForCall)]
+	fake.iDArgsForCall = append(fake.iDArgsForCall, struct {
+	}{})
+	stub := fake.IDStub
+	fakeReturns := fake.iDReturns
+	fake.recordInvocation("ID", []interface{}{})
+	fake.iDMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Provider) IDCallCount() int {
+	fake.iDMutex.RLock()
+	defer fake.iDMutex.RUnlock()
+	return len(fake.iDArgsForCall)
+}
+
+func (fake *Provider) IDCalls(stub func() string) {
+	fake.iDMutex.Lock()
+	defer fake.iDMutex.Unlock()
+	fake.IDStub = stub
+}
+
+func (fake *Provider) IDReturns(result1 string) {
+	fake.iDMutex.Lock()
+	defer fake.iDMutex.Unlock()
+	fake.IDStub = nil
+	fake.iDReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Provider) IDReturnsOnCall(i int, result1 string) {
+	fake.iDMutex.Lock()
+	defer fake.iDMutex.Unlock()
+	fake.IDStub = nil
+	if fake.iDReturnsOnCall == nil {
+		fake.iDReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.iDReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Provider) Name() string {
+	fake.nameMutex.Lock()
+	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct {
+	}{})
+	stub := fake.NameStub
+	fakeReturns := fake.nameReturns
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Provider) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *Provider) NameCalls(stub func() string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = stub
+}
+
+func (fake *Provider) NameReturns(result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Provider) NameReturnsOnCall(i int, result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	if fake.nameReturnsOnCall == nil {
+		fake.nameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.nameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Provider) RedirectURL() string {
+	fake.redirectURLMutex.Lock()
+	ret, specificReturn := fake.redirectURLReturnsOnCall[len(fake.redirectURLArgsForCall)]
+	fake.redirectURLArgsForCall = append(fake.redirectURLArgsForCall, struct {
+	}{})
+	stub := fake.RedirectURLStub
+	fakeReturns := fake.redirectURLReturns
+	fake.recordInvocation("RedirectURL", []interface{}{})
+	fake.redirectURLMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Provider) RedirectURLCallCount() int {
+	fake.redirectURLMutex.RLock()
+	defer fake.redirectURLMutex.RUnlock()
+	return len(fake.redirectURLArgsForCall)
+}
+
+func (fake *Provider) RedirectURLCalls(stub func() string) {
+	fake.redirectURLMutex.Lock()
+	defer fake.redirectURLMutex.Unlock()
+	fake.RedirectURLStub = stub
+}
+
+func (fake *Provider) RedirectURLReturns(result1 string) {
+	fake.redirectURLMutex.Lock()
+	defer fake.redirectURLMutex.Unlock()
+	fake.RedirectURLStub = nil
+	fake.redirectURLReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Provider) RedirectURLReturnsOnCall(i int, result1 string) {
+	fake.redirectURLMutex.Lock()
+	defer fake.redirectURLMutex.Unlock()
+	fake.RedirectURLStub = nil
+	if fake.redirectURLReturnsOnCall == nil {
+		fake.redirectURLReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.redirectURLReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Provider) RefreshToken(arg1 context.Context, arg2 *oauth2.Token) (*oauth2.Token, error) {
+	fake.refreshTokenMutex.Lock()
+	ret, specificReturn := fake.refreshTokenReturnsOnCall[len(fake.refreshTokenArgsForCall)]
+	fake.refreshTokenArgsForCall = append(fake.refreshTokenArgsForCall, struct {
+		arg1 context.Context
+		arg2 *oauth2.Token
+	}{arg1, arg2})
+	stub := fake.RefreshTokenStub
+	fakeReturns := fake.refreshTokenReturns
+	fake.recordInvocation("RefreshToken", []interface{}{arg1, arg2})
+	fake.refreshTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Provider) RefreshTokenCallCount() int {
+	fake.refreshTokenMutex.RLock()
+	defer fake.refreshTokenMutex.RUnlock()
+	return len(fake.refreshTokenArgsForCall)
+}
+
+func (fake *Provider) RefreshTokenCalls(stub func(context.Context, *oauth2.Token) (*oauth2.Token, error)) {
+	fake.refreshTokenMutex.Lock()
+	defer fake.refreshTokenMutex.Unlock()
+	fake.RefreshTokenStub = stub
+}
+
+func (fake *Provider) RefreshTokenArgsForCall(i int) (context.Context, *oauth2.Token) {
+	fake.refreshTokenMutex.RLock()
+	defer fake.refreshTokenMutex.RUnlock()
+	argsForCall := fake.refreshTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Provider) RefreshTokenReturns(result1 *oauth2.Token, result2 error) {
+	fake.refreshTokenMutex.Lock()
+	defer fake.refreshTokenMutex.Unlock()
+	fake.RefreshTokenStub = nil
+	fake.refreshTokenReturns = struct {
+		result1 *oauth2.Token
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Provider) RefreshTokenReturnsOnCall(i int, result1 *oauth2.Token, result2 error) {
+	fake.refreshTokenMutex.Lock()
+	defer fake.refreshTokenMutex.Unlock()
+	fake.RefreshTokenStub = nil
+	if fake.refreshTokenReturnsOnCall == nil {
+		fake.refreshTokenReturnsOnCall = make(map[int]struct {
+			result1 *oauth2.Token
+			result2 error
+		})
+	}
+	fake.refreshTokenReturnsOnCall[i] = struct {
+		result1 *oauth2.Token
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Provider) StepUpSupported() bool {
+	fake.stepUpSupportedMutex.Lock()
+	ret, specificReturn := fake.stepUpSupportedReturnsOnCall[len(fake.stepUpSupportedArgsForCall)]
+	fake.stepUpSupportedArgsForCall = append(fake.stepUpSupportedArgsForCall, struct {
+	}{})
+	stub := fake.StepUpSupportedStub
+	fakeReturns := fake.stepUpSupportedReturns
+	fake.recordInvocation("StepUpSupported", []interface{}{})
+	fake.stepUpSupportedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Provider) StepUpSupportedCallCount() int {
+	fake.stepUpSupportedMutex.RLock()
+	defer fake.stepUpSupportedMutex.RUnlock()
+	return len(fake.stepUpSupportedArgsForCall)
+}
+
+func (fake *Provider) StepUpSupportedCalls(stub func() bool) {
+	fake.stepUpSupportedMutex.Lock()
+	defer fake.stepUpSupportedMutex.Unlock()
+	fake.StepUpSupportedStub = stub
+}
+
+func (fake *Provider) StepUpSupportedReturns(result1 bool) {
+	fake.stepUpSupportedMutex.Lock()
+	defer fake.stepUpSupportedMutex.Unlock()
+	fake.StepUpSupportedStub = nil
+	fake.stepUpSupportedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *Provider) StepUpSupportedReturnsOnCall(i int, result1 bool) {
+	fake.stepUpSupportedMutex.Lock()
+	defer fake.stepUpSupportedMutex.Unlock()
+	fake.StepUpSupportedStub = nil
+	if fake.stepUpSupportedReturnsOnCall == nil {
+		fake.stepUpSupportedReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.stepUpSupportedReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *Provider) UserInfo(arg1 context.Context, arg2 pkg.Code, arg3 string) (*pkg.UserInfo, error) {
+	fake.userInfoMutex.Lock()
+	ret, specificReturn := fake.userInfoReturnsOnCall[len(fake.userInfoArgsForCall)]
+	fake.userInfoArgsForCall = append(fake.userInfoArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.Code
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.UserInfoStub
+	fakeReturns := fake.userInfoReturns
+	fake.recordInvocation("UserInfo", []interface{}{arg1, arg2, arg3})
+	fake.userInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Provider) UserInfoCallCount() int {
+	fake.userInfoMutex.RLock()
+	defer fake.userInfoMutex.RUnlock()
+	return len(fake.userInfoArgsForCall)
+}
+
+func (fake *Provider) UserInfoCalls(stub func(context.Context, pkg.Code, string) (*pkg.UserInfo, error)) {
+	fake.userInfoMutex.Lock()
+	defer fake.userInfoMutex.Unlock()
+	fake.UserInfoStub = stub
+}
+
+func (fake *Provider) UserInfoArgsForCall(i int) (context.Context, pkg.Code, string) {
+	fake.userInfoMutex.RLock()
+	defer fake.userInfoMutex.RUnlock()
+	argsForCall := fake.userInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *Provider) UserInfoReturns(result1 *pkg.UserInfo, result2 error) {
+	fake.userInfoMutex.Lock()
+	defer fake.userInfoMutex.Unlock()
+	fake.UserInfoStub = nil
+	fake.userInfoReturns = struct {
+		result1 *pkg.UserInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Provider) UserInfoReturnsOnCall(i int, result1 *pkg.UserInfo, result2 error) {
+	fake.userInfoMutex.Lock()
+	defer fake.userInfoMutex.Unlock()
+	fake.UserInfoStub = nil
+	if fake.userInfoReturnsOnCall == nil {
+		fake.userInfoReturnsOnCall = make(map[int]struct {
+			result1 *pkg.UserInfo
+			result2 error
+		})
+	}
+	fake.userInfoReturnsOnCall[i] = struct {
+		result1 *pkg.UserInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Provider) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *Provider) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.Provider = new(Provider)
@@ -0,0 +1,116 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type RateLimiter struct {
+	AllowStub        func(*http.Request) (time.Duration, bool)
+	allowMutex       sync.RWMutex
+	allowArgsForCall []struct {
+		arg1 *http.Request
+	}
+	allowReturns struct {
+		result1 time.Duration
+		result2 bool
+	}
+	allowReturnsOnCall map[int]struct {
+		result1 time.Duration
+		result2 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *RateLimiter) Allow(arg1 *http.Request) (time.Duration, bool) {
+	fake.allowMutex.Lock()
+	ret, specificReturn := fake.allowReturnsOnCall[len(fake.allowArgsForCall)]
+	fake.allowArgsForCall = append(fake.allowArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	stub := fake.AllowStub
+	fakeReturns := fake.allowReturns
+	fake.recordInvocation("Allow", []interface{}{arg1})
+	fake.allowMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *RateLimiter) AllowCallCount() int {
+	fake.allowMutex.RLock()
+	defer fake.allowMutex.RUnlock()
+	return len(fake.allowArgsForCall)
+}
+
+func (fake *RateLimiter) AllowCalls(stub func(*http.Request) (time.Duration, bool)) {
+	fake.allowMutex.Lock()
+	defer fake.allowMutex.Unlock()
+	fake.AllowStub = stub
+}
+
+func (fake *RateLimiter) AllowArgsForCall(i int) *http.Request {
+	fake.allowMutex.RLock()
+	defer fake.allowMutex.RUnlock()
+	argsForCall := fake.allowArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *RateLimiter) AllowReturns(result1 time.Duration, result2 bool) {
+	fake.allowMutex.Lock()
+	defer fake.allowMutex.Unlock()
+	fake.AllowStub = nil
+	fake.allowReturns = struct {
+		result1 time.Duration
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *RateLimiter) AllowReturnsOnCall(i int, result1 time.Duration, result2 bool) {
+	fake.allowMutex.Lock()
+	defer fake.allowMutex.Unlock()
+	fake.AllowStub = nil
+	if fake.allowReturnsOnCall == nil {
+		fake.allowReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+			result2 bool
+		})
+	}
+	fake.allowReturnsOnCall[i] = struct {
+		result1 time.Duration
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *RateLimiter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *RateLimiter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.RateLimiter = new(RateLimiter)
@@ -0,0 +1,215 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type ReloadableHandler struct {
+	ApplyStub        func(context.Context, pkg.Config) error
+	applyMutex       sync.RWMutex
+	applyArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.Config
+	}
+	applyReturns struct {
+		result1 error
+	}
+	applyReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ConfigStub        func() pkg.Config
+	configMutex       sync.RWMutex
+	configArgsForCall []struct {
+	}
+	configReturns struct {
+		result1 pkg.Config
+	}
+	configReturnsOnCall map[int]struct {
+		result1 pkg.Config
+	}
+	ServeHTTPStub        func(http.ResponseWriter, *http.Request)
+	serveHTTPMutex       sync.RWMutex
+	serveHTTPArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ReloadableHandler) Apply(arg1 context.Context, arg2 pkg.Config) error {
+	fake.applyMutex.Lock()
+	ret, specificReturn := fake.applyReturnsOnCall[len(fake.applyArgsForCall)]
+	fake.applyArgsForCall = append(fake.applyArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.Config
+	}{arg1, arg2})
+	stub := fake.ApplyStub
+	fakeReturns := fake.applyReturns
+	fake.recordInvocation("Apply", []interface{}{arg1, arg2})
+	fake.applyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *ReloadableHandler) ApplyCallCount() int {
+	fake.applyMutex.RLock()
+	defer fake.applyMutex.RUnlock()
+	return len(fake.applyArgsForCall)
+}
+
+func (fake *ReloadableHandler) ApplyCalls(stub func(context.Context, pkg.Config) error) {
+	fake.applyMutex.Lock()
+	defer fake.applyMutex.Unlock()
+	fake.ApplyStub = stub
+}
+
+func (fake *ReloadableHandler) ApplyArgsForCall(i int) (context.Context, pkg.Config) {
+	fake.applyMutex.RLock()
+	defer fake.applyMutex.RUnlock()
+	argsForCall := fake.applyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *ReloadableHandler) ApplyReturns(result1 error) {
+	fake.applyMutex.Lock()
+	defer fake.applyMutex.Unlock()
+	fake.ApplyStub = nil
+	fake.applyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ReloadableHandler) ApplyReturnsOnCall(i int, result1 error) {
+	fake.applyMutex.Lock()
+	defer fake.applyMutex.Unlock()
+	fake.ApplyStub = nil
+	if fake.applyReturnsOnCall == nil {
+		fake.applyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.applyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ReloadableHandler) Config() pkg.Config {
+	fake.configMutex.Lock()
+	ret, specificReturn := fake.configReturnsOnCall[len(fake.configArgsForCall)]
+	fake.configArgsForCall = append(fake.configArgsForCall, struct {
+	}{})
+	stub := fake.ConfigStub
+	fakeReturns := fake.configReturns
+	fake.recordInvocation("Config", []interface{}{})
+	fake.configMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *ReloadableHandler) ConfigCallCount() int {
+	fake.configMutex.RLock()
+	defer fake.configMutex.RUnlock()
+	return len(fake.configArgsForCall)
+}
+
+func (fake *ReloadableHandler) ConfigCalls(stub func() pkg.Config) {
+	fake.configMutex.Lock()
+	defer fake.configMutex.Unlock()
+	fake.ConfigStub = stub
+}
+
+func (fake *ReloadableHandler) ConfigReturns(result1 pkg.Config) {
+	fake.configMutex.Lock()
+	defer fake.configMutex.Unlock()
+	fake.ConfigStub = nil
+	fake.configReturns = struct {
+		result1 pkg.Config
+	}{result1}
+}
+
+func (fake *ReloadableHandler) ConfigReturnsOnCall(i int, result1 pkg.Config) {
+	fake.configMutex.Lock()
+	defer fake.configMutex.Unlock()
+	fake.ConfigStub = nil
+	if fake.configReturnsOnCall == nil {
+		fake.configReturnsOnCall = make(map[int]struct {
+			result1 pkg.Config
+		})
+	}
+	fake.configReturnsOnCall[i] = struct {
+		result1 pkg.Config
+	}{result1}
+}
+
+func (fake *ReloadableHandler) ServeHTTP(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.serveHTTPMutex.Lock()
+	fake.serveHTTPArgsForCall = append(fake.serveHTTPArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	stub := fake.ServeHTTPStub
+	fake.recordInvocation("ServeHTTP", []interface{}{arg1, arg2})
+	fake.serveHTTPMutex.Unlock()
+	if stub != nil {
+		fake.ServeHTTPStub(arg1, arg2)
+	}
+}
+
+func (fake *ReloadableHandler) ServeHTTPCallCount() int {
+	fake.serveHTTPMutex.RLock()
+	defer fake.serveHTTPMutex.RUnlock()
+	return len(fake.serveHTTPArgsForCall)
+}
+
+func (fake *ReloadableHandler) ServeHTTPCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.serveHTTPMutex.Lock()
+	defer fake.serveHTTPMutex.Unlock()
+	fake.ServeHTTPStub = stub
+}
+
+func (fake *ReloadableHandler) ServeHTTPArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.serveHTTPMutex.RLock()
+	defer fake.serveHTTPMutex.RUnlock()
+	argsForCall := fake.serveHTTPArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *ReloadableHandler) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ReloadableHandler) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.ReloadableHandler = new(ReloadableHandler)
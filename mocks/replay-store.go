@@ -0,0 +1,120 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type ReplayStore struct {
+	UseStub        func(context.Context, string, time.Time) (bool, error)
+	useMutex       sync.RWMutex
+	useArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 time.Time
+	}
+	useReturns struct {
+		result1 bool
+		result2 error
+	}
+	useReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ReplayStore) Use(arg1 context.Context, arg2 string, arg3 time.Time) (bool, error) {
+	fake.useMutex.Lock()
+	ret, specificReturn := fake.useReturnsOnCall[len(fake.useArgsForCall)]
+	fake.useArgsForCall = append(fake.useArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 time.Time
+	}{arg1, arg2, arg3})
+	stub := fake.UseStub
+	fakeReturns := fake.useReturns
+	fake.recordInvocation("Use", []interface{}{arg1, arg2, arg3})
+	fake.useMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReplayStore) UseCallCount() int {
+	fake.useMutex.RLock()
+	defer fake.useMutex.RUnlock()
+	return len(fake.useArgsForCall)
+}
+
+func (fake *ReplayStore) UseCalls(stub func(context.Context, string, time.Time) (bool, error)) {
+	fake.useMutex.Lock()
+	defer fake.useMutex.Unlock()
+	fake.UseStub = stub
+}
+
+func (fake *ReplayStore) UseArgsForCall(i int) (context.Context, string, time.Time) {
+	fake.useMutex.RLock()
+	defer fake.useMutex.RUnlock()
+	argsForCall := fake.useArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *ReplayStore) UseReturns(result1 bool, result2 error) {
+	fake.useMutex.Lock()
+	defer fake.useMutex.Unlock()
+	fake.UseStub = nil
+	fake.useReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReplayStore) UseReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.useMutex.Lock()
+	defer fake.useMutex.Unlock()
+	fake.UseStub = nil
+	if fake.useReturnsOnCall == nil {
+		fake.useReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.useReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReplayStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ReplayStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.ReplayStore = new(ReplayStore)
@@ -0,0 +1,110 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type RequestClassifier struct {
+	IsNavigationalStub        func(*http.Request) bool
+	isNavigationalMutex       sync.RWMutex
+	isNavigationalArgsForCall []struct {
+		arg1 *http.Request
+	}
+	isNavigationalReturns struct {
+		result1 bool
+	}
+	isNavigationalReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *RequestClassifier) IsNavigational(arg1 *http.Request) bool {
+	fake.isNavigationalMutex.Lock()
+	ret, specificReturn := fake.isNavigationalReturnsOnCall[len(fake.isNavigationalArgsForCall)]
+	fake.isNavigationalArgsForCall = append(fake.isNavigationalArgsForCall, struct {
+		arg1 *http.Request
+	}{arg1})
+	stub := fake.IsNavigationalStub
+	fakeReturns := fake.isNavigationalReturns
+	fake.recordInvocation("IsNavigational", []interface{}{arg1})
+	fake.isNavigationalMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *RequestClassifier) IsNavigationalCallCount() int {
+	fake.isNavigationalMutex.RLock()
+	defer fake.isNavigationalMutex.RUnlock()
+	return len(fake.isNavigationalArgsForCall)
+}
+
+func (fake *RequestClassifier) IsNavigationalCalls(stub func(*http.Request) bool) {
+	fake.isNavigationalMutex.Lock()
+	defer fake.isNavigationalMutex.Unlock()
+	fake.IsNavigationalStub = stub
+}
+
+func (fake *RequestClassifier) IsNavigationalArgsForCall(i int) *http.Request {
+	fake.isNavigationalMutex.RLock()
+	defer fake.isNavigationalMutex.RUnlock()
+	argsForCall := fake.isNavigationalArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *RequestClassifier) IsNavigationalReturns(result1 bool) {
+	fake.isNavigationalMutex.Lock()
+	defer fake.isNavigationalMutex.Unlock()
+	fake.IsNavigationalStub = nil
+	fake.isNavigationalReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *RequestClassifier) IsNavigationalReturnsOnCall(i int, result1 bool) {
+	fake.isNavigationalMutex.Lock()
+	defer fake.isNavigationalMutex.Unlock()
+	fake.IsNavigationalStub = nil
+	if fake.isNavigationalReturnsOnCall == nil {
+		fake.isNavigationalReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isNavigationalReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *RequestClassifier) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *RequestClassifier) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.RequestClassifier = new(RequestClassifier)
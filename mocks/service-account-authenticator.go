@@ -0,0 +1,196 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type ServiceAccountAuthenticator struct {
+	AuthenticateStub        func(context.Context, *http.Request) (*pkg.Identity, error)
+	authenticateMutex       sync.RWMutex
+	authenticateArgsForCall []struct {
+		arg1 context.Context
+		arg2 *http.Request
+	}
+	authenticateReturns struct {
+		result1 *pkg.Identity
+		result2 error
+	}
+	authenticateReturnsOnCall map[int]struct {
+		result1 *pkg.Identity
+		result2 error
+	}
+	LastUsedStub        func(string) (time.Time, bool)
+	lastUsedMutex       sync.RWMutex
+	lastUsedArgsForCall []struct {
+		arg1 string
+	}
+	lastUsedReturns struct {
+		result1 time.Time
+		result2 bool
+	}
+	lastUsedReturnsOnCall map[int]struct {
+		result1 time.Time
+		result2 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ServiceAccountAuthenticator) Authenticate(arg1 context.Context, arg2 *http.Request) (*pkg.Identity, error) {
+	fake.authenticateMutex.Lock()
+	ret, specificReturn := fake.authenticateReturnsOnCall[len(fake.authenticateArgsForCall)]
+	fake.authenticateArgsForCall = append(fake.authenticateArgsForCall, struct {
+		arg1 context.Context
+		arg2 *http.Request
+	}{arg1, arg2})
+	stub := fake.AuthenticateStub
+	fakeReturns := fake.authenticateReturns
+	fake.recordInvocation("Authenticate", []interface{}{arg1, arg2})
+	fake.authenticateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ServiceAccountAuthenticator) AuthenticateCallCount() int {
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	return len(fake.authenticateArgsForCall)
+}
+
+func (fake *ServiceAccountAuthenticator) AuthenticateCalls(stub func(context.Context, *http.Request) (*pkg.Identity, error)) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = stub
+}
+
+func (fake *ServiceAccountAuthenticator) AuthenticateArgsForCall(i int) (context.Context, *http.Request) {
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	argsForCall := fake.authenticateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *ServiceAccountAuthenticator) AuthenticateReturns(result1 *pkg.Identity, result2 error) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = nil
+	fake.authenticateReturns = struct {
+		result1 *pkg.Identity
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ServiceAccountAuthenticator) AuthenticateReturnsOnCall(i int, result1 *pkg.Identity, result2 error) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = nil
+	if fake.authenticateReturnsOnCall == nil {
+		fake.authenticateReturnsOnCall = make(map[int]struct {
+			result1 *pkg.Identity
+			result2 error
+		})
+	}
+	fake.authenticateReturnsOnCall[i] = struct {
+		result1 *pkg.Identity
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ServiceAccountAuthenticator) LastUsed(arg1 string) (time.Time, bool) {
+	fake.lastUsedMutex.Lock()
+	ret, specificReturn := fake.lastUsedReturnsOnCall[len(fake.lastUsedArgsForCall)]
+	fake.lastUsedArgsForCall = append(fake.lastUsedArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LastUsedStub
+	fakeReturns := fake.lastUsedReturns
+	fake.recordInvocation("LastUsed", []interface{}{arg1})
+	fake.lastUsedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ServiceAccountAuthenticator) LastUsedCallCount() int {
+	fake.lastUsedMutex.RLock()
+	defer fake.lastUsedMutex.RUnlock()
+	return len(fake.lastUsedArgsForCall)
+}
+
+func (fake *ServiceAccountAuthenticator) LastUsedCalls(stub func(string) (time.Time, bool)) {
+	fake.lastUsedMutex.Lock()
+	defer fake.lastUsedMutex.Unlock()
+	fake.LastUsedStub = stub
+}
+
+func (fake *ServiceAccountAuthenticator) LastUsedArgsForCall(i int) string {
+	fake.lastUsedMutex.RLock()
+	defer fake.lastUsedMutex.RUnlock()
+	argsForCall := fake.lastUsedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ServiceAccountAuthenticator) LastUsedReturns(result1 time.Time, result2 bool) {
+	fake.lastUsedMutex.Lock()
+	defer fake.lastUsedMutex.Unlock()
+	fake.LastUsedStub = nil
+	fake.lastUsedReturns = struct {
+		result1 time.Time
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *ServiceAccountAuthenticator) LastUsedReturnsOnCall(i int, result1 time.Time, result2 bool) {
+	fake.lastUsedMutex.Lock()
+	defer fake.lastUsedMutex.Unlock()
+	fake.LastUsedStub = nil
+	if fake.lastUsedReturnsOnCall == nil {
+		fake.lastUsedReturnsOnCall = make(map[int]struct {
+			result1 time.Time
+			result2 bool
+		})
+	}
+	fake.lastUsedReturnsOnCall[i] = struct {
+		result1 time.Time
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *ServiceAccountAuthenticator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ServiceAccountAuthenticator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.ServiceAccountAuthenticator = new(ServiceAccountAuthenticator)
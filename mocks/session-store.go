@@ -0,0 +1,878 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type SessionStore struct {
+	AccessTokenByHashStub        func(context.Context, string) (*pkg.AccessToken, error)
+	accessTokenByHashMutex       sync.RWMutex
+	accessTokenByHashArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	accessTokenByHashReturns struct {
+		result1 *pkg.AccessToken
+		result2 error
+	}
+	accessTokenByHashReturnsOnCall map[int]struct {
+		result1 *pkg.AccessToken
+		result2 error
+	}
+	AccessTokensStub        func(context.Context, string) ([]pkg.AccessToken, error)
+	accessTokensMutex       sync.RWMutex
+	accessTokensArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	accessTokensReturns struct {
+		result1 []pkg.AccessToken
+		result2 error
+	}
+	accessTokensReturnsOnCall map[int]struct {
+		result1 []pkg.AccessToken
+		result2 error
+	}
+	CheckStub        func(context.Context) error
+	checkMutex       sync.RWMutex
+	checkArgsForCall []struct {
+		arg1 context.Context
+	}
+	checkReturns struct {
+		result1 error
+	}
+	checkReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteAccessTokenStub        func(context.Context, string, string) error
+	deleteAccessTokenMutex       sync.RWMutex
+	deleteAccessTokenArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	deleteAccessTokenReturns struct {
+		result1 error
+	}
+	deleteAccessTokenReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteSessionStub        func(context.Context, string) error
+	deleteSessionMutex       sync.RWMutex
+	deleteSessionArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteSessionReturns struct {
+		result1 error
+	}
+	deleteSessionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SaveAccessTokenStub        func(context.Context, pkg.AccessToken) error
+	saveAccessTokenMutex       sync.RWMutex
+	saveAccessTokenArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.AccessToken
+	}
+	saveAccessTokenReturns struct {
+		result1 error
+	}
+	saveAccessTokenReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SaveSessionStub        func(context.Context, pkg.Session) error
+	saveSessionMutex       sync.RWMutex
+	saveSessionArgsForCall []struct {
+		arg1 context.Context
+		arg2 pkg.Session
+	}
+	saveSessionReturns struct {
+		result1 error
+	}
+	saveSessionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SessionStub        func(context.Context, string) (*pkg.Session, error)
+	sessionMutex       sync.RWMutex
+	sessionArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	sessionReturns struct {
+		result1 *pkg.Session
+		result2 error
+	}
+	sessionReturnsOnCall map[int]struct {
+		result1 *pkg.Session
+		result2 error
+	}
+	SessionRevokedStub        func(context.Context, string) (bool, error)
+	sessionRevokedMutex       sync.RWMutex
+	sessionRevokedArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	sessionRevokedReturns struct {
+		result1 bool
+		result2 error
+	}
+	sessionRevokedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	SessionsStub        func(context.Context) ([]pkg.Session, error)
+	sessionsMutex       sync.RWMutex
+	sessionsArgsForCall []struct {
+		arg1 context.Context
+	}
+	sessionsReturns struct {
+		result1 []pkg.Session
+		result2 error
+	}
+	sessionsReturnsOnCall map[int]struct {
+		result1 []pkg.Session
+		result2 error
+	}
+	TouchSessionStub        func(context.Context, string, time.Time) error
+	touchSessionMutex       sync.RWMutex
+	touchSessionArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 time.Time
+	}
+	touchSessionReturns struct {
+		result1 error
+	}
+	touchSessionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *SessionStore) AccessTokenByHash(arg1 context.Context, arg2 string) (*pkg.AccessToken, error) {
+	fake.accessTokenByHashMutex.Lock()
+	ret, specificReturn := fake.accessTokenByHashReturnsOnCall[len(fake.accessTokenByHashArgsForCall)]
+	fake.accessTokenByHashArgsForCall = append(fake.accessTokenByHashArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.AccessTokenByHashStub
+	fakeReturns := fake.accessTokenByHashReturns
+	fake.recordInvocation("AccessTokenByHash", []interface{}{arg1, arg2})
+	fake.accessTokenByHashMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SessionStore) AccessTokenByHashCallCount() int {
+	fake.accessTokenByHashMutex.RLock()
+	defer fake.accessTokenByHashMutex.RUnlock()
+	return len(fake.accessTokenByHashArgsForCall)
+}
+
+func (fake *SessionStore) AccessTokenByHashCalls(stub func(context.Context, string) (*pkg.AccessToken, error)) {
+	fake.accessTokenByHashMutex.Lock()
+	defer fake.accessTokenByHashMutex.Unlock()
+	fake.AccessTokenByHashStub = stub
+}
+
+func (fake *SessionStore) AccessTokenByHashArgsForCall(i int) (context.Context, string) {
+	fake.accessTokenByHashMutex.RLock()
+	defer fake.accessTokenByHashMutex.RUnlock()
+	argsForCall := fake.accessTokenByHashArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *SessionStore) AccessTokenByHashReturns(result1 *pkg.AccessToken, result2 error) {
+	fake.accessTokenByHashMutex.Lock()
+	defer fake.accessTokenByHashMutex.Unlock()
+	fake.AccessTokenByHashStub = nil
+	fake.accessTokenByHashReturns = struct {
+		result1 *pkg.AccessToken
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) AccessTokenByHashReturnsOnCall(i int, result1 *pkg.AccessToken, result2 error) {
+	fake.accessTokenByHashMutex.Lock()
+	defer fake.accessTokenByHashMutex.Unlock()
+	fake.AccessTokenByHashStub = nil
+	if fake.accessTokenByHashReturnsOnCall == nil {
+		fake.accessTokenByHashReturnsOnCall = make(map[int]struct {
+			result1 *pkg.AccessToken
+			result2 error
+		})
+	}
+	fake.accessTokenByHashReturnsOnCall[i] = struct {
+		result1 *pkg.AccessToken
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) AccessTokens(arg1 context.Context, arg2 string) ([]pkg.AccessToken, error) {
+	fake.accessTokensMutex.Lock()
+	ret, specificReturn := fake.accessTokensReturnsOnCall[len(fake.accessTokensArgsForCall)]
+	fake.accessTokensArgsForCall = append(fake.accessTokensArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.AccessTokensStub
+	fakeReturns := fake.accessTokensReturns
+	fake.recordInvocation("AccessTokens", []interface{}{arg1, arg2})
+	fake.accessTokensMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SessionStore) AccessTokensCallCount() int {
+	fake.accessTokensMutex.RLock()
+	defer fake.accessTokensMutex.RUnlock()
+	return len(fake.accessTokensArgsForCall)
+}
+
+func (fake *SessionStore) AccessTokensCalls(stub func(context.Context, string) ([]pkg.AccessToken, error)) {
+	fake.accessTokensMutex.Lock()
+	defer fake.accessTokensMutex.Unlock()
+	fake.AccessTokensStub = stub
+}
+
+func (fake *SessionStore) AccessTokensArgsForCall(i int) (context.Context, string) {
+	fake.accessTokensMutex.RLock()
+	defer fake.accessTokensMutex.RUnlock()
+	argsForCall := fake.accessTokensArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *SessionStore) AccessTokensReturns(result1 []pkg.AccessToken, result2 error) {
+	fake.accessTokensMutex.Lock()
+	defer fake.accessTokensMutex.Unlock()
+	fake.AccessTokensStub = nil
+	fake.accessTokensReturns = struct {
+		result1 []pkg.AccessToken
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) AccessTokensReturnsOnCall(i int, result1 []pkg.AccessToken, result2 error) {
+	fake.accessTokensMutex.Lock()
+	defer fake.accessTokensMutex.Unlock()
+	fake.AccessTokensStub = nil
+	if fake.accessTokensReturnsOnCall == nil {
+		fake.accessTokensReturnsOnCall = make(map[int]struct {
+			result1 []pkg.AccessToken
+			result2 error
+		})
+	}
+	fake.accessTokensReturnsOnCall[i] = struct {
+		result1 []pkg.AccessToken
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) Check(arg1 context.Context) error {
+	fake.checkMutex.Lock()
+	ret, specificReturn := fake.checkReturnsOnCall[len(fake.checkArgsForCall)]
+	fake.checkArgsForCall = append(fake.checkArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.CheckStub
+	fakeReturns := fake.checkReturns
+	fake.recordInvocation("Check", []interface{}{arg1})
+	fake.checkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) CheckCallCount() int {
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	return len(fake.checkArgsForCall)
+}
+
+func (fake *SessionStore) CheckCalls(stub func(context.Context) error) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = stub
+}
+
+func (fake *SessionStore) CheckArgsForCall(i int) context.Context {
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	argsForCall := fake.checkArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *SessionStore) CheckReturns(result1 error) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	fake.checkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) CheckReturnsOnCall(i int, result1 error) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	if fake.checkReturnsOnCall == nil {
+		fake.checkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.checkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) DeleteAccessToken(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.deleteAccessTokenMutex.Lock()
+	ret, specificReturn := fake.deleteAccessTokenReturnsOnCall[len(fake.deleteAccessTokenArgsForCall)]
+	fake.deleteAccessTokenArgsForCall = append(fake.deleteAccessTokenArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteAccessTokenStub
+	fakeReturns := fake.deleteAccessTokenReturns
+	fake.recordInvocation("DeleteAccessToken", []interface{}{arg1, arg2, arg3})
+	fake.deleteAccessTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) DeleteAccessTokenCallCount() int {
+	fake.deleteAccessTokenMutex.RLock()
+	defer fake.deleteAccessTokenMutex.RUnlock()
+	return len(fake.deleteAccessTokenArgsForCall)
+}
+
+func (fake *SessionStore) DeleteAccessTokenCalls(stub func(context.Context, string, string) error) {
+	fake.deleteAccessTokenMutex.Lock()
+	defer fake.deleteAccessTokenMutex.Unlock()
+	fake.DeleteAccessTokenStub = stub
+}
+
+func (fake *SessionStore) DeleteAccessTokenArgsForCall(i int) (context.Context, string, string) {
+	fake.deleteAccessTokenMutex.RLock()
+	defer fake.deleteAccessTokenMutex.RUnlock()
+	argsForCall := fake.deleteAccessTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *SessionStore) DeleteAccessTokenReturns(result1 error) {
+	fake.deleteAccessTokenMutex.Lock()
+	defer fake.deleteAccessTokenMutex.Unlock()
+	fake.DeleteAccessTokenStub = nil
+	fake.deleteAccessTokenReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) DeleteAccessTokenReturnsOnCall(i int, result1 error) {
+	fake.deleteAccessTokenMutex.Lock()
+	defer fake.deleteAccessTokenMutex.Unlock()
+	fake.DeleteAccessTokenStub = nil
+	if fake.deleteAccessTokenReturnsOnCall == nil {
+		fake.deleteAccessTokenReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteAccessTokenReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) DeleteSession(arg1 context.Context, arg2 string) error {
+	fake.deleteSessionMutex.Lock()
+	ret, specificReturn := fake.deleteSessionReturnsOnCall[len(fake.deleteSessionArgsForCall)]
+	fake.deleteSessionArgsForCall = append(fake.deleteSessionArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteSessionStub
+	fakeReturns := fake.deleteSessionReturns
+	fake.recordInvocation("DeleteSession", []interface{}{arg1, arg2})
+	fake.deleteSessionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) DeleteSessionCallCount() int {
+	fake.deleteSessionMutex.RLock()
+	defer fake.deleteSessionMutex.RUnlock()
+	return len(fake.deleteSessionArgsForCall)
+}
+
+func (fake *SessionStore) DeleteSessionCalls(stub func(context.Context, string) error) {
+	fake.deleteSessionMutex.Lock()
+	defer fake.deleteSessionMutex.Unlock()
+	fake.DeleteSessionStub = stub
+}
+
+func (fake *SessionStore) DeleteSessionArgsForCall(i int) (context.Context, string) {
+	fake.deleteSessionMutex.RLock()
+	defer fake.deleteSessionMutex.RUnlock()
+	argsForCall := fake.deleteSessionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *SessionStore) DeleteSessionReturns(result1 error) {
+	fake.deleteSessionMutex.Lock()
+	defer fake.deleteSessionMutex.Unlock()
+	fake.DeleteSessionStub = nil
+	fake.deleteSessionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) DeleteSessionReturnsOnCall(i int, result1 error) {
+	fake.deleteSessionMutex.Lock()
+	defer fake.deleteSessionMutex.Unlock()
+	fake.DeleteSessionStub = nil
+	if fake.deleteSessionReturnsOnCall == nil {
+		fake.deleteSessionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteSessionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) SaveAccessToken(arg1 context.Context, arg2 pkg.AccessToken) error {
+	fake.saveAccessTokenMutex.Lock()
+	ret, specificReturn := fake.saveAccessTokenReturnsOnCall[len(fake.saveAccessTokenArgsForCall)]
+	fake.saveAccessTokenArgsForCall = append(fake.saveAccessTokenArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.AccessToken
+	}{arg1, arg2})
+	stub := fake.SaveAccessTokenStub
+	fakeReturns := fake.saveAccessTokenReturns
+	fake.recordInvocation("SaveAccessToken", []interface{}{arg1, arg2})
+	fake.saveAccessTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) SaveAccessTokenCallCount() int {
+	fake.saveAccessTokenMutex.RLock()
+	defer fake.saveAccessTokenMutex.RUnlock()
+	return len(fake.saveAccessTokenArgsForCall)
+}
+
+func (fake *SessionStore) SaveAccessTokenCalls(stub func(context.Context, pkg.AccessToken) error) {
+	fake.saveAccessTokenMutex.Lock()
+	defer fake.saveAccessTokenMutex.Unlock()
+	fake.SaveAccessTokenStub = stub
+}
+
+func (fake *SessionStore) SaveAccessTokenArgsForCall(i int) (context.Context, pkg.AccessToken) {
+	fake.saveAccessTokenMutex.RLock()
+	defer fake.saveAccessTokenMutex.RUnlock()
+	argsForCall := fake.saveAccessTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *SessionStore) SaveAccessTokenReturns(result1 error) {
+	fake.saveAccessTokenMutex.Lock()
+	defer fake.saveAccessTokenMutex.Unlock()
+	fake.SaveAccessTokenStub = nil
+	fake.saveAccessTokenReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) SaveAccessTokenReturnsOnCall(i int, result1 error) {
+	fake.saveAccessTokenMutex.Lock()
+	defer fake.saveAccessTokenMutex.Unlock()
+	fake.SaveAccessTokenStub = nil
+	if fake.saveAccessTokenReturnsOnCall == nil {
+		fake.saveAccessTokenReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveAccessTokenReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) SaveSession(arg1 context.Context, arg2 pkg.Session) error {
+	fake.saveSessionMutex.Lock()
+	ret, specificReturn := fake.saveSessionReturnsOnCall[len(fake.saveSessionArgsForCall)]
+	fake.saveSessionArgsForCall = append(fake.saveSessionArgsForCall, struct {
+		arg1 context.Context
+		arg2 pkg.Session
+	}{arg1, arg2})
+	stub := fake.SaveSessionStub
+	fakeReturns := fake.saveSessionReturns
+	fake.recordInvocation("SaveSession", []interface{}{arg1, arg2})
+	fake.saveSessionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) SaveSessionCallCount() int {
+	fake.saveSessionMutex.RLock()
+	defer fake.saveSessionMutex.RUnlock()
+	return len(fake.saveSessionArgsForCall)
+}
+
+func (fake *SessionStore) SaveSessionCalls(stub func(context.Context, pkg.Session) error) {
+	fake.saveSessionMutex.Lock()
+	defer fake.saveSessionMutex.Unlock()
+	fake.SaveSessionStub = stub
+}
+
+func (fake *SessionStore) SaveSessionArgsForCall(i int) (context.Context, pkg.Session) {
+	fake.saveSessionMutex.RLock()
+	defer fake.saveSessionMutex.RUnlock()
+	argsForCall := fake.saveSessionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *SessionStore) SaveSessionReturns(result1 error) {
+	fake.saveSessionMutex.Lock()
+	defer fake.saveSessionMutex.Unlock()
+	fake.SaveSessionStub = nil
+	fake.saveSessionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) SaveSessionReturnsOnCall(i int, result1 error) {
+	fake.saveSessionMutex.Lock()
+	defer fake.saveSessionMutex.Unlock()
+	fake.SaveSessionStub = nil
+	if fake.saveSessionReturnsOnCall == nil {
+		fake.saveSessionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveSessionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) Session(arg1 context.Context, arg2 string) (*pkg.Session, error) {
+	fake.sessionMutex.Lock()
+	ret, specificReturn := fake.sessionReturnsOnCall[len(fake.sessionArgsForCall)]
+	fake.sessionArgsForCall = append(fake.sessionArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.SessionStub
+	fakeReturns := fake.sessionReturns
+	fake.recordInvocation("Session", []interface{}{arg1, arg2})
+	fake.sessionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SessionStore) SessionCallCount() int {
+	fake.sessionMutex.RLock()
+	defer fake.sessionMutex.RUnlock()
+	return len(fake.sessionArgsForCall)
+}
+
+func (fake *SessionStore) SessionCalls(stub func(context.Context, string) (*pkg.Session, error)) {
+	fake.sessionMutex.Lock()
+	defer fake.sessionMutex.Unlock()
+	fake.SessionStub = stub
+}
+
+func (fake *SessionStore) SessionArgsForCall(i int) (context.Context, string) {
+	fake.sessionMutex.RLock()
+	defer fake.sessionMutex.RUnlock()
+	argsForCall := fake.sessionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *SessionStore) SessionReturns(result1 *pkg.Session, result2 error) {
+	fake.sessionMutex.Lock()
+	defer fake.sessionMutex.Unlock()
+	fake.SessionStub = nil
+	fake.sessionReturns = struct {
+		result1 *pkg.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) SessionReturnsOnCall(i int, result1 *pkg.Session, result2 error) {
+	fake.sessionMutex.Lock()
+	defer fake.sessionMutex.Unlock()
+	fake.SessionStub = nil
+	if fake.sessionReturnsOnCall == nil {
+		fake.sessionReturnsOnCall = make(map[int]struct {
+			result1 *pkg.Session
+			result2 error
+		})
+	}
+	fake.sessionReturnsOnCall[i] = struct {
+		result1 *pkg.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) SessionRevoked(arg1 context.Context, arg2 string) (bool, error) {
+	fake.sessionRevokedMutex.Lock()
+	ret, specificReturn := fake.sessionRevokedReturnsOnCall[len(fake.sessionRevokedArgsForCall)]
+	fake.sessionRevokedArgsForCall = append(fake.sessionRevokedArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.SessionRevokedStub
+	fakeReturns := fake.sessionRevokedReturns
+	fake.recordInvocation("SessionRevoked", []interface{}{arg1, arg2})
+	fake.sessionRevokedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SessionStore) SessionRevokedCallCount() int {
+	fake.sessionRevokedMutex.RLock()
+	defer fake.sessionRevokedMutex.RUnlock()
+	return len(fake.sessionRevokedArgsForCall)
+}
+
+func (fake *SessionStore) SessionRevokedCalls(stub func(context.Context, string) (bool, error)) {
+	fake.sessionRevokedMutex.Lock()
+	defer fake.sessionRevokedMutex.Unlock()
+	fake.SessionRevokedStub = stub
+}
+
+func (fake *SessionStore) SessionRevokedArgsForCall(i int) (context.Context, string) {
+	fake.sessionRevokedMutex.RLock()
+	defer fake.sessionRevokedMutex.RUnlock()
+	argsForCall := fake.sessionRevokedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *SessionStore) SessionRevokedReturns(result1 bool, result2 error) {
+	fake.sessionRevokedMutex.Lock()
+	defer fake.sessionRevokedMutex.Unlock()
+	fake.SessionRevokedStub = nil
+	fake.sessionRevokedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) SessionRevokedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.sessionRevokedMutex.Lock()
+	defer fake.sessionRevokedMutex.Unlock()
+	fake.SessionRevokedStub = nil
+	if fake.sessionRevokedReturnsOnCall == nil {
+		fake.sessionRevokedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.sessionRevokedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) Sessions(arg1 context.Context) ([]pkg.Session, error) {
+	fake.sessionsMutex.Lock()
+	ret, specificReturn := fake.sessionsReturnsOnCall[len(fake.sessionsArgsForCall)]
+	fake.sessionsArgsForCall = append(fake.sessionsArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.SessionsStub
+	fakeReturns := fake.sessionsReturns
+	fake.recordInvocation("Sessions", []interface{}{arg1})
+	fake.sessionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SessionStore) SessionsCallCount() int {
+	fake.sessionsMutex.RLock()
+	defer fake.sessionsMutex.RUnlock()
+	return len(fake.sessionsArgsForCall)
+}
+
+func (fake *SessionStore) SessionsCalls(stub func(context.Context) ([]pkg.Session, error)) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = stub
+}
+
+func (fake *SessionStore) SessionsArgsForCall(i int) context.Context {
+	fake.sessionsMutex.RLock()
+	defer fake.sessionsMutex.RUnlock()
+	argsForCall := fake.sessionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *SessionStore) SessionsReturns(result1 []pkg.Session, result2 error) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = nil
+	fake.sessionsReturns = struct {
+		result1 []pkg.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) SessionsReturnsOnCall(i int, result1 []pkg.Session, result2 error) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = nil
+	if fake.sessionsReturnsOnCall == nil {
+		fake.sessionsReturnsOnCall = make(map[int]struct {
+			result1 []pkg.Session
+			result2 error
+		})
+	}
+	fake.sessionsReturnsOnCall[i] = struct {
+		result1 []pkg.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SessionStore) TouchSession(arg1 context.Context, arg2 string, arg3 time.Time) error {
+	fake.touchSessionMutex.Lock()
+	ret, specificReturn := fake.touchSessionReturnsOnCall[len(fake.touchSessionArgsForCall)]
+	fake.touchSessionArgsForCall = append(fake.touchSessionArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 time.Time
+	}{arg1, arg2, arg3})
+	stub := fake.TouchSessionStub
+	fakeReturns := fake.touchSessionReturns
+	fake.recordInvocation("TouchSession", []interface{}{arg1, arg2, arg3})
+	fake.touchSessionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SessionStore) TouchSessionCallCount() int {
+	fake.touchSessionMutex.RLock()
+	defer fake.touchSessionMutex.RUnlock()
+	return len(fake.touchSessionArgsForCall)
+}
+
+func (fake *SessionStore) TouchSessionCalls(stub func(context.Context, string, time.Time) error) {
+	fake.touchSessionMutex.Lock()
+	defer fake.touchSessionMutex.Unlock()
+	fake.TouchSessionStub = stub
+}
+
+func (fake *SessionStore) TouchSessionArgsForCall(i int) (context.Context, string, time.Time) {
+	fake.touchSessionMutex.RLock()
+	defer fake.touchSessionMutex.RUnlock()
+	argsForCall := fake.touchSessionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *SessionStore) TouchSessionReturns(result1 error) {
+	fake.touchSessionMutex.Lock()
+	defer fake.touchSessionMutex.Unlock()
+	fake.TouchSessionStub = nil
+	fake.touchSessionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) TouchSessionReturnsOnCall(i int, result1 error) {
+	fake.touchSessionMutex.Lock()
+	defer fake.touchSessionMutex.Unlock()
+	fake.TouchSessionStub = nil
+	if fake.touchSessionReturnsOnCall == nil {
+		fake.touchSessionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.touchSessionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SessionStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *SessionStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.SessionStore = new(SessionStore)
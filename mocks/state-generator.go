@@ -23,11 +23,13 @@ type StateGenerator struct {
 		result1 pkg.State
 		result2 error
 	}
-	GenerateStub        func(context.Context, string) (pkg.State, error)
+	GenerateStub        func(context.Context, string, string, *pkg.StepUp) (pkg.State, error)
 	generateMutex       sync.RWMutex
 	generateArgsForCall []struct {
 		arg1 context.Context
 		arg2 string
+		arg3 string
+		arg4 *pkg.StepUp
 	}
 	generateReturns struct {
 		result1 pkg.State
@@ -106,19 +108,21 @@ func (fake *StateGenerator) DecodeReturnsOnCall(i int, result1 pkg.State, result
 	}{result1, result2}
 }
 
-func (fake *StateGenerator) Generate(arg1 context.Context, arg2 string) (pkg.State, error) {
+func (fake *StateGenerator) Generate(arg1 context.Context, arg2 string, arg3 string, arg4 *pkg.StepUp) (pkg.State, error) {
 	fake.generateMutex.Lock()
 	ret, specificReturn := fake.generateReturnsOnCall[len(fake.generateArgsForCall)]
 	fake.generateArgsForCall = append(fake.generateArgsForCall, struct {
 		arg1 context.Context
 		arg2 string
-	}{arg1, arg2})
+		arg3 string
+		arg4 *pkg.StepUp
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.GenerateStub
 	fakeReturns := fake.generateReturns
-	fake.recordInvocation("Generate", []interface{}{arg1, arg2})
+	fake.recordInvocation("Generate", []interface{}{arg1, arg2, arg3, arg4})
 	fake.generateMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -132,17 +136,17 @@ func (fake *StateGenerator) GenerateCallCount() int {
 	return len(fake.generateArgsForCall)
 }
 
-func (fake *StateGenerator) GenerateCalls(stub func(context.Context, string) (pkg.State, error)) {
+func (fake *StateGenerator) GenerateCalls(stub func(context.Context, string, string, *pkg.StepUp) (pkg.State, error)) {
 	fake.generateMutex.Lock()
 	defer fake.generateMutex.Unlock()
 	fake.GenerateStub = stub
 }
 
-func (fake *StateGenerator) GenerateArgsForCall(i int) (context.Context, string) {
+func (fake *StateGenerator) GenerateArgsForCall(i int) (context.Context, string, string, *pkg.StepUp) {
 	fake.generateMutex.RLock()
 	defer fake.generateMutex.RUnlock()
 	argsForCall := fake.generateArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *StateGenerator) GenerateReturns(result1 pkg.State, result2 error) {
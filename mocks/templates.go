@@ -0,0 +1,202 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+type Templates struct {
+	ExecuteStub        func(context.Context, io.Writer, pkg.TemplateName, string, interface{}) error
+	executeMutex       sync.RWMutex
+	executeArgsForCall []struct {
+		arg1 context.Context
+		arg2 io.Writer
+		arg3 pkg.TemplateName
+		arg4 string
+		arg5 interface{}
+	}
+	executeReturns struct {
+		result1 error
+	}
+	executeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RenderStub        func(context.Context, http.ResponseWriter, int, pkg.TemplateName, string, interface{}) error
+	renderMutex       sync.RWMutex
+	renderArgsForCall []struct {
+		arg1 context.Context
+		arg2 http.ResponseWriter
+		arg3 int
+		arg4 pkg.TemplateName
+		arg5 string
+		arg6 interface{}
+	}
+	renderReturns struct {
+		result1 error
+	}
+	renderReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *Templates) Execute(arg1 context.Context, arg2 io.Writer, arg3 pkg.TemplateName, arg4 string, arg5 interface{}) error {
+	fake.executeMutex.Lock()
+	ret, specificReturn := fake.executeReturnsOnCall[len(fake.executeArgsForCall)]
+	fake.executeArgsForCall = append(fake.executeArgsForCall, struct {
+		arg1 context.Context
+		arg2 io.Writer
+		arg3 pkg.TemplateName
+		arg4 string
+		arg5 interface{}
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.ExecuteStub
+	fakeReturns := fake.executeReturns
+	fake.recordInvocation("Execute", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.executeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Templates) ExecuteCallCount() int {
+	fake.executeMutex.RLock()
+	defer fake.executeMutex.RUnlock()
+	return len(fake.executeArgsForCall)
+}
+
+func (fake *Templates) ExecuteCalls(stub func(context.Context, io.Writer, pkg.TemplateName, string, interface{}) error) {
+	fake.executeMutex.Lock()
+	defer fake.executeMutex.Unlock()
+	fake.ExecuteStub = stub
+}
+
+func (fake *Templates) ExecuteArgsForCall(i int) (context.Context, io.Writer, pkg.TemplateName, string, interface{}) {
+	fake.executeMutex.RLock()
+	defer fake.executeMutex.RUnlock()
+	argsForCall := fake.executeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *Templates) ExecuteReturns(result1 error) {
+	fake.executeMutex.Lock()
+	defer fake.executeMutex.Unlock()
+	fake.ExecuteStub = nil
+	fake.executeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Templates) ExecuteReturnsOnCall(i int, result1 error) {
+	fake.executeMutex.Lock()
+	defer fake.executeMutex.Unlock()
+	fake.ExecuteStub = nil
+	if fake.executeReturnsOnCall == nil {
+		fake.executeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.executeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Templates) Render(arg1 context.Context, arg2 http.ResponseWriter, arg3 int, arg4 pkg.TemplateName, arg5 string, arg6 interface{}) error {
+	fake.renderMutex.Lock()
+	ret, specificReturn := fake.renderReturnsOnCall[len(fake.renderArgsForCall)]
+	fake.renderArgsForCall = append(fake.renderArgsForCall, struct {
+		arg1 context.Context
+		arg2 http.ResponseWriter
+		arg3 int
+		arg4 pkg.TemplateName
+		arg5 string
+		arg6 interface{}
+	}{arg1, arg2, arg3, arg4, arg5, arg6})
+	stub := fake.RenderStub
+	fakeReturns := fake.renderReturns
+	fake.recordInvocation("Render", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6})
+	fake.renderMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Templates) RenderCallCount() int {
+	fake.renderMutex.RLock()
+	defer fake.renderMutex.RUnlock()
+	return len(fake.renderArgsForCall)
+}
+
+func (fake *Templates) RenderCalls(stub func(context.Context, http.ResponseWriter, int, pkg.TemplateName, string, interface{}) error) {
+	fake.renderMutex.Lock()
+	defer fake.renderMutex.Unlock()
+	fake.RenderStub = stub
+}
+
+func (fake *Templates) RenderArgsForCall(i int) (context.Context, http.ResponseWriter, int, pkg.TemplateName, string, interface{}) {
+	fake.renderMutex.RLock()
+	defer fake.renderMutex.RUnlock()
+	argsForCall := fake.renderArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
+}
+
+func (fake *Templates) RenderReturns(result1 error) {
+	fake.renderMutex.Lock()
+	defer fake.renderMutex.Unlock()
+	fake.RenderStub = nil
+	fake.renderReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Templates) RenderReturnsOnCall(i int, result1 error) {
+	fake.renderMutex.Lock()
+	defer fake.renderMutex.Unlock()
+	fake.RenderStub = nil
+	if fake.renderReturnsOnCall == nil {
+		fake.renderReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.renderReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Templates) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *Templates) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ pkg.Templates = new(Templates)
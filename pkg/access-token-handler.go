@@ -0,0 +1,155 @@
+package pkg
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bborbe/errors"
+	libhttp "github.com/bborbe/http"
+	"github.com/golang/glog"
+)
+
+const maxAccessTokenTTLDays = 365
+
+var accessTokenTemplate = template.Must(template.New("tokens").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Personal access tokens</title></head>
+<body>
+<h1>Personal access tokens of {{.User}}</h1>
+{{if .Secret}}
+<p>Your new token <b>{{.Created.Name}}</b>. Copy it now, it will not be shown again:</p>
+<pre>{{.Secret}}</pre>
+<p>Use it as <code>Authorization: Bearer &lt;token&gt;</code>.</p>
+{{end}}
+<table>
+<tr><th>Name</th><th>Paths</th><th>Created</th><th>Expires</th><th></th></tr>
+{{range .Tokens}}
+<tr>
+<td>{{.Name}}</td>
+<td>{{range .PathPrefixes}}{{.}} {{else}}all{{end}}</td>
+<td>{{.CreatedAt.Format "2006-01-02 15:04"}}</td>
+<td>{{.ExpiresAt.Format "2006-01-02 15:04"}}</td>
+<td><form method="post"><input type="hidden" name="action" value="revoke"><input type="hidden" name="id" value="{{.ID}}"><button>Revoke</button></form></td>
+</tr>
+{{end}}
+</table>
+<h2>Create token</h2>
+<form method="post">
+<input type="hidden" name="action" value="create">
+<label>Name <input name="name" required></label>
+<label>Path prefixes (comma separated, empty for all) <input name="path_prefixes"></label>
+<label>Expires in days <input name="expires_in_days" type="number" min="1" max="365" value="30"></label>
+<button>Create</button>
+</form>
+</body>
+</html>
+`))
+
+type accessTokenPage struct {
+	User    string
+	Tokens  []AccessToken
+	Created *AccessToken
+	Secret  string
+}
+
+// NewAccessTokenHandler lets the logged-in user create, list and revoke personal access tokens.
+// Only sessions of a login may manage tokens, access tokens and service accounts can not create new ones.
+func NewAccessTokenHandler(accessTokenManager AccessTokenManager, auditLogger AuditLogger) libhttp.WithError {
+	return libhttp.WithErrorFunc(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+		identity, ok := IdentityFromContext(ctx)
+		if !ok || identity.SessionID == "" {
+			http.Error(resp, "access tokens can only be managed after browser login", http.StatusForbidden)
+			return nil
+		}
+		user := identity.User
+		page := accessTokenPage{
+			User: user,
+		}
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			if !isSameOrigin(req) {
+				http.Error(resp, "cross origin request rejected", http.StatusForbidden)
+				return nil
+			}
+			if err := req.ParseForm(); err != nil {
+				return errors.Wrapf(ctx, err, "parse form failed")
+			}
+			switch req.Form.Get("action") {
+			case "create":
+				days, err := strconv.Atoi(req.Form.Get("expires_in_days"))
+				if err != nil || days < 1 || days > maxAccessTokenTTLDays {
+					http.Error(resp, "expires_in_days must be between 1 and 365", http.StatusBadRequest)
+					return nil
+				}
+				name := strings.TrimSpace(req.Form.Get("name"))
+				if name == "" {
+					http.Error(resp, "name missing", http.StatusBadRequest)
+					return nil
+				}
+				page.Created, page.Secret, err = accessTokenManager.Create(
+					ctx,
+					user,
+					identity.Provider,
+					name,
+					SplitList(req.Form.Get("path_prefixes")),
+					time.Duration(days)*24*time.Hour,
+				)
+				if err != nil {
+					return errors.Wrapf(ctx, err, "create access token failed")
+				}
+				event := NewAuditEvent(req, AuditEventTokenCreated)
+				event.User = user
+				event.SessionID = identity.SessionID
+				event.Reason = "access token " + page.Created.ID + " created by user"
+				auditLogger.Log(ctx, event)
+				glog.V(2).Infof("access token %s created for %s", name, user)
+			case "revoke":
+				if err := accessTokenManager.Revoke(ctx, user, req.Form.Get("id")); err != nil {
+					return errors.Wrapf(ctx, err, "revoke access token failed")
+				}
+				event := NewAuditEvent(req, AuditEventTokenRevoked)
+				event.User = user
+				event.SessionID = identity.SessionID
+				event.Reason = "access token " + req.Form.Get("id") + " revoked by user"
+				auditLogger.Log(ctx, event)
+				glog.V(2).Infof("access token %s of %s revoked", req.Form.Get("id"), user)
+			default:
+				http.Error(resp, "unknown action", http.StatusBadRequest)
+				return nil
+			}
+		default:
+			http.Error(resp, "method not allowed", http.StatusMethodNotAllowed)
+			return nil
+		}
+		var err error
+		page.Tokens, err = accessTokenManager.List(ctx, user)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "list access tokens failed")
+		}
+		resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+		resp.Header().Set("Cache-Control", "no-store")
+		return accessTokenTemplate.Execute(resp, page)
+	})
+}
+
+// isSameOrigin protects form posts against CSRF by checking the Fetch Metadata or Origin header
+func isSameOrigin(req *http.Request) bool {
+	if site := req.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin"
+	}
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return originURL.Host == RequestHost(req)
+}
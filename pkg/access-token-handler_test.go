@@ -0,0 +1,83 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("AccessTokenHandler", func() {
+	var ctx context.Context
+	var sessionStore pkg.SessionStore
+	var auditLogger *mocks.AuditLogger
+	var identity *pkg.Identity
+	var req *http.Request
+	var recorder *httptest.ResponseRecorder
+	BeforeEach(func() {
+		ctx = context.Background()
+		sessionStore = pkg.NewMemorySessionStore()
+		auditLogger = &mocks.AuditLogger{}
+		identity = &pkg.Identity{User: "jdoe@example.com", SessionID: "session-id"}
+		form := url.Values{"action": {"create"}, "name": {"cli"}, "expires_in_days": {"30"}}
+		req = httptest.NewRequest(http.MethodPost, "/tokens", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Sec-Fetch-Site", "same-origin")
+		recorder = httptest.NewRecorder()
+	})
+	JustBeforeEach(func() {
+		if identity != nil {
+			req = req.WithContext(pkg.WithIdentity(ctx, identity))
+		}
+		handler := pkg.NewAccessTokenHandler(pkg.NewAccessTokenManager(sessionStore), auditLogger)
+		Expect(handler.ServeHTTP(req.Context(), recorder, req)).To(BeNil())
+	})
+	It("creates token", func() {
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring(pkg.AccessTokenPrefix))
+		tokens, err := pkg.NewAccessTokenManager(sessionStore).List(ctx, "jdoe@example.com")
+		Expect(err).To(BeNil())
+		Expect(tokens).To(HaveLen(1))
+	})
+	It("audits the creation", func() {
+		Expect(auditLogger.LogCallCount()).To(Equal(1))
+		_, event := auditLogger.LogArgsForCall(0)
+		Expect(event.Type).To(Equal(pkg.AuditEventTokenCreated))
+		Expect(event.User).To(Equal("jdoe@example.com"))
+		Expect(event.SessionID).To(Equal("session-id"))
+	})
+	Context("authenticated without session", func() {
+		BeforeEach(func() {
+			identity = &pkg.Identity{User: "jdoe@example.com"}
+		})
+		It("returns 403", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			Expect(auditLogger.LogCallCount()).To(Equal(0))
+		})
+	})
+	Context("without identity", func() {
+		BeforeEach(func() {
+			identity = nil
+			req.Header.Set(pkg.LoginHeaderName, "jdoe@example.com")
+		})
+		It("returns 403", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+	Context("from other site", func() {
+		BeforeEach(func() {
+			req.Header.Set("Sec-Fetch-Site", "cross-site")
+		})
+		It("returns 403", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			Expect(auditLogger.LogCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/google/uuid"
+)
+
+// AccessTokenPrefix marks personal access tokens to distinguish them from other bearer tokens
+const AccessTokenPrefix = "pat_"
+
+// AccessTokenManager creates and revokes personal access tokens
+//
+//counterfeiter:generate -o ../mocks/access-token-manager.go --fake-name AccessTokenManager . AccessTokenManager
+type AccessTokenManager interface {
+	// Create returns the stored token and the secret the user of provider has to send
+	Create(ctx context.Context, user string, provider string, name string, pathPrefixes []string, ttl time.Duration) (*AccessToken, string, error)
+	List(ctx context.Context, user string) ([]AccessToken, error)
+	Revoke(ctx context.Context, user string, id string) error
+}
+
+// NewAccessTokenManager stores tokens hashed in the session store
+func NewAccessTokenManager(sessionStore SessionStore) AccessTokenManager {
+	return &accessTokenManager{
+		sessionStore: sessionStore,
+	}
+}
+
+type accessTokenManager struct {
+	sessionStore SessionStore
+}
+
+func (a *accessTokenManager) Create(ctx context.Context, user string, provider string, name string, pathPrefixes []string, ttl time.Duration) (*AccessToken, string, error) {
+	key, err := GenerateAPIKey()
+	if err != nil {
+		return nil, "", errors.Wrapf(ctx, err, "generate key failed")
+	}
+	secret := AccessTokenPrefix + key
+	now := time.Now().UTC()
+	token := AccessToken{
+		ID:           uuid.NewString(),
+		User:         user,
+		Provider:     provider,
+		Name:         name,
+		Hash:         HashAPIKey(secret),
+		PathPrefixes: pathPrefixes,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+	}
+	if err := a.sessionStore.SaveAccessToken(ctx, token); err != nil {
+		return nil, "", errors.Wrapf(ctx, err, "save access token failed")
+	}
+	return &token, secret, nil
+}
+
+func (a *accessTokenManager) List(ctx context.Context, user string) ([]AccessToken, error) {
+	return a.sessionStore.AccessTokens(ctx, user)
+}
+
+func (a *accessTokenManager) Revoke(ctx context.Context, user string, id string) error {
+	return a.sessionStore.DeleteAccessToken(ctx, user, id)
+}
+
+// NewAccessTokenAuthenticator accepts personal access tokens sent as bearer token
+func NewAccessTokenAuthenticator(sessionStore SessionStore) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) (*Identity, error) {
+		secret, ok := BearerToken(req)
+		if !ok || !strings.HasPrefix(secret, AccessTokenPrefix) {
+			return nil, ErrNoCredentials
+		}
+		token, err := sessionStore.AccessTokenByHash(ctx, HashAPIKey(secret))
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "find access token failed")
+		}
+		if token.Expired(time.Now()) {
+			return nil, errors.Errorf(ctx, "access token '%s' of %s expired", token.Name, token.User)
+		}
+		if !hasPathPrefix(req.URL.Path, token.PathPrefixes) {
+			return nil, errors.Errorf(ctx, "access token '%s' of %s not allowed to access '%s'", token.Name, token.User, req.URL.Path)
+		}
+		// the provider lets policies tell users apart, access tokens can not step up without a session
+		return &Identity{
+			User:     token.User,
+			Provider: token.Provider,
+		}, nil
+	})
+}
+
+// hasPathPrefix returns true if path is one of the prefixes or below it or no prefixes are given, see matchesPathPrefix
+func hasPathPrefix(path string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if matchesPathPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
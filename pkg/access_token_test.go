@@ -0,0 +1,106 @@
+package pkg_test
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("AccessToken", func() {
+	var ctx context.Context
+	var sessionStore pkg.SessionStore
+	var accessTokenManager pkg.AccessTokenManager
+	var authenticator pkg.Authenticator
+	var req *http.Request
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		sessionStore = pkg.NewMemorySessionStore()
+		accessTokenManager = pkg.NewAccessTokenManager(sessionStore)
+		authenticator = pkg.NewAccessTokenAuthenticator(sessionStore)
+		req, err = http.NewRequest(http.MethodGet, "http://example.com/api/foo", nil)
+		Expect(err).To(BeNil())
+	})
+	It("creates token that authenticates the user", func() {
+		token, secret, err := accessTokenManager.Create(ctx, "jdoe@example.com", pkg.ProviderGoogle, "cli", []string{"/api/"}, time.Hour)
+		Expect(err).To(BeNil())
+		Expect(token.Hash).NotTo(ContainSubstring(secret))
+		req.Header.Set("Authorization", "Bearer "+secret)
+		identity, err := authenticator.Authenticate(ctx, req)
+		Expect(err).To(BeNil())
+		Expect(identity.User).To(Equal("jdoe@example.com"))
+		Expect(identity.Provider).To(Equal(pkg.ProviderGoogle))
+	})
+	It("lists tokens of user", func() {
+		_, _, err := accessTokenManager.Create(ctx, "jdoe@example.com", pkg.ProviderGoogle, "cli", nil, time.Hour)
+		Expect(err).To(BeNil())
+		_, _, err = accessTokenManager.Create(ctx, "other@example.com", pkg.ProviderGoogle, "cli", nil, time.Hour)
+		Expect(err).To(BeNil())
+		tokens, err := accessTokenManager.List(ctx, "jdoe@example.com")
+		Expect(err).To(BeNil())
+		Expect(tokens).To(HaveLen(1))
+	})
+	It("rejects revoked token", func() {
+		token, secret, err := accessTokenManager.Create(ctx, "jdoe@example.com", pkg.ProviderGoogle, "cli", nil, time.Hour)
+		Expect(err).To(BeNil())
+		Expect(accessTokenManager.Revoke(ctx, "other@example.com", token.ID)).NotTo(BeNil())
+		Expect(accessTokenManager.Revoke(ctx, "jdoe@example.com", token.ID)).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+secret)
+		_, err = authenticator.Authenticate(ctx, req)
+		Expect(err).NotTo(BeNil())
+	})
+	It("rejects path outside of scope", func() {
+		_, secret, err := accessTokenManager.Create(ctx, "jdoe@example.com", pkg.ProviderGoogle, "cli", []string{"/other/"}, time.Hour)
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+secret)
+		_, err = authenticator.Authenticate(ctx, req)
+		Expect(err).NotTo(BeNil())
+	})
+	It("matches prefix on whole path segments", func() {
+		_, secret, err := accessTokenManager.Create(ctx, "jdoe@example.com", pkg.ProviderGoogle, "cli", []string{"/api"}, time.Hour)
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+secret)
+		_, err = authenticator.Authenticate(ctx, req)
+		Expect(err).To(BeNil())
+		req.URL.Path = "/apifoo"
+		_, err = authenticator.Authenticate(ctx, req)
+		Expect(err).NotTo(BeNil())
+	})
+	It("rejects expired token", func() {
+		_, secret, err := accessTokenManager.Create(ctx, "jdoe@example.com", pkg.ProviderGoogle, "cli", nil, -time.Hour)
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+secret)
+		_, err = authenticator.Authenticate(ctx, req)
+		Expect(err).NotTo(BeNil())
+	})
+	It("ignores other bearer tokens", func() {
+		req.Header.Set("Authorization", "Bearer eyJhbGciOi")
+		_, err = authenticator.Authenticate(ctx, req)
+		Expect(stderrors.Is(err, pkg.ErrNoCredentials)).To(BeTrue())
+	})
+	It("persists tokens in file store", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "sessions.json")
+		sessionStore, err = pkg.NewFileSessionStore(ctx, path)
+		Expect(err).To(BeNil())
+		_, secret, err := pkg.NewAccessTokenManager(sessionStore).Create(ctx, "jdoe@example.com", pkg.ProviderGoogle, "cli", nil, time.Hour)
+		Expect(err).To(BeNil())
+		content, err := os.ReadFile(path)
+		Expect(err).To(BeNil())
+		Expect(string(content)).NotTo(ContainSubstring(secret))
+
+		sessionStore, err = pkg.NewFileSessionStore(ctx, path)
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+secret)
+		identity, err := pkg.NewAccessTokenAuthenticator(sessionStore).Authenticate(ctx, req)
+		Expect(err).To(BeNil())
+		Expect(identity.User).To(Equal("jdoe@example.com"))
+	})
+})
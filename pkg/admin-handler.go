@@ -0,0 +1,205 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bborbe/errors"
+	libhttp "github.com/bborbe/http"
+	"github.com/golang/glog"
+	"github.com/gorilla/mux"
+	"go.yaml.in/yaml/v3"
+)
+
+// AdminPathPrefix of the admin API
+const AdminPathPrefix = "/admin/api"
+
+// AdminSession is a session as shown by the admin API, without the provider token
+type AdminSession struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user"`
+	Provider  string    `json:"provider"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	LastSeen  time.Time `json:"last_seen,omitempty"`
+}
+
+// NewAdminSession returns the admin view of the session
+func NewAdminSession(session Session) AdminSession {
+	return AdminSession{
+		ID:        session.ID,
+		User:      session.User,
+		Provider:  session.Provider,
+		IP:        session.IP,
+		UserAgent: session.UserAgent,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+		LastSeen:  session.LastSeen,
+	}
+}
+
+// AdminRevokeResponse lists the revoked sessions
+type AdminRevokeResponse struct {
+	Revoked []string `json:"revoked"`
+}
+
+// NewAdminHandler serves the admin API below AdminPathPrefix to members of adminGroups:
+//
+//	GET    /sessions                list active sessions
+//	DELETE /sessions/{id}           revoke a session
+//	GET    /users/{user}/sessions   list sessions of a user
+//	DELETE /users/{user}/sessions   revoke all sessions of a user
+//	GET    /config                  effective config without secrets as YAML
+//
+// It must run after the login middleware.
+func NewAdminHandler(
+	adminGroups []string,
+	sessionStore SessionStore,
+	config Config,
+	auditLogger AuditLogger,
+) http.Handler {
+	a := &adminHandler{
+		sessionStore: sessionStore,
+		config:       config,
+		auditLogger:  auditLogger,
+	}
+	router := mux.NewRouter()
+	api := router.PathPrefix(AdminPathPrefix).Subrouter()
+	api.Path("/sessions").Methods(http.MethodGet).Handler(libhttp.NewErrorHandler(libhttp.WithErrorFunc(a.sessions)))
+	api.Path("/sessions/{id}").Methods(http.MethodDelete).Handler(libhttp.NewErrorHandler(libhttp.WithErrorFunc(a.revokeSession)))
+	api.Path("/users/{user}/sessions").Methods(http.MethodGet).Handler(libhttp.NewErrorHandler(libhttp.WithErrorFunc(a.userSessions)))
+	api.Path("/users/{user}/sessions").Methods(http.MethodDelete).Handler(libhttp.NewErrorHandler(libhttp.WithErrorFunc(a.revokeUserSessions)))
+	api.Path("/config").Methods(http.MethodGet).Handler(libhttp.NewErrorHandler(libhttp.WithErrorFunc(a.effectiveConfig)))
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		identity, ok := IdentityFromContext(req.Context())
+		if !ok || !slices.ContainsFunc(identity.Groups, func(group string) bool {
+			return slices.Contains(adminGroups, group)
+		}) {
+			writeAdminError(resp, http.StatusForbidden, "admin_required")
+			return
+		}
+		// browsers send the session cookie along, so changes of sessions must come from the gateway itself.
+		// Tokens and API keys are never sent by the browser on its own.
+		if req.Method != http.MethodGet && identity.SessionID != "" && !isSameOrigin(req) {
+			writeAdminError(resp, http.StatusForbidden, "cross_origin_rejected")
+			return
+		}
+		resp.Header().Set("Cache-Control", "no-store")
+		router.ServeHTTP(resp, req)
+	})
+}
+
+type adminHandler struct {
+	sessionStore SessionStore
+	config       Config
+	auditLogger  AuditLogger
+}
+
+func (a *adminHandler) sessions(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+	sessions, err := a.filterSessions(ctx, func(session Session) bool { return true })
+	if err != nil {
+		return errors.Wrapf(ctx, err, "list sessions failed")
+	}
+	return writeAdminJSON(resp, adminSessions(sessions))
+}
+
+func (a *adminHandler) userSessions(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+	user := mux.Vars(req)["user"]
+	sessions, err := a.filterSessions(ctx, func(session Session) bool { return session.User == user })
+	if err != nil {
+		return errors.Wrapf(ctx, err, "list sessions of %s failed", user)
+	}
+	return writeAdminJSON(resp, adminSessions(sessions))
+}
+
+func (a *adminHandler) revokeSession(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+	session, err := a.sessionStore.Session(ctx, mux.Vars(req)["id"])
+	if stderrors.Is(err, ErrNotFound) {
+		writeAdminError(resp, http.StatusNotFound, "not_found")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(ctx, err, "get session failed")
+	}
+	if err := a.revoke(ctx, req, *session); err != nil {
+		return errors.Wrapf(ctx, err, "revoke session failed")
+	}
+	return writeAdminJSON(resp, AdminRevokeResponse{Revoked: []string{session.ID}})
+}
+
+func (a *adminHandler) revokeUserSessions(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+	user := mux.Vars(req)["user"]
+	sessions, err := a.filterSessions(ctx, func(session Session) bool { return session.User == user })
+	if err != nil {
+		return errors.Wrapf(ctx, err, "list sessions of %s failed", user)
+	}
+	response := AdminRevokeResponse{Revoked: []string{}}
+	for _, session := range sessions {
+		if err := a.revoke(ctx, req, session); err != nil {
+			return errors.Wrapf(ctx, err, "revoke sessions of %s failed", user)
+		}
+		response.Revoked = append(response.Revoked, session.ID)
+	}
+	return writeAdminJSON(resp, response)
+}
+
+func (a *adminHandler) effectiveConfig(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+	content, err := yaml.Marshal(a.config.Redacted())
+	if err != nil {
+		return errors.Wrapf(ctx, err, "marshal config failed")
+	}
+	resp.Header().Set("Content-Type", "application/yaml")
+	_, err = resp.Write(content)
+	return err
+}
+
+func (a *adminHandler) filterSessions(ctx context.Context, match func(session Session) bool) ([]Session, error) {
+	sessions, err := a.sessionStore.Sessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(sessions, func(session Session) bool { return !match(session) }), nil
+}
+
+// revoke the session and audit the admin who did it
+func (a *adminHandler) revoke(ctx context.Context, req *http.Request, session Session) error {
+	if err := a.sessionStore.DeleteSession(ctx, session.ID); err != nil {
+		return errors.Wrapf(ctx, err, "delete session %s failed", session.ID)
+	}
+	event := NewAuditEvent(req, AuditEventSessionRevoked)
+	event.User = session.User
+	event.Provider = session.Provider
+	event.SessionID = session.ID
+	event.Reason = "admin"
+	if identity, ok := IdentityFromContext(ctx); ok {
+		event.Actor = identity.User
+	}
+	a.auditLogger.Log(ctx, event)
+	glog.V(2).Infof("session %s of %s revoked by %s", session.ID, session.User, event.Actor)
+	return nil
+}
+
+func adminSessions(sessions []Session) []AdminSession {
+	result := make([]AdminSession, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, NewAdminSession(session))
+	}
+	return result
+}
+
+func writeAdminJSON(resp http.ResponseWriter, value interface{}) error {
+	resp.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(resp).Encode(value)
+}
+
+func writeAdminError(resp http.ResponseWriter, status int, code string) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	_ = json.NewEncoder(resp).Encode(UnauthorizedResponse{Error: code})
+}
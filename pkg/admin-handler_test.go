@@ -0,0 +1,163 @@
+package pkg_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("AdminHandler", func() {
+	var ctx context.Context
+	var sessionStore pkg.SessionStore
+	var auditLogger *mocks.AuditLogger
+	var identity *pkg.Identity
+	var req *http.Request
+	var recorder *httptest.ResponseRecorder
+	BeforeEach(func() {
+		ctx = context.Background()
+		sessionStore = pkg.NewMemorySessionStore()
+		expiresAt := time.Now().Add(time.Hour)
+		for _, session := range []pkg.Session{
+			{ID: "s1", User: "jdoe@example.com", Provider: pkg.ProviderGoogle, IP: "10.0.0.1", ExpiresAt: expiresAt, Token: &oauth2.Token{AccessToken: "secret"}},
+			{ID: "s2", User: "jdoe@example.com", Provider: pkg.ProviderGoogle, ExpiresAt: expiresAt},
+			{ID: "s3", User: "other@example.com", Provider: pkg.ProviderGitHub, ExpiresAt: expiresAt},
+		} {
+			Expect(sessionStore.SaveSession(ctx, session)).To(BeNil())
+		}
+		auditLogger = &mocks.AuditLogger{}
+		identity = &pkg.Identity{User: "admin@example.com", Groups: []string{"admins@example.com"}}
+		req = httptest.NewRequest(http.MethodGet, "/admin/api/sessions", nil)
+		recorder = httptest.NewRecorder()
+	})
+	JustBeforeEach(func() {
+		config := pkg.Config{
+			SigningKey: "signing-key",
+			Providers:  []pkg.ProviderConfig{{ID: pkg.ProviderGoogle, ClientID: "client-id", ClientSecret: "client-secret"}},
+		}
+		handler := pkg.NewAdminHandler([]string{"admins@example.com"}, sessionStore, config, auditLogger)
+		handler.ServeHTTP(recorder, req.WithContext(pkg.WithIdentity(ctx, identity)))
+	})
+	It("lists sessions without tokens", func() {
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).NotTo(ContainSubstring("secret"))
+		var sessions []pkg.AdminSession
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &sessions)).To(BeNil())
+		Expect(sessions).To(HaveLen(3))
+	})
+	Context("without admin group", func() {
+		BeforeEach(func() {
+			identity = &pkg.Identity{User: "jdoe@example.com"}
+		})
+		It("returns 403", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+	Context("sessions of user", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest(http.MethodGet, "/admin/api/users/jdoe@example.com/sessions", nil)
+		})
+		It("lists only the sessions of the user", func() {
+			var sessions []pkg.AdminSession
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &sessions)).To(BeNil())
+			Expect(sessions).To(HaveLen(2))
+			Expect(sessions[0].User).To(Equal("jdoe@example.com"))
+		})
+	})
+	Context("revoke session", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest(http.MethodDelete, "/admin/api/sessions/s1", nil)
+			req.Header.Set("Sec-Fetch-Site", "same-origin")
+			identity.SessionID = "admin-session"
+		})
+		It("revokes the session", func() {
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			revoked, err := sessionStore.SessionRevoked(ctx, "s1")
+			Expect(err).To(BeNil())
+			Expect(revoked).To(BeTrue())
+			_, event := auditLogger.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditEventSessionRevoked))
+			Expect(event.Actor).To(Equal("admin@example.com"))
+		})
+		Context("from other site", func() {
+			BeforeEach(func() {
+				req.Header.Set("Sec-Fetch-Site", "cross-site")
+			})
+			It("returns 403", func() {
+				Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			})
+		})
+		Context("from other site with authorization header", func() {
+			BeforeEach(func() {
+				req.Header.Set("Sec-Fetch-Site", "cross-site")
+				req.Header.Set("Authorization", "Bearer token")
+			})
+			It("returns 403", func() {
+				Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			})
+		})
+		Context("from other site authenticated by token", func() {
+			BeforeEach(func() {
+				req.Header.Set("Sec-Fetch-Site", "cross-site")
+				identity.SessionID = ""
+			})
+			It("revokes the session", func() {
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+		Context("with origin of the host requested behind a proxy", func() {
+			BeforeEach(func() {
+				req.Header.Del("Sec-Fetch-Site")
+				req.Header.Set("Origin", "https://app.example.com")
+				req.Host = "gateway.internal"
+				req.URL.Host = "app.example.com"
+			})
+			It("revokes the session", func() {
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+		Context("with origin of the internal host", func() {
+			BeforeEach(func() {
+				req.Header.Del("Sec-Fetch-Site")
+				req.Header.Set("Origin", "https://gateway.internal")
+				req.Host = "gateway.internal"
+				req.URL.Host = "app.example.com"
+			})
+			It("returns 403", func() {
+				Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			})
+		})
+	})
+	Context("revoke sessions of user", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest(http.MethodDelete, "/admin/api/users/jdoe@example.com/sessions", nil)
+		})
+		It("revokes all sessions of the user", func() {
+			var response pkg.AdminRevokeResponse
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Revoked).To(ConsistOf("s1", "s2"))
+			sessions, err := sessionStore.Sessions(ctx)
+			Expect(err).To(BeNil())
+			Expect(sessions).To(HaveLen(1))
+		})
+	})
+	Context("config", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest(http.MethodGet, "/admin/api/config", nil)
+		})
+		It("returns config without secrets", func() {
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring("client_id: client-id"))
+			Expect(recorder.Body.String()).NotTo(ContainSubstring("client-secret"))
+			Expect(recorder.Body.String()).NotTo(ContainSubstring("signing-key"))
+		})
+	})
+})
@@ -0,0 +1,258 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+)
+
+// AuditEventType describes what happened
+type AuditEventType string
+
+const (
+	AuditEventLoginStarted     AuditEventType = "login_started"
+	AuditEventLoginSucceeded   AuditEventType = "login_succeeded"
+	AuditEventLoginDenied      AuditEventType = "login_denied"
+	AuditEventLogout           AuditEventType = "logout"
+	AuditEventSessionRefreshed AuditEventType = "session_refreshed"
+	AuditEventTokenCreated     AuditEventType = "token_created"
+	AuditEventTokenRevoked     AuditEventType = "token_revoked"
+	AuditEventAccessDenied     AuditEventType = "access_denied"
+	AuditEventSessionRevoked   AuditEventType = "session_revoked"
+	AuditEventHandoffIssued    AuditEventType = "handoff_issued"
+)
+
+// AuditEvent is an auditable record of an authentication event
+type AuditEvent struct {
+	Time      time.Time      `json:"time"`
+	Type      AuditEventType `json:"type"`
+	User      string         `json:"user,omitempty"`
+	IP        string         `json:"ip,omitempty"`
+	UserAgent string         `json:"user_agent,omitempty"`
+	Origin    string         `json:"origin,omitempty"`
+	Provider  string         `json:"provider,omitempty"`
+	SessionID string         `json:"session_id,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+	// Actor that caused the event if not the user, e.g. an admin
+	Actor string `json:"actor,omitempty"`
+}
+
+// NewAuditEvent returns an event of the given type with time, IP and user agent of the request
+func NewAuditEvent(req *http.Request, eventType AuditEventType) AuditEvent {
+	return AuditEvent{
+		Time:      time.Now().UTC(),
+		Type:      eventType,
+		IP:        ClientIP(req),
+		UserAgent: req.UserAgent(),
+	}
+}
+
+// RemoteIP returns the IP of the client or proxy that sent the request, see ClientIP
+func RemoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// AuditLogger writes audit events to a sink
+//
+//counterfeiter:generate -o ../mocks/audit-logger.go --fake-name AuditLogger . AuditLogger
+type AuditLogger interface {
+	Log(ctx context.Context, event AuditEvent)
+}
+
+// AuditLoggers sends each event to all loggers
+type AuditLoggers []AuditLogger
+
+// Log the event to all loggers
+func (a AuditLoggers) Log(ctx context.Context, event AuditEvent) {
+	for _, auditLogger := range a {
+		auditLogger.Log(ctx, event)
+	}
+}
+
+// NewAuditLogger creates loggers for the given sinks:
+// "stdout", "file:<path>" for a rotating file or a http(s) url for a webhook.
+func NewAuditLogger(ctx context.Context, httpClient *http.Client, sinks []string) (AuditLogger, error) {
+	var result AuditLoggers
+	for _, sink := range sinks {
+		switch {
+		case sink == "stdout":
+			result = append(result, NewWriterAuditLogger(os.Stdout))
+		case strings.HasPrefix(sink, "file:"):
+			result = append(result, NewWriterAuditLogger(NewRotatingFile(strings.TrimPrefix(sink, "file:"), 100*1024*1024, 5)))
+		case strings.HasPrefix(sink, "http://") || strings.HasPrefix(sink, "https://"):
+			result = append(result, NewWebhookAuditLogger(ctx, httpClient, sink))
+		default:
+			return nil, errors.Errorf(ctx, "unknown audit sink '%s'", sink)
+		}
+	}
+	return result, nil
+}
+
+// NewWriterAuditLogger writes each event as JSON line to the writer
+func NewWriterAuditLogger(writer io.Writer) AuditLogger {
+	return &writerAuditLogger{
+		writer: writer,
+	}
+}
+
+type writerAuditLogger struct {
+	mux    sync.Mutex
+	writer io.Writer
+}
+
+func (w *writerAuditLogger) Log(ctx context.Context, event AuditEvent) {
+	content, err := json.Marshal(event)
+	if err != nil {
+		glog.Warningf("marshal audit event failed: %v", err)
+		return
+	}
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if _, err := w.writer.Write(append(content, '\n')); err != nil {
+		glog.Warningf("write audit event failed: %v", err)
+	}
+}
+
+// NewRotatingFile returns a writer appending to path. If the file exceeds maxSize bytes
+// it is renamed to path.1 (older files shift up to path.<maxBackups>) and a new file is started.
+func NewRotatingFile(path string, maxSize int64, maxBackups int) io.Writer {
+	return &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+}
+
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mux  sync.Mutex
+	file *os.File
+	size int64
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.file != nil && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	for i := r.maxBackups - 1; i > 0; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if r.maxBackups > 0 {
+		return os.Rename(r.path, r.path+".1")
+	}
+	return os.Remove(r.path)
+}
+
+const webhookAuditLoggerBufferSize = 1000
+
+// NewWebhookAuditLogger posts each event as JSON to url.
+// Events are sent in the background until ctx is canceled, if the buffer is full events are dropped.
+func NewWebhookAuditLogger(ctx context.Context, httpClient *http.Client, url string) AuditLogger {
+	w := &webhookAuditLogger{
+		httpClient: httpClient,
+		url:        url,
+		events:     make(chan AuditEvent, webhookAuditLoggerBufferSize),
+	}
+	go w.run(ctx)
+	return w
+}
+
+type webhookAuditLogger struct {
+	httpClient *http.Client
+	url        string
+	events     chan AuditEvent
+}
+
+func (w *webhookAuditLogger) Log(ctx context.Context, event AuditEvent) {
+	select {
+	case w.events <- event:
+	default:
+		glog.Warningf("audit webhook buffer full, drop %s event of %s", event.Type, event.User)
+	}
+}
+
+func (w *webhookAuditLogger) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-w.events:
+			if err := w.send(ctx, event); err != nil {
+				glog.Warningf("send audit event to webhook failed: %v", err)
+			}
+		}
+	}
+}
+
+func (w *webhookAuditLogger) send(ctx context.Context, event AuditEvent) error {
+	content, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "marshal audit event failed")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(content))
+	if err != nil {
+		return errors.Wrapf(ctx, err, "build request failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "post audit event failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf(ctx, "post audit event failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package pkg_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("AuditLogger", func() {
+	var ctx context.Context
+	var event pkg.AuditEvent
+	BeforeEach(func() {
+		ctx = context.Background()
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("User-Agent", "curl/8.0")
+		event = pkg.NewAuditEvent(req, pkg.AuditEventLoginSucceeded)
+		event.User = "jdoe@example.com"
+	})
+	It("fills request details", func() {
+		Expect(event.IP).To(Equal("10.0.0.1"))
+		Expect(event.UserAgent).To(Equal("curl/8.0"))
+	})
+	It("writes json lines", func() {
+		buf := &bytes.Buffer{}
+		pkg.NewWriterAuditLogger(buf).Log(ctx, event)
+		var result pkg.AuditEvent
+		Expect(json.Unmarshal(buf.Bytes(), &result)).To(BeNil())
+		Expect(result.Type).To(Equal(pkg.AuditEventLoginSucceeded))
+		Expect(result.User).To(Equal("jdoe@example.com"))
+		Expect(buf.String()).To(HaveSuffix("\n"))
+	})
+	It("rotates file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "audit.log")
+		auditLogger := pkg.NewWriterAuditLogger(pkg.NewRotatingFile(path, 200, 2))
+		for i := 0; i < 5; i++ {
+			auditLogger.Log(ctx, event)
+		}
+		Expect(path).To(BeAnExistingFile())
+		Expect(path + ".1").To(BeAnExistingFile())
+		Expect(path + ".2").To(BeAnExistingFile())
+		Expect(path + ".3").NotTo(BeAnExistingFile())
+		info, err := os.Stat(path)
+		Expect(err).To(BeNil())
+		Expect(info.Size()).To(BeNumerically("<=", 200))
+	})
+	It("posts to webhook", func() {
+		received := make(chan pkg.AuditEvent, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			var result pkg.AuditEvent
+			_ = json.NewDecoder(req.Body).Decode(&result)
+			received <- result
+		}))
+		defer server.Close()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		auditLogger, err := pkg.NewAuditLogger(ctx, http.DefaultClient, []string{server.URL})
+		Expect(err).To(BeNil())
+		auditLogger.Log(ctx, event)
+		Eventually(received).Should(Receive(HaveField("User", "jdoe@example.com")))
+	})
+	It("rejects unknown sink", func() {
+		_, err := pkg.NewAuditLogger(ctx, http.DefaultClient, []string{"banana"})
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,131 @@
+package pkg
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bborbe/errors"
+)
+
+// ErrNoCredentials is returned by an Authenticator if the request carries no credentials it understands
+var ErrNoCredentials = stderrors.New("no credentials")
+
+// Identity of an authenticated request
+type Identity struct {
+	User string
+	// Provider the user logged in with, empty if authenticated by a token or API key
+	Provider string
+	// SessionID of the login, empty if not authenticated by a session
+	SessionID string
+	// Groups of the user, looked up per request if groups are configured
+	Groups []string
+	// AuthTime, ACR and AMR of the login, see StepUp
+	AuthTime time.Time
+	ACR      string
+	AMR      []string
+}
+
+type identityContextKey struct{}
+
+// WithIdentity returns a context carrying the identity of the request
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// IdentityFromContext returns the identity set by the login middleware
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// Authenticator authenticates a request
+//
+//counterfeiter:generate -o ../mocks/authenticator.go --fake-name Authenticator . Authenticator
+type Authenticator interface {
+	Authenticate(ctx context.Context, req *http.Request) (*Identity, error)
+}
+
+// AuthenticatorFunc allows to use a func as Authenticator
+type AuthenticatorFunc func(ctx context.Context, req *http.Request) (*Identity, error)
+
+// Authenticate the request
+func (a AuthenticatorFunc) Authenticate(ctx context.Context, req *http.Request) (*Identity, error) {
+	return a(ctx, req)
+}
+
+// Authenticators tries all authenticators and returns the first identity found.
+// If no authenticator succeeds the first error other than ErrNoCredentials is returned.
+type Authenticators []Authenticator
+
+// Authenticate the request
+func (a Authenticators) Authenticate(ctx context.Context, req *http.Request) (*Identity, error) {
+	var result error
+	for _, authenticator := range a {
+		identity, err := authenticator.Authenticate(ctx, req)
+		if err == nil {
+			return identity, nil
+		}
+		if result == nil && !stderrors.Is(err, ErrNoCredentials) {
+			result = err
+		}
+	}
+	if result != nil {
+		return nil, result
+	}
+	return nil, ErrNoCredentials
+}
+
+// NewCookieAuthenticator authenticates requests by the login cookie, see NewSessionTokenVerifier
+func NewCookieAuthenticator(cookieGenerator CookieGenerator, sessionStore SessionStore, groupResolver GroupResolver, metrics Metrics) Authenticator {
+	verifier := NewSessionTokenVerifier(cookieGenerator, sessionStore, groupResolver)
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) (*Identity, error) {
+		cookie, err := req.Cookie(LoginCookieName)
+		if err != nil || cookie.Value == "" {
+			return nil, ErrNoCredentials
+		}
+		identity, err := verifier.Verify(ctx, cookie.Value)
+		if err != nil {
+			metrics.CookieDecodeFailure(TokenErrorReasonOf(err))
+			return nil, errors.Wrap(ctx, err, "invalid auth cookie")
+		}
+		return identity, nil
+	})
+}
+
+// NewBearerAuthenticator authenticates requests by an `Authorization: Bearer <token>` header.
+// The token is accepted if any of the given verifiers accepts it.
+func NewBearerAuthenticator(verifiers ...TokenVerifier) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) (*Identity, error) {
+		token, ok := BearerToken(req)
+		if !ok {
+			return nil, ErrNoCredentials
+		}
+		var result error
+		for _, verifier := range verifiers {
+			identity, err := verifier.Verify(ctx, token)
+			if err == nil {
+				return identity, nil
+			}
+			if result == nil {
+				result = err
+			}
+		}
+		if result == nil {
+			return nil, errors.Errorf(ctx, "no bearer token verifier configured")
+		}
+		return nil, errors.Wrap(ctx, result, "invalid bearer token")
+	})
+}
+
+// BearerToken returns the token of an `Authorization: Bearer <token>` header
+func BearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,172 @@
+package pkg_test
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("Authenticator", func() {
+	var ctx context.Context
+	var req *http.Request
+	var err error
+	var identity *pkg.Identity
+	BeforeEach(func() {
+		ctx = context.Background()
+		req, err = http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+		Expect(err).To(BeNil())
+	})
+	Context("Authenticators", func() {
+		var first *mocks.Authenticator
+		var second *mocks.Authenticator
+		BeforeEach(func() {
+			first = &mocks.Authenticator{}
+			second = &mocks.Authenticator{}
+		})
+		JustBeforeEach(func() {
+			identity, err = pkg.Authenticators{first, second}.Authenticate(ctx, req)
+		})
+		Context("second succeeds", func() {
+			BeforeEach(func() {
+				first.AuthenticateReturns(nil, pkg.ErrNoCredentials)
+				second.AuthenticateReturns(&pkg.Identity{User: "jdoe@example.com"}, nil)
+			})
+			It("returns identity", func() {
+				Expect(err).To(BeNil())
+				Expect(identity.User).To(Equal("jdoe@example.com"))
+			})
+		})
+		Context("no credentials", func() {
+			BeforeEach(func() {
+				first.AuthenticateReturns(nil, pkg.ErrNoCredentials)
+				second.AuthenticateReturns(nil, pkg.ErrNoCredentials)
+			})
+			It("returns ErrNoCredentials", func() {
+				Expect(stderrors.Is(err, pkg.ErrNoCredentials)).To(BeTrue())
+			})
+		})
+		Context("invalid credentials", func() {
+			BeforeEach(func() {
+				first.AuthenticateReturns(nil, stderrors.New("banana"))
+				second.AuthenticateReturns(nil, pkg.ErrNoCredentials)
+			})
+			It("returns the error", func() {
+				Expect(err).To(MatchError("banana"))
+			})
+		})
+	})
+	Context("BearerAuthenticator", func() {
+		var verifier *mocks.TokenVerifier
+		BeforeEach(func() {
+			verifier = &mocks.TokenVerifier{}
+			verifier.VerifyReturns(&pkg.Identity{User: "jdoe@example.com"}, nil)
+		})
+		JustBeforeEach(func() {
+			identity, err = pkg.NewBearerAuthenticator(verifier).Authenticate(ctx, req)
+		})
+		Context("without header", func() {
+			It("returns ErrNoCredentials", func() {
+				Expect(stderrors.Is(err, pkg.ErrNoCredentials)).To(BeTrue())
+				Expect(verifier.VerifyCallCount()).To(Equal(0))
+			})
+		})
+		Context("with bearer token", func() {
+			BeforeEach(func() {
+				req.Header.Set("Authorization", "Bearer my-token")
+			})
+			It("returns identity", func() {
+				Expect(err).To(BeNil())
+				Expect(identity.User).To(Equal("jdoe@example.com"))
+			})
+			It("passes token to verifier", func() {
+				Expect(verifier.VerifyCallCount()).To(Equal(1))
+				_, token := verifier.VerifyArgsForCall(0)
+				Expect(token).To(Equal("my-token"))
+			})
+		})
+		Context("with invalid token", func() {
+			BeforeEach(func() {
+				req.Header.Set("Authorization", "Bearer my-token")
+				verifier.VerifyReturns(nil, stderrors.New("banana"))
+			})
+			It("returns error", func() {
+				Expect(err).NotTo(BeNil())
+				Expect(stderrors.Is(err, pkg.ErrNoCredentials)).To(BeFalse())
+			})
+		})
+	})
+	Context("CookieAuthenticator", func() {
+		var cookieGenerator = pkg.NewCookieGenerator([]byte("test-key"), pkg.CookieOptions{})
+		var sessionStore pkg.SessionStore
+		var groupResolver pkg.GroupResolver
+		BeforeEach(func() {
+			sessionStore = pkg.NewMemorySessionStore()
+			groupResolver = nil
+		})
+		JustBeforeEach(func() {
+			identity, err = pkg.NewCookieAuthenticator(cookieGenerator, sessionStore, groupResolver, &mocks.Metrics{}).Authenticate(ctx, req)
+		})
+		Context("without cookie", func() {
+			It("returns ErrNoCredentials", func() {
+				Expect(stderrors.Is(err, pkg.ErrNoCredentials)).To(BeTrue())
+			})
+		})
+		Context("with valid cookie", func() {
+			var cookie pkg.Cookie
+			BeforeEach(func() {
+				var err error
+				cookie, err = cookieGenerator.Generate(ctx, pkg.Login{User: "jdoe@example.com", Provider: pkg.ProviderGoogle, Groups: []string{"admins@example.com"}})
+				Expect(err).To(BeNil())
+				req.AddCookie(cookie.HTTPCookie(req))
+			})
+			It("returns identity", func() {
+				Expect(err).To(BeNil())
+				Expect(identity.User).To(Equal("jdoe@example.com"))
+			})
+			It("returns groups of the login", func() {
+				Expect(identity.Groups).To(Equal([]string{"admins@example.com"}))
+			})
+			Context("with group resolver", func() {
+				var resolver *mocks.GroupResolver
+				BeforeEach(func() {
+					resolver = &mocks.GroupResolver{}
+					resolver.GroupsReturns([]string{"staff@example.com"}, nil)
+					groupResolver = resolver
+				})
+				It("returns current groups", func() {
+					Expect(err).To(BeNil())
+					Expect(identity.Groups).To(Equal([]string{"staff@example.com"}))
+					_, provider, user := resolver.GroupsArgsForCall(0)
+					Expect(provider).To(Equal(pkg.ProviderGoogle))
+					Expect(user).To(Equal("jdoe@example.com"))
+				})
+				Context("failing", func() {
+					BeforeEach(func() {
+						resolver.GroupsReturns(nil, stderrors.New("banana"))
+					})
+					It("returns identity without groups", func() {
+						Expect(err).To(BeNil())
+						Expect(identity.User).To(Equal("jdoe@example.com"))
+						Expect(identity.Groups).To(BeEmpty())
+					})
+				})
+			})
+			Context("of revoked session", func() {
+				BeforeEach(func() {
+					Expect(sessionStore.SaveSession(ctx, pkg.Session{ID: cookie.ID, User: "jdoe@example.com", ExpiresAt: cookie.ExpiresAt.Time})).To(BeNil())
+					Expect(sessionStore.DeleteSession(ctx, cookie.ID)).To(BeNil())
+				})
+				It("returns error", func() {
+					Expect(stderrors.Is(err, pkg.ErrSessionRevoked)).To(BeTrue())
+					Expect(pkg.TokenErrorReasonOf(err)).To(Equal(pkg.TokenErrorReasonRevoked))
+				})
+			})
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/bborbe/errors"
+	libhttp "github.com/bborbe/http"
+	"github.com/golang/glog"
+)
+
+// BackChannelLogoutPath receives logout tokens of the identity providers, see BackChannelLogoutPathOf
+const BackChannelLogoutPath = "/backchannel-logout"
+
+// BackChannelLogoutPathOf returns the path receiving logout tokens of provider
+func BackChannelLogoutPathOf(provider string) string {
+	return BackChannelLogoutPath + "/" + provider
+}
+
+// BackChannelLogoutResponse is returned if the logout token is rejected
+type BackChannelLogoutResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// NewBackChannelLogoutHandler receives logout tokens the provider posts if a user logged out or
+// was disabled (OpenID Connect Back-Channel Logout 1.0). All sessions of the provider matching
+// sub and sid of the token are revoked. Logging out twice is harmless, so the jti is not tracked.
+func NewBackChannelLogoutHandler(
+	provider string,
+	logoutTokenVerifier LogoutTokenVerifier,
+	sessionStore SessionStore,
+	auditLogger AuditLogger,
+) libhttp.WithError {
+	return libhttp.WithErrorFunc(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+		resp.Header().Set("Cache-Control", "no-store")
+		if req.Method != http.MethodPost {
+			resp.Header().Set("Allow", http.MethodPost)
+			return writeBackChannelLogoutError(resp, http.StatusMethodNotAllowed, "invalid_request", "method not allowed")
+		}
+		if err := req.ParseForm(); err != nil {
+			return writeBackChannelLogoutError(resp, http.StatusBadRequest, "invalid_request", "parse form failed")
+		}
+		claims, err := logoutTokenVerifier.Verify(ctx, req.PostForm.Get("logout_token"))
+		if err != nil {
+			glog.V(1).Infof("reject logout token: %v", err)
+			return writeBackChannelLogoutError(resp, http.StatusBadRequest, "invalid_request", "invalid logout token")
+		}
+		sessions, err := sessionStore.Sessions(ctx)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "list sessions failed")
+		}
+		for _, session := range sessions {
+			if !logoutTokenMatches(*claims, provider, session) {
+				continue
+			}
+			if err := sessionStore.DeleteSession(ctx, session.ID); err != nil {
+				return errors.Wrapf(ctx, err, "delete session %s failed", session.ID)
+			}
+			event := NewAuditEvent(req, AuditEventLogout)
+			event.User = session.User
+			event.Provider = session.Provider
+			event.SessionID = session.ID
+			event.Reason = "backchannel"
+			auditLogger.Log(ctx, event)
+			glog.V(2).Infof("session %s of %s revoked by back-channel logout", session.ID, session.User)
+		}
+		resp.WriteHeader(http.StatusOK)
+		return nil
+	})
+}
+
+// logoutTokenMatches returns true if the session of provider belongs to sub and sid of the token, empty claims match all
+func logoutTokenMatches(claims LogoutTokenClaims, provider string, session Session) bool {
+	if session.Provider != provider {
+		return false
+	}
+	if claims.Subject != "" && claims.Subject != session.Subject {
+		return false
+	}
+	if claims.SID != "" && claims.SID != session.ProviderSessionID {
+		return false
+	}
+	return true
+}
+
+func writeBackChannelLogoutError(resp http.ResponseWriter, status int, code string, description string) error {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	return json.NewEncoder(resp).Encode(BackChannelLogoutResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
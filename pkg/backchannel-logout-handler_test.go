@@ -0,0 +1,160 @@
+package pkg_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("BackChannelLogoutHandler", func() {
+	var ctx context.Context
+	var privateKey *rsa.PrivateKey
+	var server *httptest.Server
+	var sessionStore pkg.SessionStore
+	var auditLogger *mocks.AuditLogger
+	var claims jwt.MapClaims
+	var typ string
+	var recorder *httptest.ResponseRecorder
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			_ = json.NewEncoder(resp).Encode(map[string]interface{}{
+				"keys": []map[string]string{{
+					"kty": "RSA",
+					"kid": "key1",
+					"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+				}},
+			})
+		}))
+		sessionStore = pkg.NewMemorySessionStore()
+		expiresAt := time.Now().Add(time.Hour)
+		for _, session := range []pkg.Session{
+			{ID: "s1", User: "jdoe@example.com", Provider: pkg.ProviderGoogle, Subject: "1234", ProviderSessionID: "sid1", ExpiresAt: expiresAt},
+			{ID: "s2", User: "jdoe@example.com", Provider: pkg.ProviderGoogle, Subject: "1234", ProviderSessionID: "sid2", ExpiresAt: expiresAt},
+			{ID: "s3", User: "other@example.com", Provider: pkg.ProviderGoogle, Subject: "5678", ExpiresAt: expiresAt},
+		} {
+			Expect(sessionStore.SaveSession(ctx, session)).To(BeNil())
+		}
+		auditLogger = &mocks.AuditLogger{}
+		claims = jwt.MapClaims{
+			"iss":    "https://accounts.google.com",
+			"aud":    "client-id",
+			"sub":    "1234",
+			"iat":    time.Now().Unix(),
+			"exp":    time.Now().Add(2 * time.Minute).Unix(),
+			"jti":    "logout-1",
+			"events": map[string]interface{}{pkg.BackChannelLogoutEvent: map[string]interface{}{}},
+		}
+		typ = pkg.LogoutTokenType
+		recorder = httptest.NewRecorder()
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	JustBeforeEach(func() {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key1"
+		if typ != "" {
+			token.Header["typ"] = typ
+		}
+		logoutToken, err := token.SignedString(privateKey)
+		Expect(err).To(BeNil())
+		req := httptest.NewRequest(http.MethodPost, pkg.BackChannelLogoutPathOf(pkg.ProviderGoogle), strings.NewReader(url.Values{"logout_token": {logoutToken}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		verifier := pkg.NewLogoutTokenVerifier(pkg.NewJWKS(http.DefaultClient, server.URL), pkg.GoogleIssuers, "client-id")
+		Expect(pkg.NewBackChannelLogoutHandler(pkg.ProviderGoogle, verifier, sessionStore, auditLogger).ServeHTTP(ctx, recorder, req)).To(BeNil())
+	})
+	revoked := func(id string) bool {
+		result, err := sessionStore.SessionRevoked(ctx, id)
+		Expect(err).To(BeNil())
+		return result
+	}
+	It("revokes all sessions of the subject", func() {
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(revoked("s1")).To(BeTrue())
+		Expect(revoked("s2")).To(BeTrue())
+		Expect(revoked("s3")).To(BeFalse())
+		Expect(auditLogger.LogCallCount()).To(Equal(2))
+	})
+	Context("with sid", func() {
+		BeforeEach(func() {
+			claims["sid"] = "sid2"
+		})
+		It("revokes only the session of the sid", func() {
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(revoked("s1")).To(BeFalse())
+			Expect(revoked("s2")).To(BeTrue())
+		})
+	})
+	Context("without logout event", func() {
+		BeforeEach(func() {
+			claims["events"] = map[string]interface{}{}
+		})
+		It("rejects token", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(revoked("s1")).To(BeFalse())
+		})
+	})
+	Context("without typ", func() {
+		BeforeEach(func() {
+			typ = ""
+		})
+		It("rejects token", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(revoked("s1")).To(BeFalse())
+		})
+	})
+	Context("of typ JWT", func() {
+		BeforeEach(func() {
+			typ = "JWT"
+		})
+		It("rejects token", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+	Context("of typ application/logout+jwt", func() {
+		BeforeEach(func() {
+			typ = "application/logout+jwt"
+		})
+		It("revokes sessions", func() {
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(revoked("s1")).To(BeTrue())
+		})
+	})
+	Context("with nonce", func() {
+		BeforeEach(func() {
+			claims["nonce"] = "n"
+		})
+		It("rejects token", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+	Context("of other audience", func() {
+		BeforeEach(func() {
+			claims["aud"] = "other-client"
+		})
+		It("rejects token", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(revoked("s1")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bborbe/errors"
+)
+
+// CallbackHosts the callback url may be derived from. An entry like *.example.com
+// matches exactly one subdomain label, e.g. app1.example.com but not example.com.
+// Hosts with a port must list the port, e.g. localhost:8080.
+type CallbackHosts []string
+
+// Validate the hosts, entries must not contain a scheme or path
+func (c CallbackHosts) Validate(ctx context.Context) error {
+	for i, host := range c {
+		name := strings.TrimPrefix(host, "*.")
+		if name == "" || strings.ContainsAny(name, "/*@?#") {
+			return errors.Errorf(ctx, "[%d]: '%s' is not a host", i, host)
+		}
+		if u, err := url.Parse("https://" + name); err != nil || u.Host != name {
+			return errors.Errorf(ctx, "[%d]: '%s' is not a host", i, host)
+		}
+	}
+	return nil
+}
+
+// Allows returns true if host matches an entry
+func (c CallbackHosts) Allows(host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range c {
+		entry = strings.ToLower(entry)
+		if entry == host {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(entry, "*"); ok {
+			label, found := strings.CutSuffix(host, suffix)
+			if found && label != "" && !strings.ContainsAny(label, ".:") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RedirectURL returns the callback url of provider on the host of req if the host is allowed.
+// Otherwise it returns an empty string and the configured redirect url of the provider is used.
+func (c CallbackHosts) RedirectURL(req *http.Request, provider Provider) string {
+	host := RequestHost(req)
+	if !c.Allows(host) {
+		return ""
+	}
+	configured, err := url.Parse(provider.RedirectURL())
+	if err != nil {
+		return ""
+	}
+	scheme := "http"
+	if IsHTTPS(req) {
+		scheme = "https"
+	}
+	return (&url.URL{
+		Scheme:   scheme,
+		Host:     host,
+		Path:     configured.Path,
+		RawQuery: configured.RawQuery,
+	}).String()
+}
@@ -0,0 +1,65 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("CallbackHosts", func() {
+	callbackHosts := pkg.CallbackHosts{"*.example.com", "app.example.org", "localhost:8080"}
+	DescribeTable("Allows",
+		func(host string, expected bool) {
+			Expect(callbackHosts.Allows(host)).To(Equal(expected))
+		},
+		Entry("subdomain", "app1.example.com", true),
+		Entry("subdomain upper case", "APP1.example.com", true),
+		Entry("parent domain", "example.com", false),
+		Entry("nested subdomain", "a.app1.example.com", false),
+		Entry("other domain", "app1.example.com.evil.com", false),
+		Entry("subdomain with port", "app1.example.com:8443", false),
+		Entry("exact host", "app.example.org", true),
+		Entry("exact host with port", "localhost:8080", true),
+		Entry("other port", "localhost:9090", false),
+	)
+	DescribeTable("Validate",
+		func(hosts pkg.CallbackHosts, valid bool) {
+			err := hosts.Validate(context.Background())
+			if valid {
+				Expect(err).To(BeNil())
+			} else {
+				Expect(err).NotTo(BeNil())
+			}
+		},
+		Entry("hosts", pkg.CallbackHosts{"*.example.com", "localhost:8080"}, true),
+		Entry("url", pkg.CallbackHosts{"https://example.com"}, false),
+		Entry("path", pkg.CallbackHosts{"example.com/callback"}, false),
+		Entry("wildcard inside", pkg.CallbackHosts{"app.*.example.com"}, false),
+		Entry("empty", pkg.CallbackHosts{""}, false),
+	)
+	Describe("RedirectURL", func() {
+		var provider *mocks.Provider
+		BeforeEach(func() {
+			provider = &mocks.Provider{}
+			provider.RedirectURLReturns("https://auth.example.com/oauth2/callback")
+		})
+		It("returns callback url on allowed host", func() {
+			req := httptest.NewRequest(http.MethodGet, "https://app1.example.com/foo", nil)
+			Expect(callbackHosts.RedirectURL(req, provider)).To(Equal("https://app1.example.com/oauth2/callback"))
+		})
+		It("keeps scheme of the request", func() {
+			req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/foo", nil)
+			Expect(callbackHosts.RedirectURL(req, provider)).To(Equal("http://localhost:8080/oauth2/callback"))
+		})
+		It("returns empty for other hosts", func() {
+			req := httptest.NewRequest(http.MethodGet, "https://evil.com/foo", nil)
+			Expect(callbackHosts.RedirectURL(req, provider)).To(BeEmpty())
+		})
+	})
+})
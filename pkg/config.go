@@ -0,0 +1,235 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bborbe/errors"
+	"go.yaml.in/yaml/v3"
+)
+
+// Config of the gateway, read from a YAML or JSON file. Flags override values of the file.
+type Config struct {
+	// SigningKey of cookies and login states
+	SigningKey string           `yaml:"signing_key"`
+	Providers  []ProviderConfig `yaml:"providers"`
+	Cookie     CookieOptions    `yaml:"cookie"`
+	// Upstreams requests are proxied to after the login, empty answers with the logged in user
+	Upstreams []Upstream `yaml:"upstreams"`
+	// Policies restrict which users may access a path
+	Policies Policies `yaml:"policies"`
+	// APIPathPrefixes are answered with 401 instead of a login redirect
+	APIPathPrefixes []string `yaml:"api_path_prefixes"`
+	// Groups of Google users are looked up at login and per request if configured
+	Groups GroupsConfig `yaml:"groups"`
+	// ForwardAccessToken of the provider to upstreams: authorization, header or empty to not forward it
+	ForwardAccessToken string `yaml:"forward_access_token"`
+	// AdminGroups may use the admin API, empty disables it
+	AdminGroups []string `yaml:"admin_groups"`
+	// TrustedProxies are CIDRs or IPs of load balancers whose Forwarded and X-Forwarded-* headers are trusted
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// RateLimit of login redirects and callbacks per client IP
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	// CallbackHosts the callback url is derived from, other hosts use the redirect_url of the provider
+	CallbackHosts CallbackHosts `yaml:"callback_hosts"`
+	// Handoff of logins to app hosts on other domains
+	Handoff HandoffConfig `yaml:"handoff"`
+}
+
+// redacted is shown instead of secrets
+const redacted = "REDACTED"
+
+// Redacted returns a copy of the config without secrets
+func (c Config) Redacted() Config {
+	if c.SigningKey != "" {
+		c.SigningKey = redacted
+	}
+	providers := make([]ProviderConfig, len(c.Providers))
+	for i, provider := range c.Providers {
+		if provider.ClientSecret != "" {
+			provider.ClientSecret = redacted
+		}
+		providers[i] = provider
+	}
+	c.Providers = providers
+	return c
+}
+
+// ProviderConfig configures the login with an identity provider
+type ProviderConfig struct {
+	// ID of the provider, google or github
+	ID           string `yaml:"id"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	RedirectURL  string `yaml:"redirect_url"`
+	// Domain the users of the provider must belong to, empty allows all
+	Domain          string `yaml:"domain"`
+	AuthCodeOptions `yaml:",inline"`
+	// BackChannelLogout of the provider, only OpenID Connect providers send logout tokens
+	BackChannelLogout BackChannelLogoutConfig `yaml:"backchannel_logout"`
+}
+
+// BackChannelLogoutConfig enables OpenID Connect back-channel logout of a provider
+type BackChannelLogoutConfig struct {
+	// Issuer of the logout tokens, empty disables back-channel logout
+	Issuer string `yaml:"issuer"`
+	// JWKSURL of the keys signing the logout tokens
+	JWKSURL string `yaml:"jwks_url"`
+}
+
+// Validate the back-channel logout config
+func (b BackChannelLogoutConfig) Validate(ctx context.Context) error {
+	if b.Issuer == "" {
+		if b.JWKSURL != "" {
+			return errors.Errorf(ctx, "issuer missing")
+		}
+		return nil
+	}
+	if err := validateAbsoluteURL(ctx, b.JWKSURL); err != nil {
+		return errors.Wrapf(ctx, err, "jwks_url invalid")
+	}
+	return nil
+}
+
+// Validate the provider
+func (p ProviderConfig) Validate(ctx context.Context) error {
+	if p.ID != ProviderGoogle && p.ID != ProviderGitHub {
+		return errors.Errorf(ctx, "id '%s' unknown, expected %s or %s", p.ID, ProviderGoogle, ProviderGitHub)
+	}
+	if p.ClientID == "" {
+		return errors.Errorf(ctx, "client_id missing")
+	}
+	if p.ClientSecret == "" {
+		return errors.Errorf(ctx, "client_secret missing")
+	}
+	if err := validateAbsoluteURL(ctx, p.RedirectURL); err != nil {
+		return errors.Wrapf(ctx, err, "redirect_url invalid")
+	}
+	if err := p.AuthCodeOptions.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "authorization options invalid")
+	}
+	if err := p.BackChannelLogout.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "backchannel_logout invalid")
+	}
+	if p.ID == ProviderGitHub && p.BackChannelLogout.Issuer != "" {
+		return errors.Errorf(ctx, "backchannel_logout not supported by %s", p.ID)
+	}
+	return nil
+}
+
+// Upstream receives all requests with the path prefix
+type Upstream struct {
+	PathPrefix string `yaml:"path_prefix"`
+	URL        string `yaml:"url"`
+}
+
+// Validate the upstream
+func (u Upstream) Validate(ctx context.Context) error {
+	if !strings.HasPrefix(u.PathPrefix, "/") {
+		return errors.Errorf(ctx, "path_prefix '%s' must start with /", u.PathPrefix)
+	}
+	if err := validateAbsoluteURL(ctx, u.URL); err != nil {
+		return errors.Wrapf(ctx, err, "url invalid")
+	}
+	return nil
+}
+
+// Validate the config, the error names the invalid field
+func (c Config) Validate(ctx context.Context) error {
+	if c.SigningKey == "" {
+		return errors.Errorf(ctx, "signing_key missing")
+	}
+	if len(c.Providers) == 0 {
+		return errors.Errorf(ctx, "providers missing")
+	}
+	ids := map[string]bool{}
+	for i, provider := range c.Providers {
+		if err := provider.Validate(ctx); err != nil {
+			return errors.Wrapf(ctx, err, "providers[%d] invalid", i)
+		}
+		if ids[provider.ID] {
+			return errors.Errorf(ctx, "providers[%d]: provider '%s' defined twice", i, provider.ID)
+		}
+		ids[provider.ID] = true
+	}
+	if c.Cookie.TTL < 0 {
+		return errors.Errorf(ctx, "cookie.ttl must not be negative")
+	}
+	prefixes := map[string]bool{}
+	for i, upstream := range c.Upstreams {
+		if err := upstream.Validate(ctx); err != nil {
+			return errors.Wrapf(ctx, err, "upstreams[%d] invalid", i)
+		}
+		if prefixes[upstream.PathPrefix] {
+			return errors.Errorf(ctx, "upstreams[%d]: path_prefix '%s' defined twice", i, upstream.PathPrefix)
+		}
+		prefixes[upstream.PathPrefix] = true
+	}
+	switch c.ForwardAccessToken {
+	case "", ForwardAccessTokenAuthorization, ForwardAccessTokenHeader:
+	default:
+		return errors.Errorf(ctx, "forward_access_token '%s' unknown, expected %s or %s", c.ForwardAccessToken, ForwardAccessTokenAuthorization, ForwardAccessTokenHeader)
+	}
+	if err := c.Groups.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "groups invalid")
+	}
+	if err := c.Policies.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "policies invalid")
+	}
+	if _, err := ParseTrustedProxies(ctx, c.TrustedProxies); err != nil {
+		return errors.Wrapf(ctx, err, "trusted_proxies invalid")
+	}
+	if err := c.RateLimit.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "rate_limit invalid")
+	}
+	if err := c.CallbackHosts.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "callback_hosts invalid")
+	}
+	if err := c.Handoff.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "handoff invalid")
+	}
+	return nil
+}
+
+// ParseConfig from YAML or JSON. Unknown fields are rejected with their line.
+func ParseConfig(ctx context.Context, content []byte) (Config, error) {
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !stderrors.Is(err, io.EOF) {
+		return Config{}, errors.Wrapf(ctx, err, "decode config failed")
+	}
+	return config, nil
+}
+
+// ReadConfig from the given file without validating it
+func ReadConfig(ctx context.Context, path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, errors.Wrapf(ctx, err, "read config file '%s' failed", path)
+	}
+	config, err := ParseConfig(ctx, content)
+	if err != nil {
+		return Config{}, errors.Wrapf(ctx, err, "parse config file '%s' failed", path)
+	}
+	return config, nil
+}
+
+func validateAbsoluteURL(ctx context.Context, value string) error {
+	if value == "" {
+		return errors.Errorf(ctx, "missing")
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "parse '%s' failed", value)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf(ctx, "'%s' is not an absolute http(s) url", value)
+	}
+	return nil
+}
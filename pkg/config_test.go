@@ -0,0 +1,104 @@
+package pkg_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("Config", func() {
+	var ctx context.Context
+	var config pkg.Config
+	BeforeEach(func() {
+		ctx = context.Background()
+		config = pkg.Config{
+			SigningKey: "secret",
+			Providers: []pkg.ProviderConfig{
+				{ID: pkg.ProviderGoogle, ClientID: "id", ClientSecret: "secret", RedirectURL: "https://gateway.example.com/callback"},
+			},
+		}
+	})
+	It("parses yaml", func() {
+		config, err := pkg.ParseConfig(ctx, []byte(`
+signing_key: secret
+providers:
+  - id: github
+    client_id: id
+    client_secret: secret
+    redirect_url: https://gateway.example.com/callback
+cookie:
+  domain: example.com
+  ttl: 8h
+upstreams:
+  - path_prefix: /
+    url: http://app:8080
+policies:
+  - path_prefix: /admin
+    allowed_users: [jdoe@example.com]
+`))
+		Expect(err).To(BeNil())
+		Expect(config.Providers[0].ID).To(Equal(pkg.ProviderGitHub))
+		Expect(config.Cookie.TTL).To(Equal(8 * time.Hour))
+		Expect(config.Upstreams[0].URL).To(Equal("http://app:8080"))
+		Expect(config.Policies[0].AllowedUsers).To(ConsistOf("jdoe@example.com"))
+		Expect(config.Validate(ctx)).To(BeNil())
+	})
+	It("parses json", func() {
+		config, err := pkg.ParseConfig(ctx, []byte(`{"signing_key":"secret","api_path_prefixes":["/api/"]}`))
+		Expect(err).To(BeNil())
+		Expect(config.SigningKey).To(Equal("secret"))
+		Expect(config.APIPathPrefixes).To(ConsistOf("/api/"))
+	})
+	It("rejects unknown fields with line", func() {
+		_, err := pkg.ParseConfig(ctx, []byte("signing_key: secret\nprovider: []\n"))
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("line 2"))
+		Expect(err.Error()).To(ContainSubstring("provider"))
+	})
+	It("is valid", func() {
+		Expect(config.Validate(ctx)).To(BeNil())
+	})
+	It("requires signing key", func() {
+		config.SigningKey = ""
+		Expect(config.Validate(ctx)).To(MatchError(ContainSubstring("signing_key missing")))
+	})
+	It("names the invalid provider field", func() {
+		config.Providers = append(config.Providers, pkg.ProviderConfig{ID: pkg.ProviderGitHub, ClientID: "id", ClientSecret: "secret", RedirectURL: "/callback"})
+		err := config.Validate(ctx)
+		Expect(err).To(MatchError(ContainSubstring("providers[1] invalid")))
+		Expect(err).To(MatchError(ContainSubstring("redirect_url invalid")))
+	})
+	It("rejects duplicate provider", func() {
+		config.Providers = append(config.Providers, config.Providers[0])
+		Expect(config.Validate(ctx)).To(MatchError(ContainSubstring("defined twice")))
+	})
+	It("accepts backchannel logout", func() {
+		config.Providers[0].BackChannelLogout = pkg.BackChannelLogoutConfig{Issuer: "https://accounts.google.com", JWKSURL: "https://www.googleapis.com/oauth2/v3/certs"}
+		Expect(config.Validate(ctx)).To(BeNil())
+	})
+	It("rejects backchannel logout without jwks_url", func() {
+		config.Providers[0].BackChannelLogout = pkg.BackChannelLogoutConfig{Issuer: "https://accounts.google.com"}
+		Expect(config.Validate(ctx)).To(MatchError(ContainSubstring("jwks_url invalid")))
+	})
+	It("rejects backchannel logout without issuer", func() {
+		config.Providers[0].BackChannelLogout = pkg.BackChannelLogoutConfig{JWKSURL: "https://www.googleapis.com/oauth2/v3/certs"}
+		Expect(config.Validate(ctx)).To(MatchError(ContainSubstring("issuer missing")))
+	})
+	It("rejects backchannel logout of github", func() {
+		config.Providers[0].ID = pkg.ProviderGitHub
+		config.Providers[0].BackChannelLogout = pkg.BackChannelLogoutConfig{Issuer: "https://github.com", JWKSURL: "https://github.com/keys"}
+		Expect(config.Validate(ctx)).To(MatchError(ContainSubstring("not supported")))
+	})
+	It("rejects upstream without absolute url", func() {
+		config.Upstreams = []pkg.Upstream{{PathPrefix: "/", URL: "app:8080"}}
+		Expect(config.Validate(ctx)).To(MatchError(ContainSubstring("upstreams[0] invalid")))
+	})
+	It("rejects policy without rules", func() {
+		config.Policies = pkg.Policies{{PathPrefix: "/admin"}}
+		Expect(config.Validate(ctx)).To(MatchError(ContainSubstring("has no allowed_* rule and no step-up")))
+	})
+})
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+)
+
+// ConfigLoader returns the config to apply, e.g. the config file merged with flags
+type ConfigLoader func(ctx context.Context) (Config, error)
+
+// ConfigWatcher applies the config again each time the config file changes
+//
+//counterfeiter:generate -o ../mocks/config-watcher.go --fake-name ConfigWatcher . ConfigWatcher
+type ConfigWatcher interface {
+	// Run until ctx is canceled
+	Run(ctx context.Context) error
+}
+
+// NewConfigWatcher checks the content of path every interval. On change the config of loader
+// is applied to handler. Invalid configs are logged and the running config is kept.
+func NewConfigWatcher(
+	path string,
+	interval time.Duration,
+	loader ConfigLoader,
+	handler ReloadableHandler,
+) ConfigWatcher {
+	return &configWatcher{
+		path:     path,
+		interval: interval,
+		loader:   loader,
+		handler:  handler,
+	}
+}
+
+type configWatcher struct {
+	path     string
+	interval time.Duration
+	loader   ConfigLoader
+	handler  ReloadableHandler
+}
+
+func (c *configWatcher) Run(ctx context.Context) error {
+	last, err := c.checksum(ctx)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "read config file failed")
+	}
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			checksum, err := c.checksum(ctx)
+			if err != nil {
+				glog.Warningf("read config file failed: %v", err)
+				continue
+			}
+			if checksum == last {
+				continue
+			}
+			last = checksum
+			if err := c.reload(ctx); err != nil {
+				glog.Warningf("reload config %s failed, keep running config: %v", c.path, err)
+				continue
+			}
+			glog.V(1).Infof("config %s reloaded", c.path)
+		}
+	}
+}
+
+func (c *configWatcher) reload(ctx context.Context) error {
+	config, err := c.loader(ctx)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "load config failed")
+	}
+	if err := c.handler.Apply(ctx, config); err != nil {
+		return errors.Wrapf(ctx, err, "apply config failed")
+	}
+	return nil
+}
+
+func (c *configWatcher) checksum(ctx context.Context) ([sha256.Size]byte, error) {
+	content, err := os.ReadFile(c.path)
+	if err != nil {
+		return [sha256.Size]byte{}, errors.Wrapf(ctx, err, "read %s failed", c.path)
+	}
+	return sha256.Sum256(content), nil
+}
@@ -13,43 +13,123 @@ import (
 
 // Cookie storing the user pass-through information that is passed on authentication.
 type Cookie struct {
+	// Type is always TokenTypeSession
+	Type TokenType `json:"typ"`
+	// Provider the user authenticated with
+	Provider string `json:"provider,omitempty"`
+	// Groups of the user at login
+	Groups []string `json:"groups,omitempty"`
+	// AuthTime the user authenticated at the provider, nil if unknown
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	ACR      string           `json:"acr,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
 	jwt.RegisteredClaims
 
-	token string
+	token   string
+	options CookieOptions
+}
+
+// CookieOptions of the login cookie
+type CookieOptions struct {
+	// Domain the cookie is sent to, empty for the host of the request only
+	Domain string `yaml:"domain"`
+	// Secure sends the cookie over https only, always set for logins over https
+	Secure bool `yaml:"secure"`
+	// TTL of the login, defaults to 24h
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// ExpiredHTTPCookie removes the cookie with the given name
+func (o CookieOptions) ExpiredHTTPCookie(req *http.Request, name string) *http.Cookie {
+	return &http.Cookie{
+		Name:   name,
+		Path:   "/",
+		Domain: o.Domain,
+		Secure: o.secure(req),
+		Value:  "",
+		MaxAge: -1,
+	}
+}
+
+// secure returns true if the cookie must only be sent over https
+func (o CookieOptions) secure(req *http.Request) bool {
+	return o.Secure || IsHTTPS(req)
 }
 
 func (s Cookie) String() string {
 	return s.token
 }
 
-// HTTPCookie based on Cookie
-func (s Cookie) HTTPCookie() *http.Cookie {
+// HTTPCookie based on Cookie set in the response to req
+func (s Cookie) HTTPCookie(req *http.Request) *http.Cookie {
+	return &http.Cookie{
+		Name:   LoginCookieName,
+		Path:   "/",
+		Domain: s.options.Domain,
+		Secure: s.options.secure(req),
+		Value:  s.String(),
+	}
+}
+
+// LoginHintHTTPCookie keeps the user of the cookie after it expired, see LoginHint
+func (s Cookie) LoginHintHTTPCookie(req *http.Request) *http.Cookie {
 	return &http.Cookie{
-		Name:  LoginCookieName,
-		Path:  "/",
-		Value: s.String(),
+		Name:     LoginHintCookieName,
+		Path:     "/",
+		Domain:   s.options.Domain,
+		Secure:   s.options.secure(req),
+		HttpOnly: true,
+		MaxAge:   int(loginHintMaxAge.Seconds()),
+		Value:    s.Subject,
 	}
 }
 
+// LoginHint returns the user of the previous login or empty
+func LoginHint(req *http.Request) string {
+	cookie, err := req.Cookie(LoginHintCookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// Login completed at a provider, stored in the cookie
+type Login struct {
+	User     string
+	Provider string
+	Groups   []string
+	// AuthTime the user authenticated at the provider, zero if the provider did not tell
+	AuthTime time.Time
+	ACR      string
+	AMR      []string
+}
+
 // CookieGenerator generates and decodes secure cookies
 //
 //counterfeiter:generate -o ../mocks/cookie-generator.go --fake-name CookieGenerator . CookieGenerator
 type CookieGenerator interface {
-	Generate(ctx context.Context, user string) (Cookie, error)
+	Generate(ctx context.Context, login Login) (Cookie, error)
 	Decode(ctx context.Context, cookie string) (Cookie, error)
 }
 
 // NewCookieGenerator using key to sign cookie tokens
-func NewCookieGenerator(key []byte) CookieGenerator {
-	return &cookieGenerator{key: key}
+func NewCookieGenerator(key []byte, options CookieOptions) CookieGenerator {
+	if options.TTL == 0 {
+		options.TTL = 24 * time.Hour
+	}
+	return &cookieGenerator{
+		key:     key,
+		options: options,
+	}
 }
 
 type cookieGenerator struct {
-	key []byte
+	key     []byte
+	options CookieOptions
 }
 
 // Generate a signed cookie
-func (s *cookieGenerator) Generate(ctx context.Context, user string) (Cookie, error) {
+func (s *cookieGenerator) Generate(ctx context.Context, login Login) (Cookie, error) {
 	issuedAt := time.Now().UTC()
 	generateUUID, err := uuid.NewUUID()
 	if err != nil {
@@ -57,13 +137,22 @@ func (s *cookieGenerator) Generate(ctx context.Context, user string) (Cookie, er
 	}
 
 	cookie := Cookie{
+		Type:     TokenTypeSession,
+		Provider: login.Provider,
+		Groups:   login.Groups,
+		ACR:      login.ACR,
+		AMR:      login.AMR,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        generateUUID.String(),
-			Subject:   user,
+			Subject:   login.User,
 			IssuedAt:  jwt.NewNumericDate(issuedAt),
 			NotBefore: jwt.NewNumericDate(issuedAt),
-			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(s.options.TTL)),
 		},
+		options: s.options,
+	}
+	if !login.AuthTime.IsZero() {
+		cookie.AuthTime = jwt.NewNumericDate(login.AuthTime)
 	}
 
 	return s.sign(cookie)
@@ -88,8 +177,15 @@ func (s *cookieGenerator) Decode(ctx context.Context, cookie string) (Cookie, er
 	}
 
 	if claims, ok := token.Claims.(*Cookie); ok && token.Valid {
+		if claims.Type != TokenTypeSession {
+			return Cookie{}, fmt.Errorf("%w: %s", ErrWrongTokenType, claims.Type)
+		}
+		// session cookies are valid on all hosts, tokens bound to an audience are not sessions
+		if len(claims.Audience) > 0 {
+			return Cookie{}, fmt.Errorf("%w: audience %v", ErrWrongTokenType, claims.Audience)
+		}
 		if len(claims.Subject) < 1 {
-			return Cookie{}, errors.New("token invalid")
+			return Cookie{}, ErrSubjectMissing
 		}
 		return *claims, nil
 	}
@@ -99,7 +195,7 @@ func (s *cookieGenerator) Decode(ctx context.Context, cookie string) (Cookie, er
 
 func (s *cookieGenerator) keyFunc(token *jwt.Token) (interface{}, error) {
 	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		return nil, fmt.Errorf("%w: %v", ErrUnexpectedSigningMethod, token.Header["alg"])
 	}
 
 	return s.key, nil
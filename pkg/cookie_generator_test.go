@@ -2,6 +2,8 @@ package pkg_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,14 +15,14 @@ import (
 
 var _ = Describe("CookieGenerator", func() {
 	var signingKey = []byte("test-key")
-	var cookieGenerator = pkg.NewCookieGenerator(signingKey)
+	var cookieGenerator = pkg.NewCookieGenerator(signingKey, pkg.CookieOptions{})
 	var ctx context.Context
 	BeforeEach(func() {
 		ctx = context.Background()
 	})
 	It("generates complete token", func() {
 		user := "jdoe@example.com"
-		cookie, err := cookieGenerator.Generate(ctx, user)
+		cookie, err := cookieGenerator.Generate(ctx, pkg.Login{User: user, Provider: pkg.ProviderGitHub})
 		Expect(err).To(BeNil())
 		Expect(cookie.Subject).To(BeEquivalentTo(user))
 		Expect(cookie.ID).NotTo(BeEmpty())
@@ -31,12 +33,13 @@ var _ = Describe("CookieGenerator", func() {
 	})
 	It("generates valid token", func() {
 		user := "jdoe@example.com"
-		cookie, err := cookieGenerator.Generate(ctx, user)
+		cookie, err := cookieGenerator.Generate(ctx, pkg.Login{User: user, Provider: pkg.ProviderGitHub})
 		Expect(err).To(BeNil())
 		Expect(cookie.String()).NotTo(BeEmpty())
 		cookie, err = cookieGenerator.Decode(ctx, cookie.String())
 		Expect(err).To(BeNil())
 		Expect(cookie.Subject).To(BeEquivalentTo(user))
+		Expect(cookie.Provider).To(Equal(pkg.ProviderGitHub))
 		Expect(cookie.ID).NotTo(BeEmpty())
 		Expect(cookie.IssuedAt.Time).To(BeTemporally(">=", time.Unix(time.Now().Unix(), 0)))
 		Expect(cookie.NotBefore.Time).To(BeTemporally(">=", time.Unix(time.Now().Unix(), 0)))
@@ -44,7 +47,7 @@ var _ = Describe("CookieGenerator", func() {
 	})
 	It("returns error when decoding outdated token", func() {
 		user := "jdoe@example.com"
-		cookie, err := cookieGenerator.Generate(ctx, user)
+		cookie, err := cookieGenerator.Generate(ctx, pkg.Login{User: user, Provider: pkg.ProviderGitHub})
 		Expect(err).To(BeNil())
 		Expect(cookie.String()).NotTo(BeEmpty())
 
@@ -59,10 +62,43 @@ var _ = Describe("CookieGenerator", func() {
 		cookie, err = cookieGenerator.Decode(ctx, token)
 		Expect(err).NotTo(BeNil())
 	})
+	It("rejects state signed with the same key", func() {
+		state, err := pkg.NewStateGenerator(signingKey).Generate(ctx, "/foo", pkg.ProviderGoogle, nil)
+		Expect(err).To(BeNil())
+		_, err = cookieGenerator.Decode(ctx, state.String())
+		Expect(err).To(MatchError(pkg.ErrWrongTokenType))
+		Expect(pkg.TokenErrorReasonOf(err)).To(Equal(pkg.TokenErrorReasonWrongType))
+	})
+	It("rejects session token with audience", func() {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, pkg.Cookie{
+			Type: pkg.TokenTypeSession,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:  "jdoe@example.com",
+				Audience: jwt.ClaimStrings{"app.example.org"},
+			},
+		}).SignedString(signingKey)
+		Expect(err).To(BeNil())
+		_, err = cookieGenerator.Decode(ctx, token)
+		Expect(err).To(MatchError(pkg.ErrWrongTokenType))
+	})
 	It("returns error when decoding invalid string", func() {
 		raw := "0123456789"
 		cookie, err := cookieGenerator.Decode(ctx, raw)
 		Expect(err).NotTo(BeNil())
 		Expect(cookie).To(BeEquivalentTo(pkg.Cookie{}))
 	})
+	It("applies cookie options", func() {
+		cookie, err := pkg.NewCookieGenerator(signingKey, pkg.CookieOptions{Domain: "example.com", Secure: true, TTL: time.Hour}).Generate(ctx, pkg.Login{User: "jdoe@example.com", Provider: pkg.ProviderGoogle})
+		Expect(err).To(BeNil())
+		Expect(cookie.ExpiresAt.Time).To(BeTemporally("<=", time.Now().Add(time.Hour)))
+		httpCookie := cookie.HTTPCookie(httptest.NewRequest(http.MethodGet, "http://example.com/callback", nil))
+		Expect(httpCookie.Domain).To(Equal("example.com"))
+		Expect(httpCookie.Secure).To(BeTrue())
+	})
+	It("sets secure for https requests", func() {
+		cookie, err := cookieGenerator.Generate(ctx, pkg.Login{User: "jdoe@example.com", Provider: pkg.ProviderGoogle})
+		Expect(err).To(BeNil())
+		Expect(cookie.HTTPCookie(httptest.NewRequest(http.MethodGet, "http://example.com/callback", nil)).Secure).To(BeFalse())
+		Expect(cookie.HTTPCookie(httptest.NewRequest(http.MethodGet, "https://example.com/callback", nil)).Secure).To(BeTrue())
+	})
 })
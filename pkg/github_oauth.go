@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bborbe/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+const (
+	githubUserURL   = "https://api.github.com/user"
+	githubEmailsURL = "https://api.github.com/user/emails"
+)
+
+// NewGitHubOAuth returns a Provider running the GitHub OAuth flow using the provided credentials.
+// The user is identified by the primary verified email, if allowedDomain is set it must belong to it.
+func NewGitHubOAuth(
+	httpClient *http.Client,
+	clientID string,
+	clientSecret string,
+	redirectURL string,
+	allowedDomain string,
+	options AuthCodeOptions,
+	metrics Metrics,
+) Provider {
+	return &githubOAuth{
+		config: oauth2.Config{
+			RedirectURL:  redirectURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scopes: append([]string{
+				"read:user",
+				"user:email",
+			}, options.Scopes...),
+			Endpoint: github.Endpoint,
+		},
+		httpClient:    httpClient,
+		allowedDomain: allowedDomain,
+		options:       options,
+		metrics:       metrics,
+	}
+}
+
+type githubOAuth struct {
+	httpClient    *http.Client
+	config        oauth2.Config
+	allowedDomain string
+	options       AuthCodeOptions
+	metrics       Metrics
+}
+
+func (o *githubOAuth) ID() string {
+	return ProviderGitHub
+}
+
+func (o *githubOAuth) Name() string {
+	return "GitHub"
+}
+
+func (o *githubOAuth) RedirectURL() string {
+	return o.config.RedirectURL
+}
+
+// StepUpSupported returns false, GitHub has no parameter to authenticate the user again
+func (o *githubOAuth) StepUpSupported() bool {
+	return false
+}
+
+// AuthCodeURL returns the auth code url for the provided state, GitHub accepts the login hint as login.
+func (o *githubOAuth) AuthCodeURL(state State, request AuthRequest) string {
+	return o.config.AuthCodeURL(state.String(), o.options.authCodeOptions("login", request)...)
+}
+
+func (o *githubOAuth) UserInfo(ctx context.Context, code Code, redirectURL string) (*UserInfo, error) {
+	token, err := exchangeCode(ctx, o.httpClient, o.metrics, ProviderGitHub, o.config, code, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *UserInfo
+	err = providerRequest(ctx, o.metrics, ProviderGitHub, "userinfo", func(ctx context.Context) error {
+		data, err = o.userInfo(ctx, token)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(ctx, fmt.Errorf("%w: %w", ErrUserInfoFailed, err), "get user info failed")
+	}
+
+	if !data.VerifiedEmail {
+		return nil, errors.Wrapf(ctx, ErrUserNotAllowed, "github user %s has no verified primary email", data.ID)
+	}
+	if !inEmailDomain(data.Email, o.allowedDomain) {
+		return nil, errors.Wrapf(ctx, ErrUserNotAllowed, "user %s not in domain %s", data.Email, o.allowedDomain)
+	}
+	data.Token = token
+	return data, nil
+}
+
+// RefreshToken returns token, tokens of GitHub OAuth apps do not expire unless the app opted in to expiration
+func (o *githubOAuth) RefreshToken(ctx context.Context, token *oauth2.Token) (*oauth2.Token, error) {
+	return refreshToken(ctx, o.httpClient, o.metrics, ProviderGitHub, o.config, token)
+}
+
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func (o *githubOAuth) userInfo(ctx context.Context, token *oauth2.Token) (*UserInfo, error) {
+	var user githubUser
+	if err := getJSON(ctx, o.httpClient, token, githubUserURL, &user); err != nil {
+		return nil, errors.Wrapf(ctx, err, "get user failed")
+	}
+	// the email of the profile may be hidden, only the emails endpoint tells if it is verified
+	var emails []githubEmail
+	if err := getJSON(ctx, o.httpClient, token, githubEmailsURL, &emails); err != nil {
+		return nil, errors.Wrapf(ctx, err, "get emails failed")
+	}
+	result := &UserInfo{
+		ID:      strconv.FormatInt(user.ID, 10),
+		Picture: user.AvatarURL,
+	}
+	for _, email := range emails {
+		if email.Primary {
+			result.Email = email.Email
+			result.VerifiedEmail = email.Verified
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,157 @@
+package pkg_test
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+// rewriteTransport sends all requests to the test server instead of GitHub
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (r rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var _ = Describe("GitHubOAuth", func() {
+	var ctx context.Context
+	var server *httptest.Server
+	var emails []map[string]interface{}
+	var allowedDomain string
+	var info *pkg.UserInfo
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		allowedDomain = "example.com"
+		emails = []map[string]interface{}{
+			{"email": "jdoe@other.com", "primary": false, "verified": true},
+			{"email": "jdoe@example.com", "primary": true, "verified": true},
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			resp.Header().Set("Content-Type", "application/json")
+			switch req.URL.Path {
+			case "/login/oauth/access_token":
+				_ = json.NewEncoder(resp).Encode(map[string]interface{}{"access_token": "access", "token_type": "bearer"})
+			case "/user":
+				Expect(req.Header.Get("Authorization")).To(Equal("Bearer access"))
+				_ = json.NewEncoder(resp).Encode(map[string]interface{}{"id": 42, "login": "jdoe", "avatar_url": "https://avatars.example.com/42"})
+			case "/user/emails":
+				Expect(req.Header.Get("Authorization")).To(Equal("Bearer access"))
+				_ = json.NewEncoder(resp).Encode(emails)
+			default:
+				resp.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	JustBeforeEach(func() {
+		target, parseErr := url.Parse(server.URL)
+		Expect(parseErr).To(BeNil())
+		httpClient := &http.Client{Transport: rewriteTransport{target: target}}
+		provider := pkg.NewGitHubOAuth(httpClient, "id", "secret", "https://gateway.example.com/callback", allowedDomain, pkg.AuthCodeOptions{}, &mocks.Metrics{})
+		info, err = provider.UserInfo(ctx, pkg.Code("code"), "")
+	})
+	It("returns the verified primary email", func() {
+		Expect(err).To(BeNil())
+		Expect(info.Email).To(Equal("jdoe@example.com"))
+		Expect(info.VerifiedEmail).To(BeTrue())
+		Expect(info.ID).To(Equal("42"))
+		Expect(info.Picture).To(Equal("https://avatars.example.com/42"))
+		Expect(info.Token.AccessToken).To(Equal("access"))
+	})
+	Context("primary email not verified", func() {
+		BeforeEach(func() {
+			emails = []map[string]interface{}{
+				{"email": "jdoe@example.com", "primary": true, "verified": false},
+				{"email": "jdoe@example.org", "primary": false, "verified": true},
+			}
+		})
+		It("rejects user", func() {
+			Expect(stderrors.Is(err, pkg.ErrUserNotAllowed)).To(BeTrue())
+		})
+	})
+	Context("without primary email", func() {
+		BeforeEach(func() {
+			emails = []map[string]interface{}{
+				{"email": "jdoe@example.com", "primary": false, "verified": true},
+			}
+		})
+		It("rejects user", func() {
+			Expect(stderrors.Is(err, pkg.ErrUserNotAllowed)).To(BeTrue())
+		})
+	})
+	Context("primary email of other domain", func() {
+		BeforeEach(func() {
+			emails = []map[string]interface{}{
+				{"email": "jdoe@example.com", "primary": false, "verified": true},
+				{"email": "jdoe@other.com", "primary": true, "verified": true},
+			}
+		})
+		It("rejects user", func() {
+			Expect(stderrors.Is(err, pkg.ErrUserNotAllowed)).To(BeTrue())
+		})
+	})
+	Context("primary email of subdomain", func() {
+		BeforeEach(func() {
+			emails = []map[string]interface{}{
+				{"email": "jdoe@evil.example.com", "primary": true, "verified": true},
+			}
+		})
+		It("rejects user", func() {
+			Expect(stderrors.Is(err, pkg.ErrUserNotAllowed)).To(BeTrue())
+		})
+	})
+	Context("without domain", func() {
+		BeforeEach(func() {
+			allowedDomain = ""
+			emails = []map[string]interface{}{
+				{"email": "jdoe@other.com", "primary": true, "verified": true},
+			}
+		})
+		It("accepts every verified primary email", func() {
+			Expect(err).To(BeNil())
+			Expect(info.Email).To(Equal("jdoe@other.com"))
+		})
+	})
+	Context("emails endpoint failing", func() {
+		BeforeEach(func() {
+			server.Config.Handler = http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/login/oauth/access_token" {
+					resp.Header().Set("Content-Type", "application/json")
+					_ = json.NewEncoder(resp).Encode(map[string]interface{}{"access_token": "access", "token_type": "bearer"})
+					return
+				}
+				resp.WriteHeader(http.StatusInternalServerError)
+			})
+		})
+		It("returns ErrUserInfoFailed", func() {
+			Expect(stderrors.Is(err, pkg.ErrUserInfoFailed)).To(BeTrue())
+		})
+	})
+	Context("code exchange failing", func() {
+		BeforeEach(func() {
+			server.Config.Handler = http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				resp.WriteHeader(http.StatusUnauthorized)
+			})
+		})
+		It("returns ErrCodeExchangeFailed", func() {
+			Expect(stderrors.Is(err, pkg.ErrCodeExchangeFailed)).To(BeTrue())
+		})
+	})
+})
@@ -2,15 +2,29 @@ package pkg
 
 import (
 	"context"
-	"encoding/json"
+	stderrors "errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bborbe/errors"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
+var (
+	// ErrCodeExchangeFailed is returned if the provider rejected the authorization code
+	ErrCodeExchangeFailed = stderrors.New("code exchange failed")
+	// ErrUserInfoFailed is returned if the user info could not be fetched from the provider
+	ErrUserInfoFailed = stderrors.New("get user info failed")
+	// ErrUserNotAllowed is returned if the user authenticated but is not allowed to login
+	ErrUserNotAllowed = stderrors.New("user not allowed")
+)
+
+const googleUserInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
+
 // UserInfo returned by the OAuth provider
 type UserInfo struct {
 	ID            string `json:"id"`
@@ -18,6 +32,16 @@ type UserInfo struct {
 	VerifiedEmail bool   `json:"verified_email"`
 	Picture       string `json:"picture"`
 	HD            string `json:"hd"`
+	// Token issued by the provider for the login
+	Token *oauth2.Token `json:"-"`
+	// AuthTime the user authenticated at the provider, zero if the provider did not tell
+	AuthTime time.Time `json:"-"`
+	// ACR and AMR of the ID token
+	ACR string   `json:"-"`
+	AMR []string `json:"-"`
+	// Subject and SessionID of the ID token, used by back-channel logout
+	Subject   string `json:"-"`
+	SessionID string `json:"-"`
 }
 
 // Code used for authorization
@@ -27,65 +51,132 @@ func (c Code) String() string {
 	return string(c)
 }
 
-// GoogleOAuth defines the interface used for running a Google OAuth flow
-//
-//counterfeiter:generate -o ../mocks/google-oauth.go --fake-name GoogleOAuth . GoogleOAuth
-type GoogleOAuth interface {
-	AuthCodeURL(state State) string
-	UserInfo(ctx context.Context, code Code) (*UserInfo, error)
-}
-
-// NewGoogleOAuth returns an implementation of the Google OAuth flow using the provided credentials
+// NewGoogleOAuth returns a Provider running the Google OAuth flow using the provided credentials
 func NewGoogleOAuth(
+	httpClient *http.Client,
 	clientID string,
 	clientSecret string,
 	redirectURL string,
 	hostedDomain string,
-) GoogleOAuth {
+	options AuthCodeOptions,
+	metrics Metrics,
+) Provider {
 	return &googleOAuth{
 		config: oauth2.Config{
 			RedirectURL:  redirectURL,
 			ClientID:     strings.ReplaceAll(clientID, "client_id: ", ""),
 			ClientSecret: clientSecret,
-			Scopes: []string{
+			Scopes: append([]string{
+				"openid",
 				"https://www.googleapis.com/auth/userinfo.profile",
 				"https://www.googleapis.com/auth/userinfo.email",
-			},
+			}, options.Scopes...),
 			Endpoint: google.Endpoint,
 		},
+		httpClient:   httpClient,
 		hostedDomain: hostedDomain,
+		options:      options,
+		metrics:      metrics,
 	}
 }
 
 type googleOAuth struct {
+	httpClient   *http.Client
 	config       oauth2.Config
 	hostedDomain string
+	options      AuthCodeOptions
+	metrics      Metrics
+}
+
+func (o *googleOAuth) ID() string {
+	return ProviderGoogle
 }
 
-// AuthCodeURL returns the auth code url for the provided state
-func (o *googleOAuth) AuthCodeURL(state State) string {
-	return o.config.AuthCodeURL(state.String(),
-		oauth2.SetAuthURLParam("hd", o.hostedDomain),
+func (o *googleOAuth) Name() string {
+	return "Google"
+}
+
+func (o *googleOAuth) RedirectURL() string {
+	return o.config.RedirectURL
+}
+
+// StepUpSupported returns true, Google honors max_age and returns auth_time
+func (o *googleOAuth) StepUpSupported() bool {
+	return true
+}
+
+// AuthCodeURL returns the auth code url for the provided state.
+// Step-up is requested with the OpenID Connect max_age and acr_values parameters.
+func (o *googleOAuth) AuthCodeURL(state State, request AuthRequest) string {
+	opts := append(
+		[]oauth2.AuthCodeOption{oauth2.SetAuthURLParam("hd", o.hostedDomain)},
+		o.options.authCodeOptions("login_hint", request)...,
 	)
+	if request.StepUp.MaxAuthAge > 0 {
+		opts = append(opts, oauth2.SetAuthURLParam("max_age", strconv.Itoa(int(request.StepUp.MaxAuthAge.Seconds()))))
+	}
+	if len(request.StepUp.AcceptedACR) > 0 {
+		opts = append(opts, oauth2.SetAuthURLParam("acr_values", strings.Join(request.StepUp.AcceptedACR, " ")))
+	}
+	return o.config.AuthCodeURL(state.String(), opts...)
 }
 
 // UserInfo retrieves the UserInfo for the provided auth code
-func (o *googleOAuth) UserInfo(ctx context.Context, code Code) (*UserInfo, error) {
+func (o *googleOAuth) UserInfo(ctx context.Context, code Code, redirectURL string) (*UserInfo, error) {
+	token, err := exchangeCode(ctx, o.httpClient, o.metrics, ProviderGoogle, o.config, code, redirectURL)
+	if err != nil {
+		return nil, err
+	}
 
-	token, err := o.config.Exchange(ctx, code.String())
+	var data *UserInfo
+	err = providerRequest(ctx, o.metrics, ProviderGoogle, "userinfo", func(ctx context.Context) error {
+		data, err = o.userInfo(ctx, token)
+		return err
+	})
 	if err != nil {
-		return nil, errors.Wrapf(ctx, err, "code exchange failed")
+		return nil, errors.Wrapf(ctx, fmt.Errorf("%w: %w", ErrUserInfoFailed, err), "get user info failed")
 	}
 
-	response, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + token.AccessToken)
+	if !data.VerifiedEmail {
+		return nil, errors.Wrapf(ctx, ErrUserNotAllowed, "email %s not verified", data.Email)
+	}
+	if !inHostedDomain(data.HD, o.hostedDomain) {
+		return nil, errors.Wrapf(ctx, ErrUserNotAllowed, "user %s not in hosted domain %s", data.Email, o.hostedDomain)
+	}
+	claims, err := idTokenClaims(ctx, token)
 	if err != nil {
-		return nil, errors.Wrapf(ctx, err, "failed getting user info")
+		return nil, errors.Wrapf(ctx, fmt.Errorf("%w: %w", ErrUserInfoFailed, err), "get id token claims failed")
+	}
+	if claims.AuthTime != nil {
+		data.AuthTime = claims.AuthTime.Time
 	}
+	data.ACR = claims.ACR
+	data.AMR = claims.AMR
+	data.Subject = claims.Subject
+	data.SessionID = claims.SID
+	data.Token = token
+	return data, nil
+}
 
-	defer response.Body.Close()
+func (o *googleOAuth) RefreshToken(ctx context.Context, token *oauth2.Token) (*oauth2.Token, error) {
+	return refreshToken(ctx, o.httpClient, o.metrics, ProviderGoogle, o.config, token)
+}
+
+func (o *googleOAuth) userInfo(ctx context.Context, token *oauth2.Token) (*UserInfo, error) {
 	var data UserInfo
-	if err := json.NewDecoder(response.Body).Decode(&data); err != nil {
-		return nil, errors.Wrapf(ctx, err, "decode json failed")
+	if err := getJSON(ctx, o.httpClient, token, googleUserInfoURL, &data); err != nil {
+		return nil, errors.Wrapf(ctx, err, "get user info failed")
 	}
 	return &data, nil
 }
+
+// inHostedDomain returns true if no hosted domain is required or the hd claim of the user equals it.
+// Consumer accounts registered with a company email have no hd claim and are rejected.
+func inHostedDomain(hd string, hostedDomain string) bool {
+	return hostedDomain == "" || hd == hostedDomain
+}
+
+// inEmailDomain returns true if no domain is required or the email belongs to it
+func inEmailDomain(email string, domain string) bool {
+	return domain == "" || strings.HasSuffix(email, "@"+domain)
+}
@@ -0,0 +1,189 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// DirectoryAPIURL is the base url of the Admin SDK Directory API
+	DirectoryAPIURL = "https://admin.googleapis.com/admin/directory/v1"
+	// directoryGroupScope allows to list the groups of users
+	directoryGroupScope = "https://www.googleapis.com/auth/admin.directory.group.readonly"
+)
+
+// GroupsConfig enables the lookup of Google Workspace groups at login
+type GroupsConfig struct {
+	// CredentialsFile of a service account with domain-wide delegation of the group scope, empty disables groups
+	CredentialsFile string `yaml:"credentials_file"`
+	// AdminEmail of a Workspace admin the service account acts as
+	AdminEmail string `yaml:"admin_email"`
+	// CacheTTL of the groups of a user, defaults to 5m
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+}
+
+// Validate the groups config
+func (g GroupsConfig) Validate(ctx context.Context) error {
+	if g.CredentialsFile == "" {
+		return nil
+	}
+	if g.AdminEmail == "" {
+		return errors.Errorf(ctx, "admin_email missing")
+	}
+	if g.CacheTTL < 0 {
+		return errors.Errorf(ctx, "cache_ttl must not be negative")
+	}
+	return nil
+}
+
+// GroupResolver returns the groups of a user
+//
+//counterfeiter:generate -o ../mocks/group-resolver.go --fake-name GroupResolver . GroupResolver
+type GroupResolver interface {
+	// Groups of the user that logged in with provider, sorted by email
+	Groups(ctx context.Context, provider string, user string) ([]string, error)
+}
+
+// GroupResolverFunc allows to use a function as GroupResolver
+type GroupResolverFunc func(ctx context.Context, provider string, user string) ([]string, error)
+
+// Groups of the user
+func (g GroupResolverFunc) Groups(ctx context.Context, provider string, user string) ([]string, error) {
+	return g(ctx, provider, user)
+}
+
+// NewNoGroupResolver returns no groups for all users
+func NewNoGroupResolver() GroupResolver {
+	return GroupResolverFunc(func(ctx context.Context, provider string, user string) ([]string, error) {
+		return nil, nil
+	})
+}
+
+// NewDirectoryHTTPClient returns a client authenticated as the service account of credentialsFile
+// acting as adminEmail, requests of the client are sent by httpClient
+func NewDirectoryHTTPClient(ctx context.Context, httpClient *http.Client, credentialsFile string, adminEmail string) (*http.Client, error) {
+	content, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read credentials file '%s' failed", credentialsFile)
+	}
+	config, err := google.JWTConfigFromJSON(content, directoryGroupScope)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse credentials file '%s' failed", credentialsFile)
+	}
+	config.Subject = adminEmail
+	// the context is only used to pass httpClient, it must live as long as the returned client
+	return config.Client(context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)), nil
+}
+
+// NewDirectoryGroupResolver lists the groups of Google users with the Directory API at baseURL.
+// httpClient must authenticate requests, see NewDirectoryHTTPClient. Users of other providers have no groups.
+func NewDirectoryGroupResolver(httpClient *http.Client, baseURL string, metrics Metrics) GroupResolver {
+	return &directoryGroupResolver{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		metrics:    metrics,
+	}
+}
+
+type directoryGroupResolver struct {
+	httpClient *http.Client
+	baseURL    string
+	metrics    Metrics
+}
+
+type directoryGroups struct {
+	Groups []struct {
+		Email string `json:"email"`
+	} `json:"groups"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func (d *directoryGroupResolver) Groups(ctx context.Context, provider string, user string) ([]string, error) {
+	if provider != ProviderGoogle {
+		return nil, nil
+	}
+	var result []string
+	err := providerRequest(ctx, d.metrics, ProviderGoogle, "groups", func(ctx context.Context) error {
+		pageToken := ""
+		for {
+			values := url.Values{"userKey": {user}}
+			if pageToken != "" {
+				values.Set("pageToken", pageToken)
+			}
+			var page directoryGroups
+			if err := getJSON(ctx, d.httpClient, nil, d.baseURL+"/groups?"+values.Encode(), &page); err != nil {
+				return errors.Wrapf(ctx, err, "list groups failed")
+			}
+			for _, group := range page.Groups {
+				result = append(result, group.Email)
+			}
+			if page.NextPageToken == "" {
+				return nil
+			}
+			pageToken = page.NextPageToken
+		}
+	})
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "get groups of %s failed", user)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// NewCachedGroupResolver returns the groups of resolver, cached per user for ttl
+func NewCachedGroupResolver(resolver GroupResolver, ttl time.Duration) GroupResolver {
+	return &cachedGroupResolver{
+		resolver: resolver,
+		ttl:      ttl,
+		entries:  map[string]cachedGroups{},
+	}
+}
+
+type cachedGroups struct {
+	groups  []string
+	expires time.Time
+}
+
+type cachedGroupResolver struct {
+	resolver GroupResolver
+	ttl      time.Duration
+
+	mux     sync.Mutex
+	entries map[string]cachedGroups
+}
+
+func (c *cachedGroupResolver) Groups(ctx context.Context, provider string, user string) ([]string, error) {
+	key := provider + "/" + user
+	now := time.Now()
+	c.mux.Lock()
+	entry, ok := c.entries[key]
+	c.mux.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.groups, nil
+	}
+	groups, err := c.resolver.Groups(ctx, provider, user)
+	if err != nil {
+		return nil, err
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[key] = cachedGroups{
+		groups:  groups,
+		expires: now.Add(c.ttl),
+	}
+	return groups, nil
+}
@@ -0,0 +1,138 @@
+package pkg_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("GroupResolver", func() {
+	var ctx context.Context
+	var server *httptest.Server
+	var requests atomic.Int32
+	var status int
+	var groups []string
+	var err error
+	var provider string
+	var authorization string
+	BeforeEach(func() {
+		ctx = context.Background()
+		requests.Store(0)
+		status = http.StatusOK
+		provider = pkg.ProviderGoogle
+		// stand-in for the Directory API returning two pages
+		server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/token" {
+				Expect(req.ParseForm()).To(BeNil())
+				Expect(req.Form.Get("grant_type")).To(Equal("urn:ietf:params:oauth:grant-type:jwt-bearer"))
+				resp.Header().Set("Content-Type", "application/json")
+				_, _ = resp.Write([]byte(`{"access_token":"directory-token","token_type":"Bearer","expires_in":3600}`))
+				return
+			}
+			requests.Add(1)
+			authorization = req.Header.Get("Authorization")
+			Expect(req.URL.Path).To(Equal("/groups"))
+			Expect(req.URL.Query().Get("userKey")).To(Equal("jdoe@example.com"))
+			if status != http.StatusOK {
+				resp.WriteHeader(status)
+				return
+			}
+			if req.URL.Query().Get("pageToken") == "" {
+				_ = json.NewEncoder(resp).Encode(map[string]interface{}{
+					"groups":        []map[string]string{{"email": "staff@example.com"}},
+					"nextPageToken": "page2",
+				})
+				return
+			}
+			_ = json.NewEncoder(resp).Encode(map[string]interface{}{
+				"groups": []map[string]string{{"email": "admins@example.com"}},
+			})
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("directory", func() {
+		JustBeforeEach(func() {
+			groups, err = pkg.NewDirectoryGroupResolver(server.Client(), server.URL, &mocks.Metrics{}).Groups(ctx, provider, "jdoe@example.com")
+		})
+		It("returns groups of all pages sorted", func() {
+			Expect(err).To(BeNil())
+			Expect(groups).To(Equal([]string{"admins@example.com", "staff@example.com"}))
+			Expect(requests.Load()).To(Equal(int32(2)))
+		})
+		Context("api error", func() {
+			BeforeEach(func() {
+				status = http.StatusForbidden
+			})
+			It("returns error", func() {
+				Expect(err).NotTo(BeNil())
+			})
+		})
+		Context("other provider", func() {
+			BeforeEach(func() {
+				provider = pkg.ProviderGitHub
+			})
+			It("returns no groups without request", func() {
+				Expect(err).To(BeNil())
+				Expect(groups).To(BeEmpty())
+				Expect(requests.Load()).To(Equal(int32(0)))
+			})
+		})
+	})
+	Context("service account", func() {
+		It("authenticates with token of service account", func() {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).To(BeNil())
+			credentials, err := json.Marshal(map[string]string{
+				"type":         "service_account",
+				"client_email": "groups@project.iam.gserviceaccount.com",
+				"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+				"token_uri":    server.URL + "/token",
+			})
+			Expect(err).To(BeNil())
+			credentialsFile := filepath.Join(GinkgoT().TempDir(), "credentials.json")
+			Expect(os.WriteFile(credentialsFile, credentials, 0600)).To(BeNil())
+
+			httpClient, err := pkg.NewDirectoryHTTPClient(ctx, server.Client(), credentialsFile, "admin@example.com")
+			Expect(err).To(BeNil())
+			groups, err := pkg.NewDirectoryGroupResolver(httpClient, server.URL, &mocks.Metrics{}).Groups(ctx, provider, "jdoe@example.com")
+			Expect(err).To(BeNil())
+			Expect(groups).To(HaveLen(2))
+			Expect(authorization).To(Equal("Bearer directory-token"))
+		})
+	})
+	Context("cached", func() {
+		var resolver pkg.GroupResolver
+		BeforeEach(func() {
+			resolver = pkg.NewCachedGroupResolver(pkg.NewDirectoryGroupResolver(server.Client(), server.URL, &mocks.Metrics{}), 50*time.Millisecond)
+		})
+		It("requests groups again after ttl", func() {
+			for i := 0; i < 2; i++ {
+				groups, err = resolver.Groups(ctx, provider, "jdoe@example.com")
+				Expect(err).To(BeNil())
+				Expect(groups).To(HaveLen(2))
+			}
+			Expect(requests.Load()).To(Equal(int32(2)))
+			time.Sleep(60 * time.Millisecond)
+			_, err = resolver.Groups(ctx, provider, "jdoe@example.com")
+			Expect(err).To(BeNil())
+			Expect(requests.Load()).To(Equal(int32(4)))
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/url"
+
+	"github.com/bborbe/errors"
+	libhttp "github.com/bborbe/http"
+	"github.com/golang/glog"
+)
+
+// HandoffAuthorizePath on the central auth host hands the login over to an app host
+const HandoffAuthorizePath = "/sso/authorize"
+
+// NewHandoffAuthorizeHandler redirects a logged in user to the exchange endpoint of the host in the
+// rd parameter with a one-time code bound to that host and the nonce parameter, see NewHandoffExchangeHandler.
+// The code carries sub and sid of the session, so back-channel logout revokes the session on the app host too.
+// Only hosts in handoffHosts receive codes. It must run after the login middleware,
+// which logs the user in at the provider first.
+func NewHandoffAuthorizeHandler(
+	handoffGenerator HandoffGenerator,
+	handoffHosts CallbackHosts,
+	sessionStore SessionStore,
+	auditLogger AuditLogger,
+) libhttp.WithError {
+	return libhttp.WithErrorFunc(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+		resp.Header().Set("Cache-Control", "no-store")
+		identity, ok := IdentityFromContext(ctx)
+		if !ok || identity.SessionID == "" {
+			// tokens and API keys must not turn into cookies of other hosts
+			http.Error(resp, "login required", http.StatusForbidden)
+			return nil
+		}
+		origin, err := url.Parse(req.URL.Query().Get("rd"))
+		if err != nil || (origin.Scheme != "http" && origin.Scheme != "https") || origin.User != nil || !handoffHosts.Allows(origin.Host) {
+			http.Error(resp, "invalid redirect", http.StatusBadRequest)
+			return nil
+		}
+		nonce := req.URL.Query().Get("nonce")
+		if nonce == "" || len(nonce) > 128 {
+			http.Error(resp, "invalid nonce", http.StatusBadRequest)
+			return nil
+		}
+		session, err := sessionStore.Session(ctx, identity.SessionID)
+		if err != nil {
+			if !stderrors.Is(err, ErrNotFound) {
+				return errors.Wrapf(ctx, err, "get session %s failed", identity.SessionID)
+			}
+			// the app host session can not be found by back-channel logout without sub and sid
+			glog.Warningf("session %s of %s not found, hand over without provider session", identity.SessionID, identity.User)
+			session = &Session{}
+		}
+		code, err := handoffGenerator.Generate(ctx, *identity, *session, origin.Host, origin.String(), nonce)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "generate handoff code failed")
+		}
+		event := NewAuditEvent(req, AuditEventHandoffIssued)
+		event.User = identity.User
+		event.Provider = identity.Provider
+		event.Origin = origin.String()
+		event.SessionID = identity.SessionID
+		auditLogger.Log(ctx, event)
+
+		exchangeURL := url.URL{
+			Scheme:   origin.Scheme,
+			Host:     origin.Host,
+			Path:     HandoffExchangePath,
+			RawQuery: url.Values{"code": {code.String()}}.Encode(),
+		}
+		glog.V(2).Infof("hand login of %s over to %s", identity.User, origin.Host)
+		http.Redirect(resp, req, exchangeURL.String(), http.StatusFound)
+		return nil
+	})
+}
@@ -0,0 +1,98 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("HandoffAuthorizeHandler", func() {
+	var ctx context.Context
+	var handoffGenerator pkg.HandoffGenerator
+	var auditLogger *mocks.AuditLogger
+	var sessionStore pkg.SessionStore
+	var recorder *httptest.ResponseRecorder
+	var identity *pkg.Identity
+	var target string
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		handoffGenerator = pkg.NewHandoffGenerator([]byte("test-key"))
+		auditLogger = &mocks.AuditLogger{}
+		sessionStore = pkg.NewMemorySessionStore()
+		Expect(sessionStore.SaveSession(ctx, pkg.Session{ID: "session-id", User: "jdoe@example.com", Provider: pkg.ProviderGoogle, Subject: "1234", ProviderSessionID: "sid1", ExpiresAt: time.Now().Add(time.Hour)})).To(BeNil())
+		recorder = httptest.NewRecorder()
+		identity = &pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGoogle, SessionID: "session-id"}
+		target = "https://auth.example.com/sso/authorize?rd=https%3A%2F%2Fapp.example.org%2Ffoo%3Fbar%3D1&nonce=n1"
+	})
+	JustBeforeEach(func() {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if identity != nil {
+			ctx = pkg.WithIdentity(ctx, identity)
+		}
+		handler := pkg.NewHandoffAuthorizeHandler(handoffGenerator, pkg.CallbackHosts{"app.example.org"}, sessionStore, auditLogger)
+		err = handler.ServeHTTP(ctx, recorder, req.WithContext(ctx))
+	})
+	It("redirects to exchange of host with code", func() {
+		Expect(err).To(BeNil())
+		Expect(recorder.Code).To(Equal(http.StatusFound))
+		location, parseErr := url.Parse(recorder.Header().Get("Location"))
+		Expect(parseErr).To(BeNil())
+		Expect(location.Host).To(Equal("app.example.org"))
+		Expect(location.Path).To(Equal(pkg.HandoffExchangePath))
+		code, decodeErr := handoffGenerator.Decode(ctx, location.Query().Get("code"), "app.example.org")
+		Expect(decodeErr).To(BeNil())
+		Expect(code.Subject).To(Equal("jdoe@example.com"))
+		Expect(code.Origin).To(Equal("https://app.example.org/foo?bar=1"))
+		Expect(code.Nonce).To(Equal("n1"))
+		Expect(code.ProviderSubject).To(Equal("1234"))
+		Expect(code.ProviderSessionID).To(Equal("sid1"))
+	})
+	It("audits handoff", func() {
+		_, event := auditLogger.LogArgsForCall(0)
+		Expect(event.Type).To(Equal(pkg.AuditEventHandoffIssued))
+		Expect(event.SessionID).To(Equal("session-id"))
+	})
+	Context("host not allowed", func() {
+		BeforeEach(func() {
+			target = "https://auth.example.com/sso/authorize?rd=https%3A%2F%2Fevil.example.net%2F&nonce=n1"
+		})
+		It("returns bad request", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(auditLogger.LogCallCount()).To(Equal(0))
+		})
+	})
+	Context("without nonce", func() {
+		BeforeEach(func() {
+			target = "https://auth.example.com/sso/authorize?rd=https%3A%2F%2Fapp.example.org%2Ffoo"
+		})
+		It("returns bad request", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+	Context("session not stored", func() {
+		BeforeEach(func() {
+			identity.SessionID = "unknown"
+		})
+		It("hands over without provider session", func() {
+			Expect(err).To(BeNil())
+			Expect(recorder.Code).To(Equal(http.StatusFound))
+		})
+	})
+	Context("authenticated without session", func() {
+		BeforeEach(func() {
+			identity = &pkg.Identity{User: "ci@example.com"}
+		})
+		It("returns forbidden", func() {
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		})
+	})
+})
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bborbe/errors"
+	libhttp "github.com/bborbe/http"
+	"github.com/golang/glog"
+)
+
+// HandoffExchangePath on app hosts exchanges handoff codes for a login cookie
+const HandoffExchangePath = "/sso/exchange"
+
+// NewHandoffExchangeHandler completes a login handed over by the central auth host.
+// The code must be issued for the host of the request and the nonce cookie of the browser,
+// it is accepted once,
+// the user gets a new session on this host and is returned to the origin of the code.
+func NewHandoffExchangeHandler(
+	handoffGenerator HandoffGenerator,
+	replayStore ReplayStore,
+	cookieGenerator CookieGenerator,
+	sessionStore SessionStore,
+	metrics Metrics,
+	auditLogger AuditLogger,
+	templates Templates,
+) libhttp.WithError {
+	return libhttp.WithErrorFunc(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+		resp.Header().Set("Cache-Control", "no-store")
+		// the code is part of the url, do not pass it on to the origin
+		resp.Header().Set("Referrer-Policy", "no-referrer")
+		providerID := ProviderUnknown
+		fail := func(reason CallbackFailureReason, origin string, cause error) error {
+			metrics.CallbackFailure(providerID, reason)
+			event := NewAuditEvent(req, AuditEventLoginDenied)
+			event.Provider = providerID
+			event.Origin = origin
+			event.Reason = string(reason)
+			auditLogger.Log(ctx, event)
+			return WriteLoginErrorPage(ctx, resp, templates, reason, origin, "", cause)
+		}
+		host := RequestHost(req)
+		code, err := handoffGenerator.Decode(ctx, req.URL.Query().Get("code"), host)
+		if err != nil {
+			return fail(CallbackFailureReasonInvalidState, "", errors.Wrapf(ctx, err, "invalid handoff code for %s", host))
+		}
+		if code.Provider != "" {
+			providerID = code.Provider
+		}
+		origin := SafeOrigin(code.Origin, host)
+		if !HandoffNonceMatches(req, code) {
+			return fail(CallbackFailureReasonInvalidState, origin, errors.Errorf(ctx, "handoff code of %s not started by this browser", code.Subject))
+		}
+		unused, err := replayStore.Use(ctx, code.ID, code.ExpiresAt.Time)
+		if err != nil {
+			return fail(CallbackFailureReasonInternal, origin, errors.Wrapf(ctx, err, "use handoff code failed"))
+		}
+		if !unused {
+			return fail(CallbackFailureReasonInvalidState, origin, errors.Errorf(ctx, "handoff code %s of %s used twice", code.ID, code.Subject))
+		}
+
+		login := code.Login()
+		cookie, err := cookieGenerator.Generate(ctx, login)
+		if err != nil {
+			return fail(CallbackFailureReasonInternal, origin, errors.Wrapf(ctx, err, "generating cookie failed"))
+		}
+		if err := sessionStore.SaveSession(ctx, Session{
+			ID:        cookie.ID,
+			User:      login.User,
+			Provider:  login.Provider,
+			CreatedAt: cookie.IssuedAt.Time,
+			ExpiresAt: cookie.ExpiresAt.Time,
+			// allow back-channel logout to find the session
+			Subject:           code.ProviderSubject,
+			ProviderSessionID: code.ProviderSessionID,
+			IP:                ClientIP(req),
+			UserAgent:         req.UserAgent(),
+			LastSeen:          cookie.IssuedAt.Time,
+		}); err != nil {
+			return fail(CallbackFailureReasonInternal, origin, errors.Wrapf(ctx, err, "save session failed"))
+		}
+		metrics.CallbackSuccess(providerID)
+		event := NewAuditEvent(req, AuditEventLoginSucceeded)
+		event.User = login.User
+		event.Provider = providerID
+		event.Origin = origin
+		event.SessionID = cookie.ID
+		event.Reason = "handoff"
+		auditLogger.Log(ctx, event)
+
+		glog.V(2).Infof("set X-Gateway-User to %s by handoff", login.User)
+		http.SetCookie(resp, cookie.HTTPCookie(req))
+		http.SetCookie(resp, cookie.LoginHintHTTPCookie(req))
+		http.SetCookie(resp, &http.Cookie{Name: HandoffNonceCookieName, Path: HandoffExchangePath, MaxAge: -1})
+		http.Redirect(resp, req, origin, http.StatusTemporaryRedirect)
+		return nil
+	})
+}
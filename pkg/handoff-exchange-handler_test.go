@@ -0,0 +1,134 @@
+package pkg_test
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("HandoffExchangeHandler", func() {
+	var ctx context.Context
+	var handoffGenerator pkg.HandoffGenerator
+	var replayStore *mocks.ReplayStore
+	var cookieGenerator *mocks.CookieGenerator
+	var sessionStore *mocks.SessionStore
+	var metrics *mocks.Metrics
+	var auditLogger *mocks.AuditLogger
+	var recorder *httptest.ResponseRecorder
+	var host string
+	var nonce string
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		now := time.Now()
+		handoffGenerator = pkg.NewHandoffGenerator([]byte("test-key"))
+		replayStore = &mocks.ReplayStore{}
+		replayStore.UseReturns(true, nil)
+		cookieGenerator = &mocks.CookieGenerator{}
+		cookieGenerator.GenerateReturns(pkg.Cookie{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "session-id",
+				Subject:   "jdoe@example.com",
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		}, nil)
+		sessionStore = &mocks.SessionStore{}
+		metrics = &mocks.Metrics{}
+		auditLogger = &mocks.AuditLogger{}
+		recorder = httptest.NewRecorder()
+		host = "app.example.org"
+		nonce = "nonce"
+	})
+	JustBeforeEach(func() {
+		code, generateErr := handoffGenerator.Generate(ctx, pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGoogle}, pkg.Session{Subject: "1234", ProviderSessionID: "sid1"}, "app.example.org", "https://app.example.org/foo", "nonce")
+		Expect(generateErr).To(BeNil())
+		req := httptest.NewRequest(http.MethodGet, "https://"+host+pkg.HandoffExchangePath+"?code="+code.String(), nil)
+		if nonce != "" {
+			req.AddCookie(&http.Cookie{Name: pkg.HandoffNonceCookieName, Value: nonce})
+		}
+		templates, templatesErr := pkg.NewTemplates(ctx, "", pkg.Branding{})
+		Expect(templatesErr).To(BeNil())
+		handler := pkg.NewHandoffExchangeHandler(handoffGenerator, replayStore, cookieGenerator, sessionStore, metrics, auditLogger, templates)
+		err = handler.ServeHTTP(ctx, recorder, req)
+	})
+	It("sets cookie and redirects to origin", func() {
+		Expect(err).To(BeNil())
+		Expect(recorder.Code).To(Equal(http.StatusTemporaryRedirect))
+		Expect(recorder.Header().Get("Location")).To(Equal("https://app.example.org/foo"))
+		Expect(recorder.Result().Cookies()).To(ContainElement(HaveField("Name", pkg.LoginCookieName)))
+		_, login := cookieGenerator.GenerateArgsForCall(0)
+		Expect(login.User).To(Equal("jdoe@example.com"))
+		Expect(login.Provider).To(Equal(pkg.ProviderGoogle))
+	})
+	It("saves session", func() {
+		_, session := sessionStore.SaveSessionArgsForCall(0)
+		Expect(session.ID).To(Equal("session-id"))
+		Expect(session.User).To(Equal("jdoe@example.com"))
+		Expect(session.Subject).To(Equal("1234"))
+		Expect(session.ProviderSessionID).To(Equal("sid1"))
+	})
+	It("audits login", func() {
+		_, event := auditLogger.LogArgsForCall(0)
+		Expect(event.Type).To(Equal(pkg.AuditEventLoginSucceeded))
+		Expect(event.Reason).To(Equal("handoff"))
+	})
+	Context("code of other host", func() {
+		BeforeEach(func() {
+			host = "evil.example.net"
+		})
+		It("renders error page", func() {
+			Expect(err).To(BeNil())
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(replayStore.UseCallCount()).To(Equal(0))
+			Expect(cookieGenerator.GenerateCallCount()).To(Equal(0))
+		})
+	})
+	Context("browser without nonce", func() {
+		BeforeEach(func() {
+			nonce = ""
+		})
+		It("renders error page", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(replayStore.UseCallCount()).To(Equal(0))
+			Expect(cookieGenerator.GenerateCallCount()).To(Equal(0))
+		})
+	})
+	Context("browser with other nonce", func() {
+		BeforeEach(func() {
+			nonce = "attacker"
+		})
+		It("renders error page", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(cookieGenerator.GenerateCallCount()).To(Equal(0))
+		})
+	})
+	Context("code used before", func() {
+		BeforeEach(func() {
+			replayStore.UseReturns(false, nil)
+		})
+		It("renders error page", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(cookieGenerator.GenerateCallCount()).To(Equal(0))
+			_, reason := metrics.CallbackFailureArgsForCall(0)
+			Expect(reason).To(Equal(pkg.CallbackFailureReasonInvalidState))
+		})
+	})
+	Context("replay store failed", func() {
+		BeforeEach(func() {
+			replayStore.UseReturns(false, stderrors.New("banana"))
+		})
+		It("renders error page", func() {
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,211 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// HandoffConfig hands logins of a central auth host over to app hosts on other domains
+type HandoffConfig struct {
+	// AuthorizeURL of the central auth host, e.g. https://auth.example.com/sso/authorize.
+	// Users without login are sent there instead of to the provider, empty disables.
+	AuthorizeURL string `yaml:"authorize_url"`
+	// Hosts the central auth host issues handoff codes for, empty disables
+	Hosts CallbackHosts `yaml:"hosts"`
+}
+
+// Validate the handoff config
+func (h HandoffConfig) Validate(ctx context.Context) error {
+	if h.AuthorizeURL != "" {
+		if err := validateAbsoluteURL(ctx, h.AuthorizeURL); err != nil {
+			return errors.Wrapf(ctx, err, "authorize_url invalid")
+		}
+	}
+	if err := h.Hosts.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "hosts invalid")
+	}
+	return nil
+}
+
+// handoffCodeTTL limits how long a handoff code can be exchanged
+const handoffCodeTTL = time.Minute
+
+// HandoffNonceCookieName binds a handoff to the browser that started it on the app host
+const HandoffNonceCookieName = "X-Gateway-Handoff-Nonce"
+
+// handoffNonceMaxAge leaves time for the login at the provider
+const handoffNonceMaxAge = 10 * time.Minute
+
+// NewHandoffNonceCookie returns a cookie with a random nonce for the host of req. The nonce is passed to
+// the central auth host and back in the code, the exchange only accepts codes matching the cookie.
+// This prevents logging a victim in with a code of another account.
+func NewHandoffNonceCookie(req *http.Request) (*http.Cookie, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	return &http.Cookie{
+		Name:     HandoffNonceCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(buf),
+		Path:     HandoffExchangePath,
+		Secure:   IsHTTPS(req),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(handoffNonceMaxAge.Seconds()),
+	}, nil
+}
+
+// HandoffNonceMatches returns true if req carries the nonce cookie of the code
+func HandoffNonceMatches(req *http.Request, code HandoffCode) bool {
+	cookie, err := req.Cookie(HandoffNonceCookieName)
+	if err != nil || cookie.Value == "" || code.Nonce == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(code.Nonce)) == 1
+}
+
+// HandoffCode passes a login of the central auth host to an app host on another domain.
+// It is bound to the host in the audience and may only be exchanged once, see ReplayStore.
+type HandoffCode struct {
+	// Type is always TokenTypeHandoff
+	Type TokenType `json:"typ"`
+	// Origin the user is returned to after the exchange
+	Origin string `json:"origin"`
+	// Nonce of the browser on the app host, see NewHandoffNonceCookie
+	Nonce string `json:"nonce"`
+	// ProviderSubject and ProviderSessionID of the ID token, they let back-channel logout find the session on the app host
+	ProviderSubject   string           `json:"provider_sub,omitempty"`
+	ProviderSessionID string           `json:"provider_sid,omitempty"`
+	Provider          string           `json:"provider,omitempty"`
+	Groups            []string         `json:"groups,omitempty"`
+	AuthTime          *jwt.NumericDate `json:"auth_time,omitempty"`
+	ACR               string           `json:"acr,omitempty"`
+	AMR               []string         `json:"amr,omitempty"`
+	jwt.RegisteredClaims
+
+	token string
+}
+
+func (h HandoffCode) String() string {
+	return h.token
+}
+
+// Login of the user on the central auth host
+func (h HandoffCode) Login() Login {
+	login := Login{
+		User:     h.Subject,
+		Provider: h.Provider,
+		Groups:   h.Groups,
+		ACR:      h.ACR,
+		AMR:      h.AMR,
+	}
+	if h.AuthTime != nil {
+		login.AuthTime = h.AuthTime.Time
+	}
+	return login
+}
+
+// HandoffGenerator generates and decodes one-time codes handing a login over to another host
+//
+//counterfeiter:generate -o ../mocks/handoff-generator.go --fake-name HandoffGenerator . HandoffGenerator
+type HandoffGenerator interface {
+	// Generate a code for identity and its session that can only be exchanged on host by the browser holding nonce
+	Generate(ctx context.Context, identity Identity, session Session, host string, origin string, nonce string) (HandoffCode, error)
+	// Decode the code and validate it was issued for host
+	Decode(ctx context.Context, code string, host string) (HandoffCode, error)
+}
+
+// NewHandoffGenerator using key to sign handoff codes, the central auth host and the app hosts must share it
+func NewHandoffGenerator(key []byte) HandoffGenerator {
+	return &handoffGenerator{key: key}
+}
+
+type handoffGenerator struct {
+	key []byte
+}
+
+// Generate a signed handoff code
+func (h *handoffGenerator) Generate(ctx context.Context, identity Identity, session Session, host string, origin string, nonce string) (HandoffCode, error) {
+	issuedAt := time.Now().UTC()
+	generateUUID, err := uuid.NewUUID()
+	if err != nil {
+		return HandoffCode{}, err
+	}
+
+	code := HandoffCode{
+		Type:              TokenTypeHandoff,
+		Origin:            origin,
+		Nonce:             nonce,
+		ProviderSubject:   session.Subject,
+		ProviderSessionID: session.ProviderSessionID,
+		Provider:          identity.Provider,
+		Groups:            identity.Groups,
+		ACR:               identity.ACR,
+		AMR:               identity.AMR,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        generateUUID.String(),
+			Subject:   identity.User,
+			Audience:  jwt.ClaimStrings{host},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(handoffCodeTTL)),
+		},
+	}
+	if !identity.AuthTime.IsZero() {
+		code.AuthTime = jwt.NewNumericDate(identity.AuthTime)
+	}
+
+	return h.sign(code)
+}
+
+func (h *handoffGenerator) sign(code HandoffCode) (HandoffCode, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, code)
+	signed, err := token.SignedString(h.key)
+	if err != nil {
+		return HandoffCode{}, err
+	}
+
+	code.token = signed
+	return code, nil
+}
+
+// Decode a handoff code and validate it
+func (h *handoffGenerator) Decode(ctx context.Context, code string, host string) (HandoffCode, error) {
+	token, err := jwt.ParseWithClaims(code, &HandoffCode{}, h.keyFunc, jwt.WithAudience(host), jwt.WithExpirationRequired())
+	if err != nil {
+		return HandoffCode{}, err
+	}
+
+	if claims, ok := token.Claims.(*HandoffCode); ok && token.Valid {
+		if claims.Type != TokenTypeHandoff {
+			return HandoffCode{}, fmt.Errorf("%w: %s", ErrWrongTokenType, claims.Type)
+		}
+		if len(claims.Subject) < 1 {
+			return HandoffCode{}, ErrSubjectMissing
+		}
+		if len(claims.ID) < 1 {
+			return HandoffCode{}, stderrors.New("id missing")
+		}
+		return *claims, nil
+	}
+
+	return HandoffCode{}, stderrors.New("token invalid")
+}
+
+func (h *handoffGenerator) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnexpectedSigningMethod, token.Header["alg"])
+	}
+
+	return h.key, nil
+}
@@ -0,0 +1,88 @@
+package pkg_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("HandoffGenerator", func() {
+	var handoffGenerator = pkg.NewHandoffGenerator([]byte("test-key"))
+	var ctx context.Context
+	var identity pkg.Identity
+	BeforeEach(func() {
+		ctx = context.Background()
+		identity = pkg.Identity{
+			User:      "jdoe@example.com",
+			Provider:  pkg.ProviderGoogle,
+			SessionID: "session-id",
+			Groups:    []string{"admins@example.com"},
+			AuthTime:  time.Now().Add(-time.Hour).Truncate(time.Second),
+			ACR:       "urn:mfa",
+		}
+	})
+	It("passes login to host", func() {
+		code, err := handoffGenerator.Generate(ctx, identity, pkg.Session{Subject: "1234", ProviderSessionID: "sid1"}, "app.example.org", "https://app.example.org/foo", "nonce")
+		Expect(err).To(BeNil())
+		code, err = handoffGenerator.Decode(ctx, code.String(), "app.example.org")
+		Expect(err).To(BeNil())
+		Expect(code.ID).NotTo(BeEmpty())
+		Expect(code.Origin).To(Equal("https://app.example.org/foo"))
+		Expect(code.ProviderSubject).To(Equal("1234"))
+		Expect(code.ProviderSessionID).To(Equal("sid1"))
+		Expect(code.ExpiresAt.Time).To(BeTemporally("<=", time.Now().Add(time.Minute)))
+		login := code.Login()
+		Expect(login.User).To(Equal("jdoe@example.com"))
+		Expect(login.Provider).To(Equal(pkg.ProviderGoogle))
+		Expect(login.Groups).To(ConsistOf("admins@example.com"))
+		Expect(login.AuthTime).To(BeTemporally("==", identity.AuthTime))
+		Expect(login.ACR).To(Equal("urn:mfa"))
+	})
+	It("rejects code of other host", func() {
+		code, err := handoffGenerator.Generate(ctx, identity, pkg.Session{}, "app.example.org", "https://app.example.org/foo", "nonce")
+		Expect(err).To(BeNil())
+		_, err = handoffGenerator.Decode(ctx, code.String(), "evil.example.net")
+		Expect(err).NotTo(BeNil())
+	})
+	It("can not be used as session cookie", func() {
+		code, err := handoffGenerator.Generate(ctx, identity, pkg.Session{}, "app.example.org", "https://app.example.org/foo", "nonce")
+		Expect(err).To(BeNil())
+		_, err = pkg.NewCookieGenerator([]byte("test-key"), pkg.CookieOptions{}).Decode(ctx, code.String())
+		Expect(err).To(MatchError(pkg.ErrWrongTokenType))
+		_, err = pkg.NewSessionTokenVerifier(pkg.NewCookieGenerator([]byte("test-key"), pkg.CookieOptions{}), pkg.NewMemorySessionStore(), nil).Verify(ctx, code.String())
+		Expect(err).NotTo(BeNil())
+	})
+	It("rejects session cookie as code", func() {
+		cookie, err := pkg.NewCookieGenerator([]byte("test-key"), pkg.CookieOptions{}).Generate(ctx, pkg.Login{User: "jdoe@example.com"})
+		Expect(err).To(BeNil())
+		_, err = handoffGenerator.Decode(ctx, cookie.String(), "app.example.org")
+		Expect(err).NotTo(BeNil())
+	})
+	It("rejects code of other key", func() {
+		code, err := pkg.NewHandoffGenerator([]byte("other-key")).Generate(ctx, identity, pkg.Session{}, "app.example.org", "https://app.example.org/foo", "nonce")
+		Expect(err).To(BeNil())
+		_, err = handoffGenerator.Decode(ctx, code.String(), "app.example.org")
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("MemoryReplayStore", func() {
+	It("accepts each code once", func() {
+		ctx := context.Background()
+		replayStore := pkg.NewMemoryReplayStore()
+		expiresAt := time.Now().Add(time.Minute)
+		unused, err := replayStore.Use(ctx, "a", expiresAt)
+		Expect(err).To(BeNil())
+		Expect(unused).To(BeTrue())
+		unused, err = replayStore.Use(ctx, "a", expiresAt)
+		Expect(err).To(BeNil())
+		Expect(unused).To(BeFalse())
+		unused, err = replayStore.Use(ctx, "b", expiresAt)
+		Expect(err).To(BeNil())
+		Expect(unused).To(BeTrue())
+	})
+})
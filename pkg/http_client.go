@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+)
+
+// HTTPClientOptions configures the client used for calls to the OAuth provider
+type HTTPClientOptions struct {
+	// Timeout of a single request including retries
+	Timeout time.Duration
+	// ProxyURL overrides the proxy from the HTTP_PROXY/HTTPS_PROXY env variables
+	ProxyURL string
+	// CABundle is a PEM file with additional root certificates
+	CABundle string
+	// MaxRetries of idempotent requests failed with a network error or 5xx/429 status
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on each further retry
+	RetryBackoff time.Duration
+}
+
+// NewHTTPClient returns a client with timeout, proxy, custom CA and retries configured
+func NewHTTPClient(ctx context.Context, options HTTPClientOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.ProxyURL != "" {
+		proxyURL, err := url.Parse(options.ProxyURL)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "parse proxy url failed")
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if options.CABundle != "" {
+		content, err := os.ReadFile(options.CABundle)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "read ca bundle %s failed", options.CABundle)
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(content) {
+			return nil, errors.Errorf(ctx, "no certificates found in ca bundle %s", options.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    rootCAs,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	return &http.Client{
+		Timeout:   options.Timeout,
+		Transport: NewRetryRoundTripper(transport, options.MaxRetries, options.RetryBackoff),
+	}, nil
+}
+
+// NewRetryRoundTripper retries idempotent requests without body
+// if they failed with a network error or a 5xx/429 status.
+func NewRetryRoundTripper(next http.RoundTripper, maxRetries int, backoff time.Duration) http.RoundTripper {
+	return &retryRoundTripper{
+		next:       next,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+	}
+}
+
+type retryRoundTripper struct {
+	next       http.RoundTripper
+	maxRetries int
+	backoff    time.Duration
+}
+
+func (r *retryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isRetryable(req) {
+		return r.next.RoundTrip(req)
+	}
+	delay := r.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := r.next.RoundTrip(req)
+		if attempt >= r.maxRetries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		if err != nil {
+			glog.V(2).Infof("%s %s failed, retry in %v: %v", req.Method, req.URL.Redacted(), delay, err)
+		} else {
+			glog.V(2).Infof("%s %s failed with status %d, retry in %v", req.Method, req.URL.Redacted(), resp.StatusCode, delay)
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	default:
+		return false
+	}
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
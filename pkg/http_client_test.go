@@ -0,0 +1,66 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("HTTPClient", func() {
+	var server *httptest.Server
+	var calls atomic.Int32
+	var failures int32
+	var httpClient *http.Client
+	BeforeEach(func() {
+		calls.Store(0)
+		failures = 2
+		server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			if calls.Add(1) <= failures {
+				resp.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			resp.WriteHeader(http.StatusOK)
+		}))
+		var err error
+		httpClient, err = pkg.NewHTTPClient(context.Background(), pkg.HTTPClientOptions{
+			Timeout:      5 * time.Second,
+			MaxRetries:   2,
+			RetryBackoff: time.Millisecond,
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	It("retries get", func() {
+		resp, err := httpClient.Get(server.URL)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(calls.Load()).To(Equal(int32(3)))
+	})
+	It("gives up after max retries", func() {
+		failures = 5
+		resp, err := httpClient.Get(server.URL)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(calls.Load()).To(Equal(int32(3)))
+	})
+	It("does not retry post", func() {
+		resp, err := httpClient.Post(server.URL, "text/plain", strings.NewReader("code"))
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(calls.Load()).To(Equal(int32(1)))
+	})
+	It("rejects missing ca bundle", func() {
+		_, err := pkg.NewHTTPClient(context.Background(), pkg.HTTPClientOptions{CABundle: "/does/not/exist.pem"})
+		Expect(err).NotTo(BeNil())
+	})
+})
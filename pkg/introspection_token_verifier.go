@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/bborbe/errors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// NewIntrospectionTokenVerifier accepts provider issued access tokens
+// the OAuth 2.0 token introspection endpoint (RFC 7662) reports as active.
+// The token must be issued to clientID and carry a verified email,
+// if hostedDomain is set the hd of the token must equal it.
+func NewIntrospectionTokenVerifier(
+	ctx context.Context,
+	httpClient *http.Client,
+	introspectionURL string,
+	clientID string,
+	clientSecret string,
+	hostedDomain string,
+) (TokenVerifier, error) {
+	if clientID == "" {
+		return nil, errors.Errorf(ctx, "client id missing")
+	}
+	return &introspectionTokenVerifier{
+		httpClient:       httpClient,
+		introspectionURL: introspectionURL,
+		clientID:         clientID,
+		clientSecret:     clientSecret,
+		hostedDomain:     hostedDomain,
+	}, nil
+}
+
+type introspectionTokenVerifier struct {
+	httpClient       *http.Client
+	introspectionURL string
+	clientID         string
+	clientSecret     string
+	hostedDomain     string
+}
+
+type introspectionResponse struct {
+	Active        bool             `json:"active"`
+	ClientID      string           `json:"client_id"`
+	Audience      jwt.ClaimStrings `json:"aud"`
+	Email         string           `json:"email"`
+	EmailVerified bool             `json:"email_verified"`
+	HD            string           `json:"hd"`
+}
+
+func (i *introspectionTokenVerifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	form := url.Values{}
+	form.Set("token", token)
+	form.Set("token_type_hint", "access_token")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.introspectionURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "build request failed")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(i.clientID, i.clientSecret)
+
+	resp, err := i.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "introspect token failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf(ctx, "introspect token failed with status %d", resp.StatusCode)
+	}
+	var data introspectionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, errors.Wrapf(ctx, err, "decode introspection response failed")
+	}
+	if !data.Active {
+		return nil, errors.Errorf(ctx, "token is not active")
+	}
+	// the authorization server also reports tokens of its other clients as active
+	if data.ClientID != i.clientID && !slices.Contains(data.Audience, i.clientID) {
+		return nil, errors.Wrapf(ctx, jwt.ErrTokenInvalidAudience, "token issued to client '%s'", data.ClientID)
+	}
+	if data.Email == "" || !data.EmailVerified {
+		return nil, errors.Errorf(ctx, "token has no verified email")
+	}
+	if !inHostedDomain(data.HD, i.hostedDomain) {
+		return nil, errors.Errorf(ctx, "hosted domain '%s' not allowed", data.HD)
+	}
+	return &Identity{
+		User: data.Email,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package pkg_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("IntrospectionTokenVerifier", func() {
+	var ctx context.Context
+	var server *httptest.Server
+	var response map[string]interface{}
+	var identity *pkg.Identity
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		response = map[string]interface{}{
+			"active":         true,
+			"client_id":      "client-id",
+			"email":          "jdoe@example.com",
+			"email_verified": true,
+			"hd":             "example.com",
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			user, password, ok := req.BasicAuth()
+			if !ok || user != "client-id" || password != "secret" || req.FormValue("token") != "token" {
+				resp.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(resp).Encode(response)
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	JustBeforeEach(func() {
+		verifier, verifierErr := pkg.NewIntrospectionTokenVerifier(ctx, http.DefaultClient, server.URL, "client-id", "secret", "example.com")
+		Expect(verifierErr).To(BeNil())
+		identity, err = verifier.Verify(ctx, "token")
+	})
+	It("accepts active token", func() {
+		Expect(err).To(BeNil())
+		Expect(identity.User).To(Equal("jdoe@example.com"))
+	})
+	Context("issued for client in audience", func() {
+		BeforeEach(func() {
+			response["client_id"] = "other-client"
+			response["aud"] = []string{"client-id"}
+		})
+		It("accepts token", func() {
+			Expect(err).To(BeNil())
+		})
+	})
+	DescribeTable("rejects",
+		func(key string, value interface{}) {
+			response[key] = value
+			verifier, verifierErr := pkg.NewIntrospectionTokenVerifier(ctx, http.DefaultClient, server.URL, "client-id", "secret", "example.com")
+			Expect(verifierErr).To(BeNil())
+			_, err := verifier.Verify(ctx, "token")
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("inactive token", "active", false),
+		Entry("token of other client", "client_id", "other-client"),
+		Entry("unverified email", "email_verified", false),
+		Entry("missing email", "email", ""),
+		Entry("other hosted domain", "hd", "other.com"),
+		Entry("consumer account", "hd", ""),
+	)
+	It("requires client id", func() {
+		_, err := pkg.NewIntrospectionTokenVerifier(ctx, http.DefaultClient, server.URL, "", "secret", "example.com")
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,225 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/glog"
+)
+
+// GoogleJWKSURL contains the keys Google signs its ID tokens with
+const GoogleJWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+// GoogleIssuers are the issuers used by Google in ID tokens
+var GoogleIssuers = []string{"https://accounts.google.com", "accounts.google.com"}
+
+// IDTokenClaims contained in an OpenID Connect ID token
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	HD            string `json:"hd,omitempty"`
+	// AuthTime, ACR and AMR describe the login, see StepUp
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	ACR      string           `json:"acr,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+	// SID of the login at the provider, see BackChannelLogoutHandler
+	SID string `json:"sid,omitempty"`
+}
+
+// NewJWKSTokenVerifier accepts provider issued ID tokens signed by a key of the given JWKS.
+// The token must be issued by one of issuers for the audience (client id).
+// If hostedDomain is set the token must belong to it. An empty audience is rejected,
+// it would accept tokens issued to any client.
+func NewJWKSTokenVerifier(
+	ctx context.Context,
+	keySet JWKS,
+	issuers []string,
+	audience string,
+	hostedDomain string,
+) (TokenVerifier, error) {
+	if audience == "" {
+		return nil, errors.Errorf(ctx, "audience missing")
+	}
+	return &jwksTokenVerifier{
+		keySet:       keySet,
+		issuers:      issuers,
+		audience:     audience,
+		hostedDomain: hostedDomain,
+	}, nil
+}
+
+type jwksTokenVerifier struct {
+	keySet       JWKS
+	issuers      []string
+	audience     string
+	hostedDomain string
+}
+
+func (j *jwksTokenVerifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	var claims IDTokenClaims
+	_, err := jwt.ParseWithClaims(
+		token,
+		&claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return j.keySet.Key(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithAudience(j.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse id token failed")
+	}
+	if !slices.Contains(j.issuers, claims.Issuer) {
+		return nil, errors.Wrapf(ctx, jwt.ErrTokenInvalidIssuer, "issuer '%s' not allowed", claims.Issuer)
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errors.Errorf(ctx, "id token has no verified email")
+	}
+	if !inHostedDomain(claims.HD, j.hostedDomain) {
+		return nil, errors.Errorf(ctx, "hosted domain '%s' not allowed", claims.HD)
+	}
+	return &Identity{
+		User: claims.Email,
+	}, nil
+}
+
+// JWKS provides public keys of a JSON Web Key Set by key id
+type JWKS interface {
+	Key(ctx context.Context, kid string) (*rsa.PublicKey, error)
+	// Check fetches the keys if none are cached yet
+	Check(ctx context.Context) error
+}
+
+// NewJWKS returns a JWKS that fetches the keys from url and caches them.
+// Keys are refetched after an hour or if an unknown key id is requested.
+func NewJWKS(httpClient *http.Client, url string) JWKS {
+	return &jwks{
+		httpClient: httpClient,
+		url:        url,
+	}
+}
+
+type jwks struct {
+	httpClient *http.Client
+	url        string
+
+	mux       sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+const (
+	jwksMaxAge           = time.Hour
+	jwksMinFetchInterval = time.Minute
+)
+
+func (j *jwks) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	age := time.Since(j.fetchedAt)
+	key, ok := j.keys[kid]
+	if ok && age < jwksMaxAge {
+		return key, nil
+	}
+	if j.keys == nil || age >= jwksMinFetchInterval {
+		if err := j.fetch(ctx); err != nil {
+			if ok {
+				glog.Warningf("refresh jwks failed, use cached key: %v", err)
+				return key, nil
+			}
+			return nil, errors.Wrapf(ctx, err, "fetch jwks failed")
+		}
+		if key, ok = j.keys[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, errors.Errorf(ctx, "key '%s' not found in jwks", kid)
+}
+
+func (j *jwks) Check(ctx context.Context) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	if len(j.keys) > 0 && time.Since(j.fetchedAt) < jwksMaxAge {
+		return nil
+	}
+	if err := j.fetch(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "fetch jwks failed")
+	}
+	if len(j.keys) == 0 {
+		return errors.Errorf(ctx, "jwks %s contains no rsa keys", j.url)
+	}
+	return nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (j *jwks) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "build request failed")
+	}
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "get %s failed", j.url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf(ctx, "get %s failed with status %d", j.url, resp.StatusCode)
+	}
+	var data struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return errors.Wrapf(ctx, err, "decode jwks failed")
+	}
+	keys := make(map[string]*rsa.PublicKey, len(data.Keys))
+	for _, key := range data.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		publicKey, err := key.rsaPublicKey()
+		if err != nil {
+			glog.Warningf("skip invalid jwk %s: %v", key.Kid, err)
+			continue
+		}
+		keys[key.Kid] = publicKey
+	}
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	glog.V(2).Infof("fetched %d keys from %s", len(keys), j.url)
+	return nil
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -0,0 +1,124 @@
+package pkg_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("JWKSTokenVerifier", func() {
+	var ctx context.Context
+	var privateKey *rsa.PrivateKey
+	var server *httptest.Server
+	var verifier pkg.TokenVerifier
+	var claims pkg.IDTokenClaims
+	var identity *pkg.Identity
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			_ = json.NewEncoder(resp).Encode(map[string]interface{}{
+				"keys": []map[string]string{{
+					"kty": "RSA",
+					"kid": "key1",
+					"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+				}},
+			})
+		}))
+		var verifierErr error
+		verifier, verifierErr = pkg.NewJWKSTokenVerifier(ctx, pkg.NewJWKS(http.DefaultClient, server.URL), pkg.GoogleIssuers, "client-id", "example.com")
+		Expect(verifierErr).To(BeNil())
+		claims = pkg.IDTokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "https://accounts.google.com",
+				Subject:   "1234",
+				Audience:  jwt.ClaimStrings{"client-id"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+			Email:         "jdoe@example.com",
+			EmailVerified: true,
+			HD:            "example.com",
+		}
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	JustBeforeEach(func() {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key1"
+		signed, signErr := token.SignedString(privateKey)
+		Expect(signErr).To(BeNil())
+		identity, err = verifier.Verify(ctx, signed)
+	})
+	It("accepts valid token", func() {
+		Expect(err).To(BeNil())
+		Expect(identity.User).To(Equal("jdoe@example.com"))
+	})
+	Context("wrong audience", func() {
+		BeforeEach(func() {
+			claims.Audience = jwt.ClaimStrings{"other"}
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("wrong issuer", func() {
+		BeforeEach(func() {
+			claims.Issuer = "https://evil.example.com"
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("expired", func() {
+		BeforeEach(func() {
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		})
+		It("returns error", func() {
+			Expect(pkg.TokenErrorReasonOf(err)).To(Equal(pkg.TokenErrorReasonExpired))
+		})
+	})
+	Context("unverified email", func() {
+		BeforeEach(func() {
+			claims.EmailVerified = false
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("consumer account with company email", func() {
+		BeforeEach(func() {
+			claims.HD = ""
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("other hosted domain", func() {
+		BeforeEach(func() {
+			claims.HD = "other.com"
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	It("rejects empty audience", func() {
+		_, err := pkg.NewJWKSTokenVerifier(ctx, pkg.NewJWKS(http.DefaultClient, server.URL), pkg.GoogleIssuers, "", "example.com")
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -2,44 +2,168 @@ package pkg
 
 import (
 	"context"
+	stderrors "errors"
 	"net/http"
+	"time"
 
 	"github.com/bborbe/errors"
 	libhttp "github.com/bborbe/http"
 	"github.com/golang/glog"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// NewLoginCallbackHandler completes the login with the provider stored in the state
 func NewLoginCallbackHandler(
 	cookieGenerator CookieGenerator,
 	stateGenerator StateGenerator,
-	googleOAuth GoogleOAuth,
+	providers Providers,
+	groupResolver GroupResolver,
+	sessionStore SessionStore,
+	metrics Metrics,
+	auditLogger AuditLogger,
+	templates Templates,
+	callbackHosts CallbackHosts,
 ) libhttp.WithError {
 	return libhttp.WithErrorFunc(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
-		if err := req.ParseForm(); err != nil {
+		ctx, span := startSpan(extractTraceContext(ctx, req), "login-callback")
+		outcome := "success"
+		var err error
+		defer func() {
+			endSpan(span, outcome, err)
+		}()
+
+		if err = req.ParseForm(); err != nil {
+			outcome = "invalid_request"
 			return errors.Wrapf(ctx, err, "parse form failed")
 		}
-		state, err := stateGenerator.Decode(ctx, req.Form.Get("state"))
-		if err != nil {
-			return errors.Wrapf(ctx, err, "invalid oauth state")
+		providerID := ProviderUnknown
+		fail := func(reason CallbackFailureReason, origin string, detail string, cause error) error {
+			outcome = string(reason)
+			err = cause
+			metrics.CallbackFailure(providerID, reason)
+			event := NewAuditEvent(req, AuditEventLoginDenied)
+			event.Provider = providerID
+			event.Origin = origin
+			event.Reason = string(reason)
+			auditLogger.Log(ctx, event)
+			return WriteLoginErrorPage(ctx, resp, templates, reason, origin, detail, cause)
+		}
+		var origin string
+		state, stateErr := stateGenerator.Decode(ctx, req.Form.Get("state"))
+		if stateErr == nil {
+			origin = state.Origin
+		}
+		provider, ok := providers.Find(state.Provider)
+		if stateErr == nil && ok {
+			providerID = provider.ID()
+			span.SetAttributes(attribute.String("provider", providerID))
+		}
+		if providerError := req.Form.Get("error"); providerError != "" {
+			// the description is chosen by whoever crafted the redirect, it is only logged
+			return fail(
+				providerErrorReasonOf(providerError),
+				origin,
+				providerErrorDetails[providerError],
+				errors.Errorf(ctx, "provider returned error %q: %q", providerError, req.Form.Get("error_description")),
+			)
 		}
-		info, err := googleOAuth.UserInfo(ctx, Code(req.Form.Get("code")))
+		if stateErr != nil {
+			return fail(CallbackFailureReasonInvalidState, "", "", errors.Wrapf(ctx, stateErr, "invalid oauth state"))
+		}
+		if !ok {
+			return fail(CallbackFailureReasonInvalidState, origin, "", errors.Errorf(ctx, "unknown provider '%s' in state", state.Provider))
+		}
+		// the provider redirected to the callback url of the login, derive it the same way
+		info, err := provider.UserInfo(ctx, Code(req.Form.Get("code")), callbackHosts.RedirectURL(req, provider))
 		if err != nil {
-			return errors.Wrapf(ctx, err, "get user info failed")
+			return fail(callbackFailureReasonOf(err), origin, "", errors.Wrapf(ctx, err, "get user info failed"))
 		}
 		user := info.Email
-		origin := state.Origin
+		if state.StepUp != nil {
+			// a provider not returning auth_time did not confirm a recent login
+			if err := state.StepUp.Check(ctx, time.Now(), info.AuthTime, info.ACR, info.AMR); err != nil {
+				return fail(CallbackFailureReasonStepUpFailed, origin, "", errors.Wrapf(ctx, err, "step-up of %s failed", user))
+			}
+		}
+		groups, err := groupResolver.Groups(ctx, providerID, user)
+		if err != nil {
+			return fail(CallbackFailureReasonUserInfoFailed, origin, "", errors.Wrapf(ctx, err, "get groups failed"))
+		}
 
-		cookie, err := cookieGenerator.Generate(ctx, user)
+		cookie, err := cookieGenerator.Generate(ctx, Login{
+			User:     user,
+			Provider: providerID,
+			Groups:   groups,
+			AuthTime: info.AuthTime,
+			ACR:      info.ACR,
+			AMR:      info.AMR,
+		})
 		if err != nil {
 			glog.V(1).Infof("generate cookie for %s failed", user)
-			return errors.Wrapf(ctx, err, "generating cookie failed")
+			return fail(CallbackFailureReasonInternal, origin, "", errors.Wrapf(ctx, err, "generating cookie failed"))
+		}
+		if err = sessionStore.SaveSession(ctx, Session{
+			ID:        cookie.ID,
+			User:      user,
+			Provider:  providerID,
+			CreatedAt: cookie.IssuedAt.Time,
+			ExpiresAt: cookie.ExpiresAt.Time,
+			Token:     info.Token,
+			// allow back-channel logout to find the session
+			Subject:           info.Subject,
+			ProviderSessionID: info.SessionID,
+			IP:                ClientIP(req),
+			UserAgent:         req.UserAgent(),
+			LastSeen:          cookie.IssuedAt.Time,
+		}); err != nil {
+			return fail(CallbackFailureReasonInternal, origin, "", errors.Wrapf(ctx, err, "save session failed"))
 		}
+		metrics.CallbackSuccess(providerID)
+		event := NewAuditEvent(req, AuditEventLoginSucceeded)
+		event.User = user
+		event.Provider = providerID
+		event.Origin = origin
+		event.SessionID = cookie.ID
+		auditLogger.Log(ctx, event)
 
 		glog.V(2).Infof("set X-Gateway-User to %s", user)
-		http.SetCookie(resp, cookie.HTTPCookie())
+		http.SetCookie(resp, cookie.HTTPCookie(req))
+		http.SetCookie(resp, cookie.LoginHintHTTPCookie(req))
 		glog.V(2).Infof("redirect to %s", origin)
 		http.Redirect(resp, req, origin, http.StatusTemporaryRedirect)
 		return nil
 	})
 
 }
+
+// providerErrorDetails are shown instead of the error_description of the provider redirect (RFC 6749 4.1.2.1)
+var providerErrorDetails = map[string]string{
+	"access_denied":             "The login was canceled or the consent was not given.",
+	"invalid_request":           "The login request was rejected by the identity provider.",
+	"unauthorized_client":       "This site is not allowed to request a login at the identity provider.",
+	"unsupported_response_type": "This site is not allowed to request a login at the identity provider.",
+	"invalid_scope":             "The requested permissions were rejected by the identity provider.",
+	"server_error":              "The identity provider is not working correctly, please try again later.",
+	"temporarily_unavailable":   "The identity provider is temporarily unavailable, please try again later.",
+}
+
+// providerErrorReasonOf maps the error parameter of the provider redirect (RFC 6749 4.1.2.1)
+func providerErrorReasonOf(providerError string) CallbackFailureReason {
+	if providerError == "access_denied" {
+		return CallbackFailureReasonAccessDenied
+	}
+	return CallbackFailureReasonProviderError
+}
+
+func callbackFailureReasonOf(err error) CallbackFailureReason {
+	switch {
+	case stderrors.Is(err, ErrCodeExchangeFailed):
+		return CallbackFailureReasonCodeExchangeFailed
+	case stderrors.Is(err, ErrUserInfoFailed):
+		return CallbackFailureReasonUserInfoFailed
+	case stderrors.Is(err, ErrUserNotAllowed):
+		return CallbackFailureReasonUnauthorized
+	default:
+		return CallbackFailureReasonInternal
+	}
+}
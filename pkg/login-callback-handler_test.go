@@ -0,0 +1,262 @@
+package pkg_test
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("LoginCallbackHandler", func() {
+	var ctx context.Context
+	var cookieGenerator *mocks.CookieGenerator
+	var stateGenerator *mocks.StateGenerator
+	var provider *mocks.Provider
+	var providers pkg.Providers
+	var groupResolver *mocks.GroupResolver
+	var sessionStore *mocks.SessionStore
+	var metrics *mocks.Metrics
+	var auditLogger *mocks.AuditLogger
+	var recorder *httptest.ResponseRecorder
+	var target string
+	var callbackHosts pkg.CallbackHosts
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		now := time.Now()
+		cookieGenerator = &mocks.CookieGenerator{}
+		cookieGenerator.GenerateReturns(pkg.Cookie{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "session-id",
+				Subject:   "jdoe@example.com",
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		}, nil)
+		stateGenerator = &mocks.StateGenerator{}
+		stateGenerator.DecodeReturns(pkg.State{Origin: "/foo"}, nil)
+		provider = &mocks.Provider{}
+		provider.IDReturns(pkg.ProviderGoogle)
+		provider.RedirectURLReturns("https://auth.example.com/callback")
+		provider.UserInfoReturns(&pkg.UserInfo{Email: "jdoe@example.com", Token: &oauth2.Token{AccessToken: "access"}}, nil)
+		providers = pkg.Providers{provider}
+		groupResolver = &mocks.GroupResolver{}
+		groupResolver.GroupsReturns([]string{"admins@example.com"}, nil)
+		sessionStore = &mocks.SessionStore{}
+		metrics = &mocks.Metrics{}
+		auditLogger = &mocks.AuditLogger{}
+		recorder = httptest.NewRecorder()
+		target = "/callback?state=s&code=c"
+		callbackHosts = nil
+	})
+	JustBeforeEach(func() {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		templates, templatesErr := pkg.NewTemplates(ctx, "", pkg.Branding{})
+		Expect(templatesErr).To(BeNil())
+		handler := pkg.NewLoginCallbackHandler(cookieGenerator, stateGenerator, providers, groupResolver, sessionStore, metrics, auditLogger, templates, callbackHosts)
+		err = handler.ServeHTTP(ctx, recorder, req)
+	})
+	It("redirects to origin", func() {
+		Expect(err).To(BeNil())
+		Expect(recorder.Code).To(Equal(http.StatusTemporaryRedirect))
+		Expect(recorder.Header().Get("Location")).To(Equal("/foo"))
+		Expect(metrics.CallbackSuccessCallCount()).To(Equal(1))
+	})
+	It("exchanges code with configured redirect url", func() {
+		_, code, redirectURL := provider.UserInfoArgsForCall(0)
+		Expect(code).To(Equal(pkg.Code("c")))
+		Expect(redirectURL).To(BeEmpty())
+	})
+	Context("callback on allowed host", func() {
+		BeforeEach(func() {
+			callbackHosts = pkg.CallbackHosts{"*.example.com"}
+			target = "https://app1.example.com/callback?state=s&code=c"
+		})
+		It("exchanges code with redirect url of the host", func() {
+			_, _, redirectURL := provider.UserInfoArgsForCall(0)
+			Expect(redirectURL).To(Equal("https://app1.example.com/callback"))
+		})
+	})
+	It("sets login hint cookie", func() {
+		Expect(recorder.Result().Cookies()).To(ContainElement(HaveField("Name", pkg.LoginHintCookieName)))
+	})
+	It("adds groups to cookie", func() {
+		_, provider, user := groupResolver.GroupsArgsForCall(0)
+		Expect(provider).To(Equal(pkg.ProviderGoogle))
+		Expect(user).To(Equal("jdoe@example.com"))
+		_, login := cookieGenerator.GenerateArgsForCall(0)
+		Expect(login.Groups).To(ConsistOf("admins@example.com"))
+	})
+	It("saves session", func() {
+		Expect(sessionStore.SaveSessionCallCount()).To(Equal(1))
+		_, session := sessionStore.SaveSessionArgsForCall(0)
+		Expect(session.ID).To(Equal("session-id"))
+		Expect(session.User).To(Equal("jdoe@example.com"))
+		Expect(session.Provider).To(Equal(pkg.ProviderGoogle))
+		Expect(session.Token.AccessToken).To(Equal("access"))
+	})
+	It("audits login success", func() {
+		Expect(auditLogger.LogCallCount()).To(Equal(1))
+		_, event := auditLogger.LogArgsForCall(0)
+		Expect(event.Type).To(Equal(pkg.AuditEventLoginSucceeded))
+		Expect(event.User).To(Equal("jdoe@example.com"))
+		Expect(event.SessionID).To(Equal("session-id"))
+		Expect(event.Origin).To(Equal("/foo"))
+	})
+	Context("invalid state", func() {
+		BeforeEach(func() {
+			stateGenerator.DecodeReturns(pkg.State{}, stderrors.New("banana"))
+		})
+		It("counts failure", func() {
+			Expect(metrics.CallbackFailureCallCount()).To(Equal(1))
+			_, reason := metrics.CallbackFailureArgsForCall(0)
+			Expect(reason).To(Equal(pkg.CallbackFailureReasonInvalidState))
+		})
+		It("renders error page", func() {
+			Expect(err).To(BeNil())
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("text/html"))
+			Expect(recorder.Header().Get(pkg.CorrelationIDHeaderName)).NotTo(BeEmpty())
+			Expect(recorder.Body.String()).To(ContainSubstring(recorder.Header().Get(pkg.CorrelationIDHeaderName)))
+			Expect(recorder.Body.String()).To(ContainSubstring(`href="/"`))
+		})
+	})
+	Context("access denied by provider", func() {
+		BeforeEach(func() {
+			target = "/callback?state=s&error=access_denied&error_description=%3Cb%3Euser+canceled%3C%2Fb%3E"
+		})
+		It("renders error page with retry link", func() {
+			Expect(err).To(BeNil())
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			Expect(recorder.Body.String()).To(ContainSubstring(`href="/foo"`))
+			Expect(recorder.Body.String()).To(ContainSubstring("The login was canceled or the consent was not given."))
+			Expect(recorder.Body.String()).NotTo(ContainSubstring("user canceled"))
+			Expect(provider.UserInfoCallCount()).To(Equal(0))
+			_, reason := metrics.CallbackFailureArgsForCall(0)
+			Expect(reason).To(Equal(pkg.CallbackFailureReasonAccessDenied))
+		})
+	})
+	Context("unknown provider error", func() {
+		BeforeEach(func() {
+			target = "/callback?state=s&error=evil&error_description=Call+support+at+555-0100"
+		})
+		It("renders error page without description", func() {
+			Expect(err).To(BeNil())
+			Expect(recorder.Code).To(Equal(http.StatusBadGateway))
+			Expect(recorder.Body.String()).NotTo(ContainSubstring("555-0100"))
+			_, reason := metrics.CallbackFailureArgsForCall(0)
+			Expect(reason).To(Equal(pkg.CallbackFailureReasonProviderError))
+		})
+	})
+	Context("state of second provider", func() {
+		var github *mocks.Provider
+		BeforeEach(func() {
+			github = &mocks.Provider{}
+			github.IDReturns(pkg.ProviderGitHub)
+			github.UserInfoReturns(&pkg.UserInfo{Email: "contractor@example.org"}, nil)
+			providers = append(providers, github)
+			stateGenerator.DecodeReturns(pkg.State{Origin: "/foo", Provider: pkg.ProviderGitHub}, nil)
+		})
+		It("logs in with the provider of the state", func() {
+			Expect(err).To(BeNil())
+			Expect(github.UserInfoCallCount()).To(Equal(1))
+			Expect(provider.UserInfoCallCount()).To(Equal(0))
+			_, login := cookieGenerator.GenerateArgsForCall(0)
+			Expect(login.User).To(Equal("contractor@example.org"))
+			Expect(login.Provider).To(Equal(pkg.ProviderGitHub))
+			_, session := sessionStore.SaveSessionArgsForCall(0)
+			Expect(session.Provider).To(Equal(pkg.ProviderGitHub))
+		})
+	})
+	Context("unknown provider in state", func() {
+		BeforeEach(func() {
+			stateGenerator.DecodeReturns(pkg.State{Origin: "/foo", Provider: "banana"}, nil)
+		})
+		It("renders error page", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(provider.UserInfoCallCount()).To(Equal(0))
+		})
+	})
+	Context("code exchange failed", func() {
+		BeforeEach(func() {
+			provider.UserInfoReturns(nil, fmt.Errorf("%w: banana", pkg.ErrCodeExchangeFailed))
+		})
+		It("counts failure", func() {
+			Expect(err).To(BeNil())
+			_, reason := metrics.CallbackFailureArgsForCall(0)
+			Expect(reason).To(Equal(pkg.CallbackFailureReasonCodeExchangeFailed))
+		})
+	})
+	Context("group lookup failed", func() {
+		BeforeEach(func() {
+			groupResolver.GroupsReturns(nil, stderrors.New("banana"))
+		})
+		It("fails login", func() {
+			Expect(err).To(BeNil())
+			Expect(recorder.Code).To(Equal(http.StatusBadGateway))
+			Expect(cookieGenerator.GenerateCallCount()).To(Equal(0))
+			_, reason := metrics.CallbackFailureArgsForCall(0)
+			Expect(reason).To(Equal(pkg.CallbackFailureReasonUserInfoFailed))
+		})
+	})
+	Context("step-up", func() {
+		BeforeEach(func() {
+			stateGenerator.DecodeReturns(pkg.State{Origin: "/foo", StepUp: &pkg.StepUp{MaxAuthAge: 5 * time.Minute}}, nil)
+		})
+		Context("with recent login", func() {
+			BeforeEach(func() {
+				provider.UserInfoReturns(&pkg.UserInfo{Email: "jdoe@example.com", AuthTime: time.Now()}, nil)
+			})
+			It("passes auth time to cookie", func() {
+				Expect(recorder.Code).To(Equal(http.StatusTemporaryRedirect))
+				_, login := cookieGenerator.GenerateArgsForCall(0)
+				Expect(login.AuthTime).NotTo(BeZero())
+			})
+		})
+		Context("without auth time", func() {
+			BeforeEach(func() {
+				provider.UserInfoReturns(&pkg.UserInfo{Email: "jdoe@example.com"}, nil)
+			})
+			It("fails login", func() {
+				Expect(recorder.Code).To(Equal(http.StatusForbidden))
+				Expect(cookieGenerator.GenerateCallCount()).To(Equal(0))
+			})
+		})
+		Context("with old login", func() {
+			BeforeEach(func() {
+				provider.UserInfoReturns(&pkg.UserInfo{Email: "jdoe@example.com", AuthTime: time.Now().Add(-time.Hour)}, nil)
+			})
+			It("fails login", func() {
+				Expect(err).To(BeNil())
+				Expect(recorder.Code).To(Equal(http.StatusForbidden))
+				Expect(cookieGenerator.GenerateCallCount()).To(Equal(0))
+				_, reason := metrics.CallbackFailureArgsForCall(0)
+				Expect(reason).To(Equal(pkg.CallbackFailureReasonStepUpFailed))
+			})
+		})
+	})
+	Context("user not allowed", func() {
+		BeforeEach(func() {
+			provider.UserInfoReturns(nil, fmt.Errorf("%w: banana", pkg.ErrUserNotAllowed))
+		})
+		It("counts failure", func() {
+			Expect(err).To(BeNil())
+			_, reason := metrics.CallbackFailureArgsForCall(0)
+			Expect(reason).To(Equal(pkg.CallbackFailureReasonUnauthorized))
+			Expect(sessionStore.SaveSessionCallCount()).To(Equal(0))
+			_, event := auditLogger.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditEventLoginDenied))
+			Expect(event.Reason).To(Equal("unauthorized"))
+		})
+	})
+})
@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+)
+
+// CorrelationIDHeaderName contains the id of a failed login, it is also logged with the error
+const CorrelationIDHeaderName = "X-Correlation-ID"
+
+// LoginErrorPage is rendered if a login could not be completed
+type LoginErrorPage struct {
+	Message       string
+	Detail        string
+	RetryURL      string
+	CorrelationID string
+}
+
+type loginErrorMessage struct {
+	status  int
+	title   string
+	message string
+}
+
+var loginErrorMessages = map[CallbackFailureReason]loginErrorMessage{
+	CallbackFailureReasonInvalidState: {
+		status:  http.StatusBadRequest,
+		title:   "Login expired",
+		message: "Your login took too long or was started in another window.",
+	},
+	CallbackFailureReasonAccessDenied: {
+		status:  http.StatusForbidden,
+		title:   "Login canceled",
+		message: "Access was not granted at the identity provider.",
+	},
+	CallbackFailureReasonProviderError: {
+		status:  http.StatusBadGateway,
+		title:   "Login failed",
+		message: "The identity provider reported an error.",
+	},
+	CallbackFailureReasonCodeExchangeFailed: {
+		status:  http.StatusBadGateway,
+		title:   "Login failed",
+		message: "The login could not be confirmed with the identity provider.",
+	},
+	CallbackFailureReasonUserInfoFailed: {
+		status:  http.StatusBadGateway,
+		title:   "Login failed",
+		message: "Your account details could not be loaded from the identity provider.",
+	},
+	CallbackFailureReasonStepUpFailed: {
+		status:  http.StatusForbidden,
+		title:   "Verification failed",
+		message: "The identity provider did not confirm a recent or strong enough login.",
+	},
+	CallbackFailureReasonUnauthorized: {
+		status:  http.StatusForbidden,
+		title:   "Access denied",
+		message: "Your account is not allowed to access this site.",
+	},
+}
+
+var loginErrorMessageInternal = loginErrorMessage{
+	status:  http.StatusInternalServerError,
+	title:   "Login failed",
+	message: "An unexpected error occurred.",
+}
+
+// WriteLoginErrorPage logs err with a new correlation id and renders a page explaining the reason.
+// The page links to origin to restart the login.
+func WriteLoginErrorPage(
+	ctx context.Context,
+	resp http.ResponseWriter,
+	templates Templates,
+	reason CallbackFailureReason,
+	origin string,
+	detail string,
+	err error,
+) error {
+	correlationID := uuid.NewString()
+	glog.Warningf("login failed with reason %s (correlation id %s): %v", reason, correlationID, err)
+
+	message, ok := loginErrorMessages[reason]
+	if !ok {
+		message = loginErrorMessageInternal
+	}
+	if origin == "" {
+		origin = "/"
+	}
+	name := TemplateError
+	if message.status == http.StatusForbidden {
+		name = TemplateForbidden
+	}
+	resp.Header().Set(CorrelationIDHeaderName, correlationID)
+	if err := templates.Render(ctx, resp, message.status, name, message.title, LoginErrorPage{
+		Message:       message.message,
+		Detail:        detail,
+		RetryURL:      origin,
+		CorrelationID: correlationID,
+	}); err != nil {
+		return errors.Wrapf(ctx, err, "render login error page failed")
+	}
+	return nil
+}
@@ -2,89 +2,324 @@ package pkg
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/bborbe/errors"
 	libhttp "github.com/bborbe/http"
 	"github.com/golang/glog"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
 	LoginCookieName = "X-Gateway-User"
 	LoginHeaderName = "X-Gateway-User"
+	// ProviderHeaderName contains the provider the user logged in with
+	ProviderHeaderName = "X-Gateway-Provider"
+	// GroupsHeaderName contains the comma separated groups of the user
+	GroupsHeaderName = "X-Gateway-Groups"
+	// LoginHintCookieName contains the user of the previous login
+	LoginHintCookieName = "X-Gateway-Login-Hint"
 )
 
+const loginHintMaxAge = 30 * 24 * time.Hour
+
 type LoginMiddleware interface {
 	Middleware(handler http.Handler) http.Handler
 }
 
 // NewLoginMiddleware for validating request against a jwt secret
 func NewLoginMiddleware(
-	cookieGenerator CookieGenerator,
+	authenticator Authenticator,
 	stateGenerator StateGenerator,
-	googleOAuth GoogleOAuth,
-	callbackPath string,
+	providers Providers,
+	policies Policies,
+	requestClassifier RequestClassifier,
+	rateLimiter RateLimiter,
+	metrics Metrics,
+	auditLogger AuditLogger,
+	publicPaths []string,
+	signInPath string,
+	callbackHosts CallbackHosts,
+	handoffURL string,
 ) LoginMiddleware {
 	return &loginMiddleware{
-		stateGenerator:  stateGenerator,
-		cookieGenerator: cookieGenerator,
-		googleOAuth:     googleOAuth,
-		callbackPath:    callbackPath,
+		authenticator:     authenticator,
+		stateGenerator:    stateGenerator,
+		providers:         providers,
+		policies:          policies,
+		requestClassifier: requestClassifier,
+		rateLimiter:       rateLimiter,
+		metrics:           metrics,
+		auditLogger:       auditLogger,
+		publicPaths:       publicPaths,
+		signInPath:        signInPath,
+		callbackHosts:     callbackHosts,
+		handoffURL:        handoffURL,
 	}
 }
 
 type loginMiddleware struct {
-	cookieGenerator CookieGenerator
-	stateGenerator  StateGenerator
-	googleOAuth     GoogleOAuth
-	callbackPath    string
+	authenticator     Authenticator
+	stateGenerator    StateGenerator
+	providers         Providers
+	policies          Policies
+	requestClassifier RequestClassifier
+	rateLimiter       RateLimiter
+	metrics           Metrics
+	auditLogger       AuditLogger
+	// publicPaths are served without login, e.g. the login callbacks
+	publicPaths []string
+	signInPath  string
+	// callbackHosts the callback url is derived from instead of the configured redirect url
+	callbackHosts CallbackHosts
+	// handoffURL of the central auth host logging in users of this host, empty to login at the provider
+	handoffURL string
 }
 
 func (l *loginMiddleware) Middleware(handler http.Handler) http.Handler {
 	return libhttp.NewErrorHandler(libhttp.WithErrorFunc(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
 		glog.V(2).Infof("login middleware started with url %s", req.URL.String())
-		if err := l.authenticate(ctx, req); err != nil {
+		ctx, span := startSpan(
+			extractTraceContext(ctx, req),
+			"login-middleware",
+			attribute.String("http.method", req.Method),
+			attribute.String("url.path", req.URL.Path),
+		)
+		req = req.WithContext(ctx)
+		identity, err := l.authenticate(ctx, req)
+		if err != nil {
+			// each login creates a state and may end in a code exchange, throttle clients starting too many
+			if retryAfter, ok := l.rateLimiter.Allow(req); !ok {
+				l.metrics.RateLimited("login")
+				WriteTooManyRequests(resp, retryAfter)
+				endSpan(span, "rate_limited", nil)
+				return nil
+			}
+			if !l.requestClassifier.IsNavigational(req) {
+				glog.V(2).Infof("reject non navigational request: %v", err)
+				err = l.unauthorized(ctx, resp, req)
+				endSpan(span, "unauthorized", err)
+				return err
+			}
 			if err := l.login(ctx, resp, req); err != nil {
-				return errors.Wrapf(ctx, err, "redirect to google login failed")
+				endSpan(span, "login_failed", err)
+				return errors.Wrapf(ctx, err, "redirect to login failed")
 			}
+			endSpan(span, "login_redirect", nil)
 			glog.V(2).Infof("login redirect completed")
 			return nil
 		}
 		glog.V(2).Infof("user is authenticated")
+		if identity != nil {
+			if stepUp, ok := l.stepUpRequired(ctx, req, identity); ok {
+				err := l.stepUp(ctx, resp, req, identity, stepUp)
+				endSpan(span, "step_up", err)
+				return err
+			}
+			ctx = WithIdentity(ctx, identity)
+			req = req.WithContext(ctx)
+		}
+		span.SetAttributes(
+			attribute.String("enduser.id", req.Header.Get(LoginHeaderName)),
+			attribute.String("provider", req.Header.Get(ProviderHeaderName)),
+		)
+		injectTraceContext(ctx, req)
 		handler.ServeHTTP(resp, req)
+		endSpan(span, "authenticated", nil)
 		glog.V(2).Infof("login middleware completed")
 		return nil
 	}))
 }
 
-func (l *loginMiddleware) authenticate(ctx context.Context, req *http.Request) error {
-	if req.URL.Path == l.callbackPath {
-		glog.V(2).Info("skip auth for callback")
-		return nil
+// authenticate sets the user headers, the identity is nil for paths not requiring a login
+func (l *loginMiddleware) authenticate(ctx context.Context, req *http.Request) (*Identity, error) {
+	req.Header.Del(LoginHeaderName)
+	req.Header.Del(ProviderHeaderName)
+	req.Header.Del(GroupsHeaderName)
+	if slices.Contains(l.publicPaths, req.URL.Path) || (l.signInPath != "" && req.URL.Path == l.signInPath) {
+		glog.V(2).Infof("skip auth for %s", req.URL.Path)
+		return nil, nil
 	}
 
-	cookie, err := req.Cookie(LoginCookieName)
-	if err != nil || cookie.Value == "" {
-		return errors.Wrap(ctx, err, "invalid auth cookie")
+	identity, err := l.authenticator.Authenticate(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(ctx, err, "authenticate failed")
 	}
+	req.Header.Set(LoginHeaderName, identity.User)
+	if identity.Provider != "" {
+		req.Header.Set(ProviderHeaderName, identity.Provider)
+	}
+	if len(identity.Groups) > 0 {
+		req.Header.Set(GroupsHeaderName, strings.Join(identity.Groups, ","))
+	}
+
+	glog.V(2).Infof("user %s is authenticated", identity.User)
+	return identity, nil
+}
 
-	secureCookie, err := l.cookieGenerator.Decode(ctx, cookie.Value)
+func (l *loginMiddleware) login(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+	handoffURL, ok, err := l.startHandoff(resp, req)
 	if err != nil {
-		return errors.Wrap(ctx, err, "invalid auth cookie")
+		return errors.Wrapf(ctx, err, "start handoff failed")
+	}
+	if ok {
+		glog.V(3).Infof("redirect to central auth host")
+		http.Redirect(resp, req, handoffURL, http.StatusFound)
+		return nil
+	}
+	if l.signInPath != "" {
+		glog.V(3).Infof("redirect to sign-in page")
+		http.Redirect(resp, req, l.signInPath+"?"+url.Values{"rd": {req.URL.String()}}.Encode(), http.StatusFound)
+		return nil
 	}
-	req.Header.Set(LoginHeaderName, secureCookie.Subject)
+	return startLogin(ctx, resp, req, l.stateGenerator, l.providers.Default(), l.callbackHosts, l.metrics, l.auditLogger, req.URL.String(), nil)
+}
 
-	glog.V(2).Infof("user %s is authenticated", secureCookie.Subject)
-	return nil
+// stepUpRequired returns the requirements of the policy of the path if the login of identity does not satisfy them
+func (l *loginMiddleware) stepUpRequired(ctx context.Context, req *http.Request, identity *Identity) (StepUp, bool) {
+	policy, ok := l.policies.Find(req.URL.Path)
+	if !ok || !policy.StepUp.Required() {
+		return StepUp{}, false
+	}
+	if err := policy.StepUp.Check(ctx, time.Now(), identity.AuthTime, identity.ACR, identity.AMR); err != nil {
+		glog.V(2).Infof("step-up of %s for %s required: %v", identity.User, req.URL.Path, err)
+		return policy.StepUp, true
+	}
+	return StepUp{}, false
 }
 
-func (l *loginMiddleware) login(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
-	state, err := l.stateGenerator.Generate(ctx, req.URL.String())
+// stepUp starts a new login with the provider of identity. Identities without login session, e.g. access
+// tokens, or with a provider that can not authenticate the user again can not step up.
+func (l *loginMiddleware) stepUp(ctx context.Context, resp http.ResponseWriter, req *http.Request, identity *Identity, stepUp StepUp) error {
+	provider, ok := l.providers.Find(identity.Provider)
+	if identity.SessionID == "" || !ok || !provider.StepUpSupported() {
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(http.StatusForbidden)
+		return json.NewEncoder(resp).Encode(UnauthorizedResponse{Error: "step_up_required"})
+	}
+	if l.requestClassifier.IsNavigational(req) {
+		return startLogin(ctx, resp, req, l.stateGenerator, provider, l.callbackHosts, l.metrics, l.auditLogger, req.URL.String(), &stepUp)
+	}
+	url, err := l.loginURL(ctx, req, provider, &stepUp)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "get login url failed")
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("WWW-Authenticate", `Bearer realm="login", error="insufficient_user_authentication"`)
+	resp.WriteHeader(http.StatusUnauthorized)
+	return json.NewEncoder(resp).Encode(UnauthorizedResponse{
+		Error:    "step_up_required",
+		LoginURL: url,
+	})
+}
+
+// startLogin redirects to the provider, which returns the user to origin after the login.
+// The callback url is derived from the request host if it is one of callbackHosts.
+// stepUp is nil for a regular login.
+func startLogin(
+	ctx context.Context,
+	resp http.ResponseWriter,
+	req *http.Request,
+	stateGenerator StateGenerator,
+	provider Provider,
+	callbackHosts CallbackHosts,
+	metrics Metrics,
+	auditLogger AuditLogger,
+	origin string,
+	stepUp *StepUp,
+) error {
+	state, err := stateGenerator.Generate(ctx, origin, provider.ID(), stepUp)
 	if err != nil {
 		return errors.Wrapf(ctx, err, "generate state failed")
 	}
-	url := l.googleOAuth.AuthCodeURL(state)
-	glog.V(3).Infof("redirect url '%s'", url)
-	http.Redirect(resp, req, url, http.StatusTemporaryRedirect)
+	authCodeURL := provider.AuthCodeURL(state, authRequest(req, provider, callbackHosts, stepUp))
+	glog.V(3).Infof("redirect url '%s'", authCodeURL)
+	metrics.LoginRedirect(provider.ID())
+	event := NewAuditEvent(req, AuditEventLoginStarted)
+	event.Origin = origin
+	event.Provider = provider.ID()
+	if stepUp != nil {
+		event.Reason = "step_up"
+	}
+	auditLogger.Log(ctx, event)
+	http.Redirect(resp, req, authCodeURL, http.StatusTemporaryRedirect)
 	return nil
 }
+
+// UnauthorizedResponse is returned as JSON to non navigational requests without valid credentials
+type UnauthorizedResponse struct {
+	Error    string `json:"error"`
+	LoginURL string `json:"login_url,omitempty"`
+}
+
+func (l *loginMiddleware) unauthorized(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+	url, ok, err := l.startHandoff(resp, req)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "start handoff failed")
+	}
+	if !ok {
+		url, err = l.loginURL(ctx, req, l.providers.Default(), nil)
+		if err != nil {
+			return errors.Wrapf(ctx, err, "get login url failed")
+		}
+	}
+	l.metrics.LoginUnauthorized(l.providers.Default().ID())
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("WWW-Authenticate", `Bearer realm="login"`)
+	resp.WriteHeader(http.StatusUnauthorized)
+	return json.NewEncoder(resp).Encode(UnauthorizedResponse{
+		Error:    "unauthorized",
+		LoginURL: url,
+	})
+}
+
+func (l *loginMiddleware) loginURL(ctx context.Context, req *http.Request, provider Provider, stepUp *StepUp) (string, error) {
+	state, err := l.stateGenerator.Generate(ctx, req.URL.String(), provider.ID(), stepUp)
+	if err != nil {
+		return "", errors.Wrapf(ctx, err, "generate state failed")
+	}
+	return provider.AuthCodeURL(state, authRequest(req, provider, l.callbackHosts, stepUp)), nil
+}
+
+// startHandoff sets the nonce cookie and returns the url of the central auth host handing the login
+// over to this request. Requests to the central auth host itself login at the provider.
+func (l *loginMiddleware) startHandoff(resp http.ResponseWriter, req *http.Request) (string, bool, error) {
+	if l.handoffURL == "" {
+		return "", false, nil
+	}
+	handoffURL, err := url.Parse(l.handoffURL)
+	if err != nil || strings.EqualFold(handoffURL.Host, RequestHost(req)) {
+		return "", false, nil
+	}
+	nonceCookie, err := NewHandoffNonceCookie(req)
+	if err != nil {
+		return "", false, err
+	}
+	http.SetCookie(resp, nonceCookie)
+	scheme := "http"
+	if IsHTTPS(req) {
+		scheme = "https"
+	}
+	origin := url.URL{Scheme: scheme, Host: RequestHost(req), Path: req.URL.Path, RawQuery: req.URL.RawQuery}
+	query := handoffURL.Query()
+	query.Set("rd", origin.String())
+	query.Set("nonce", nonceCookie.Value)
+	handoffURL.RawQuery = query.Encode()
+	return handoffURL.String(), true, nil
+}
+
+func authRequest(req *http.Request, provider Provider, callbackHosts CallbackHosts, stepUp *StepUp) AuthRequest {
+	request := AuthRequest{
+		LoginHint:   LoginHint(req),
+		RedirectURL: callbackHosts.RedirectURL(req, provider),
+	}
+	if stepUp != nil {
+		request.StepUp = *stepUp
+	}
+	return request
+}
@@ -0,0 +1,273 @@
+package pkg_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("LoginMiddleware", func() {
+	var authenticator *mocks.Authenticator
+	var stateGenerator *mocks.StateGenerator
+	var provider *mocks.Provider
+	var requestClassifier *mocks.RequestClassifier
+	var metrics *mocks.Metrics
+	var auditLogger *mocks.AuditLogger
+	var req *http.Request
+	var recorder *httptest.ResponseRecorder
+	var user string
+	var traceparent string
+	var signInPath string
+	var providerID string
+	var policies pkg.Policies
+	var rateLimiter *mocks.RateLimiter
+	var callbackHosts pkg.CallbackHosts
+	var handoffURL string
+	BeforeEach(func() {
+		signInPath = ""
+		callbackHosts = nil
+		handoffURL = ""
+		policies = nil
+		rateLimiter = &mocks.RateLimiter{}
+		rateLimiter.AllowReturns(0, true)
+		authenticator = &mocks.Authenticator{}
+		stateGenerator = &mocks.StateGenerator{}
+		provider = &mocks.Provider{}
+		provider.IDReturns(pkg.ProviderGoogle)
+		provider.NameReturns("Google")
+		provider.StepUpSupportedReturns(true)
+		provider.AuthCodeURLReturns("https://accounts.example.com/auth")
+		provider.RedirectURLReturns("https://auth.example.com/callback")
+		requestClassifier = &mocks.RequestClassifier{}
+		requestClassifier.IsNavigationalReturns(true)
+		metrics = &mocks.Metrics{}
+		auditLogger = &mocks.AuditLogger{}
+
+		var err error
+		req, err = http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+		Expect(err).To(BeNil())
+		req.Header.Set(pkg.LoginHeaderName, "forged@example.com")
+		recorder = httptest.NewRecorder()
+		user = ""
+	})
+	JustBeforeEach(func() {
+		middleware := pkg.NewLoginMiddleware(authenticator, stateGenerator, pkg.Providers{provider}, policies, requestClassifier, rateLimiter, metrics, auditLogger, []string{"/callback"}, signInPath, callbackHosts, handoffURL)
+		middleware.Middleware(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			user = req.Header.Get(pkg.LoginHeaderName)
+			traceparent = req.Header.Get("traceparent")
+			providerID = req.Header.Get(pkg.ProviderHeaderName)
+		})).ServeHTTP(recorder, req)
+	})
+	Context("authenticated", func() {
+		BeforeEach(func() {
+			authenticator.AuthenticateReturns(&pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGitHub}, nil)
+		})
+		It("passes user to handler", func() {
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(user).To(Equal("jdoe@example.com"))
+			Expect(providerID).To(Equal(pkg.ProviderGitHub))
+		})
+		Context("with trace context", func() {
+			BeforeEach(func() {
+				_, err := pkg.SetupTracing(context.Background(), pkg.TraceExporterNone, "test")
+				Expect(err).To(BeNil())
+				req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			})
+			It("propagates trace to upstream", func() {
+				Expect(traceparent).To(ContainSubstring("4bf92f3577b34da6a3ce929d0e0e4736"))
+			})
+		})
+		Context("with step-up policy", func() {
+			var authTime time.Time
+			BeforeEach(func() {
+				authTime = time.Now().Add(-time.Hour)
+				policies = pkg.Policies{{PathPrefix: "/foo", StepUp: pkg.StepUp{MaxAuthAge: 5 * time.Minute}}}
+			})
+			Context("and old login", func() {
+				BeforeEach(func() {
+					authenticator.AuthenticateReturns(&pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGoogle, SessionID: "session-id", AuthTime: authTime}, nil)
+				})
+				It("redirects to provider with step-up", func() {
+					Expect(recorder.Code).To(Equal(http.StatusTemporaryRedirect))
+					Expect(user).To(BeEmpty())
+					_, request := provider.AuthCodeURLArgsForCall(0)
+					Expect(request.StepUp.MaxAuthAge).To(Equal(5 * time.Minute))
+					_, _, _, stepUp := stateGenerator.GenerateArgsForCall(0)
+					Expect(stepUp).NotTo(BeNil())
+				})
+				Context("of api client", func() {
+					BeforeEach(func() {
+						requestClassifier.IsNavigationalReturns(false)
+					})
+					It("returns 401 step_up_required", func() {
+						Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+						var response pkg.UnauthorizedResponse
+						Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(BeNil())
+						Expect(response.Error).To(Equal("step_up_required"))
+						Expect(response.LoginURL).To(Equal("https://accounts.example.com/auth"))
+					})
+				})
+			})
+			Context("and recent login", func() {
+				BeforeEach(func() {
+					authenticator.AuthenticateReturns(&pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGoogle, AuthTime: time.Now()}, nil)
+				})
+				It("passes user to handler", func() {
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					Expect(user).To(Equal("jdoe@example.com"))
+				})
+			})
+			Context("and login without auth time", func() {
+				BeforeEach(func() {
+					authenticator.AuthenticateReturns(&pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGoogle, SessionID: "session-id"}, nil)
+				})
+				It("redirects to provider with step-up", func() {
+					Expect(recorder.Code).To(Equal(http.StatusTemporaryRedirect))
+					Expect(user).To(BeEmpty())
+				})
+			})
+			Context("and provider without step-up", func() {
+				BeforeEach(func() {
+					provider.StepUpSupportedReturns(false)
+					authenticator.AuthenticateReturns(&pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGoogle, AuthTime: authTime}, nil)
+				})
+				It("returns 403 step_up_required", func() {
+					Expect(recorder.Code).To(Equal(http.StatusForbidden))
+					var response pkg.UnauthorizedResponse
+					Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(BeNil())
+					Expect(response.Error).To(Equal("step_up_required"))
+					Expect(provider.AuthCodeURLCallCount()).To(Equal(0))
+				})
+			})
+			Context("and access token of a google user", func() {
+				BeforeEach(func() {
+					authenticator.AuthenticateReturns(&pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGoogle}, nil)
+				})
+				It("returns 403 step_up_required", func() {
+					Expect(recorder.Code).To(Equal(http.StatusForbidden))
+					Expect(provider.AuthCodeURLCallCount()).To(Equal(0))
+				})
+			})
+			Context("and identity without provider", func() {
+				BeforeEach(func() {
+					authenticator.AuthenticateReturns(&pkg.Identity{User: "ci@example.com", AuthTime: authTime}, nil)
+				})
+				It("returns 403", func() {
+					Expect(recorder.Code).To(Equal(http.StatusForbidden))
+					Expect(user).To(BeEmpty())
+				})
+			})
+		})
+	})
+	Context("unauthenticated browser", func() {
+		BeforeEach(func() {
+			authenticator.AuthenticateReturns(nil, pkg.ErrNoCredentials)
+		})
+		It("redirects to login", func() {
+			Expect(recorder.Code).To(Equal(http.StatusTemporaryRedirect))
+			Expect(recorder.Header().Get("Location")).To(Equal("https://accounts.example.com/auth"))
+			Expect(user).To(BeEmpty())
+		})
+		It("counts redirect", func() {
+			Expect(metrics.LoginRedirectCallCount()).To(Equal(1))
+		})
+		Context("with sign-in page", func() {
+			BeforeEach(func() {
+				signInPath = "/sign-in"
+			})
+			It("redirects to sign-in page", func() {
+				Expect(recorder.Code).To(Equal(http.StatusFound))
+				Expect(recorder.Header().Get("Location")).To(Equal("/sign-in?rd=http%3A%2F%2Fexample.com%2Ffoo"))
+				Expect(metrics.LoginRedirectCallCount()).To(Equal(0))
+			})
+		})
+		Context("with login hint of previous login", func() {
+			BeforeEach(func() {
+				req.AddCookie(&http.Cookie{Name: pkg.LoginHintCookieName, Value: "jdoe@example.com"})
+			})
+			It("passes hint to provider", func() {
+				_, request := provider.AuthCodeURLArgsForCall(0)
+				Expect(request.LoginHint).To(Equal("jdoe@example.com"))
+			})
+		})
+		It("uses configured redirect url", func() {
+			_, request := provider.AuthCodeURLArgsForCall(0)
+			Expect(request.RedirectURL).To(BeEmpty())
+		})
+		Context("with central auth host", func() {
+			BeforeEach(func() {
+				handoffURL = "https://auth.example.org/sso/authorize"
+				signInPath = "/sign-in"
+			})
+			It("redirects to central auth host", func() {
+				Expect(recorder.Code).To(Equal(http.StatusFound))
+				location, err := url.Parse(recorder.Header().Get("Location"))
+				Expect(err).To(BeNil())
+				Expect(location.Host).To(Equal("auth.example.org"))
+				Expect(location.Query().Get("rd")).To(Equal("http://example.com/foo"))
+				Expect(stateGenerator.GenerateCallCount()).To(Equal(0))
+				Expect(recorder.Result().Cookies()).To(ContainElement(And(
+					HaveField("Name", pkg.HandoffNonceCookieName),
+					HaveField("Value", location.Query().Get("nonce")),
+				)))
+			})
+			Context("on central auth host", func() {
+				BeforeEach(func() {
+					handoffURL = "http://example.com/sso/authorize"
+				})
+				It("logs in at sign-in page", func() {
+					Expect(recorder.Header().Get("Location")).To(HavePrefix("/sign-in?"))
+				})
+			})
+		})
+		Context("with allowed callback host", func() {
+			BeforeEach(func() {
+				callbackHosts = pkg.CallbackHosts{"example.com"}
+			})
+			It("derives redirect url from request host", func() {
+				_, request := provider.AuthCodeURLArgsForCall(0)
+				Expect(request.RedirectURL).To(Equal("http://example.com/callback"))
+			})
+		})
+		It("audits login start", func() {
+			Expect(auditLogger.LogCallCount()).To(Equal(1))
+			_, event := auditLogger.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditEventLoginStarted))
+			Expect(event.Origin).To(Equal("http://example.com/foo"))
+		})
+	})
+	Context("unauthenticated client over rate limit", func() {
+		BeforeEach(func() {
+			authenticator.AuthenticateReturns(nil, pkg.ErrNoCredentials)
+			rateLimiter.AllowReturns(1500*time.Millisecond, false)
+		})
+		It("returns 429", func() {
+			Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(recorder.Header().Get("Retry-After")).To(Equal("2"))
+			Expect(stateGenerator.GenerateCallCount()).To(Equal(0))
+			Expect(metrics.RateLimitedArgsForCall(0)).To(Equal("login"))
+		})
+	})
+	Context("unauthenticated api client", func() {
+		BeforeEach(func() {
+			authenticator.AuthenticateReturns(nil, pkg.ErrNoCredentials)
+			requestClassifier.IsNavigationalReturns(false)
+		})
+		It("returns 401 with login url", func() {
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+			var response pkg.UnauthorizedResponse
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.LoginURL).To(Equal("https://accounts.example.com/auth"))
+			Expect(user).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+
+	libhttp "github.com/bborbe/http"
+	"github.com/golang/glog"
+)
+
+// NewLogoutHandler deletes the session of the login cookie, removes the cookie and renders the signed out page
+func NewLogoutHandler(
+	cookieGenerator CookieGenerator,
+	cookieOptions CookieOptions,
+	sessionStore SessionStore,
+	auditLogger AuditLogger,
+	templates Templates,
+) libhttp.WithError {
+	return libhttp.WithErrorFunc(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+		event := NewAuditEvent(req, AuditEventLogout)
+		if cookie, err := req.Cookie(LoginCookieName); err == nil {
+			if session, err := cookieGenerator.Decode(ctx, cookie.Value); err == nil {
+				event.User = session.Subject
+				event.SessionID = session.ID
+				if err := sessionStore.DeleteSession(ctx, session.ID); err != nil {
+					glog.V(2).Infof("delete session %s failed: %v", session.ID, err)
+				}
+			}
+		}
+		auditLogger.Log(ctx, event)
+
+		http.SetCookie(resp, cookieOptions.ExpiredHTTPCookie(req, LoginCookieName))
+		http.SetCookie(resp, cookieOptions.ExpiredHTTPCookie(req, LoginHintCookieName))
+		glog.V(2).Infof("logout %s success", event.User)
+		return templates.Render(ctx, resp, http.StatusOK, TemplateSignedOut, "Signed out", SignedOutPage{
+			User:      event.User,
+			SignInURL: "/",
+		})
+	})
+}
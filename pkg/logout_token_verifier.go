@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/bborbe/errors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// BackChannelLogoutEvent identifies a logout token in its events claim (OpenID Connect Back-Channel Logout 2.4)
+const BackChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// LogoutTokenType is the typ header of logout tokens, it keeps other JWTs of the provider from being used as one
+const LogoutTokenType = "logout+jwt"
+
+// LogoutTokenClaims of a logout token sent by the provider
+type LogoutTokenClaims struct {
+	// SID of the login at the provider, identifies the sessions to logout together with or instead of the subject
+	SID    string                     `json:"sid,omitempty"`
+	Events map[string]json.RawMessage `json:"events"`
+	// Nonce is forbidden in logout tokens, so they can not be used as ID tokens
+	Nonce string `json:"nonce,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// LogoutTokenVerifier verifies logout tokens of a provider
+//
+//counterfeiter:generate -o ../mocks/logout-token-verifier.go --fake-name LogoutTokenVerifier . LogoutTokenVerifier
+type LogoutTokenVerifier interface {
+	Verify(ctx context.Context, token string) (*LogoutTokenClaims, error)
+}
+
+// NewLogoutTokenVerifier accepts logout tokens signed by a key of the given JWKS,
+// issued by one of issuers for the audience (client id).
+func NewLogoutTokenVerifier(
+	keySet JWKS,
+	issuers []string,
+	audience string,
+) LogoutTokenVerifier {
+	return &logoutTokenVerifier{
+		keySet:   keySet,
+		issuers:  issuers,
+		audience: audience,
+	}
+}
+
+type logoutTokenVerifier struct {
+	keySet   JWKS
+	issuers  []string
+	audience string
+}
+
+func (l *logoutTokenVerifier) Verify(ctx context.Context, token string) (*LogoutTokenClaims, error) {
+	var claims LogoutTokenClaims
+	parsed, err := jwt.ParseWithClaims(
+		token,
+		&claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return l.keySet.Key(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithAudience(l.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse logout token failed")
+	}
+	if typ, _ := parsed.Header["typ"].(string); !isLogoutTokenType(typ) {
+		return nil, errors.Wrapf(ctx, jwt.ErrTokenInvalidClaims, "typ '%s' is not %s", typ, LogoutTokenType)
+	}
+	if !slices.Contains(l.issuers, claims.Issuer) {
+		return nil, errors.Wrapf(ctx, jwt.ErrTokenInvalidIssuer, "issuer '%s' not allowed", claims.Issuer)
+	}
+	if claims.IssuedAt == nil {
+		return nil, errors.Wrapf(ctx, jwt.ErrTokenRequiredClaimMissing, "iat missing")
+	}
+	if claims.ID == "" {
+		return nil, errors.Wrapf(ctx, jwt.ErrTokenRequiredClaimMissing, "jti missing")
+	}
+	if claims.Subject == "" && claims.SID == "" {
+		return nil, errors.Wrapf(ctx, jwt.ErrTokenRequiredClaimMissing, "sub and sid missing")
+	}
+	if claims.Nonce != "" {
+		return nil, errors.Wrapf(ctx, jwt.ErrTokenInvalidClaims, "nonce not allowed")
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(claims.Events[BackChannelLogoutEvent], &event); err != nil || event == nil {
+		return nil, errors.Wrapf(ctx, jwt.ErrTokenInvalidClaims, "events claim has no %s object", BackChannelLogoutEvent)
+	}
+	return &claims, nil
+}
+
+// isLogoutTokenType returns true for the typ header logout+jwt, the application/ prefix may be omitted (RFC 7515 4.1.9)
+func isLogoutTokenType(typ string) bool {
+	typ = strings.ToLower(typ)
+	return typ == LogoutTokenType || typ == "application/"+LogoutTokenType
+}
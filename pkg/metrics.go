@@ -0,0 +1,162 @@
+package pkg
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CallbackFailureReason describes why a login callback failed
+type CallbackFailureReason string
+
+const (
+	CallbackFailureReasonInvalidState       CallbackFailureReason = "invalid_state"
+	CallbackFailureReasonAccessDenied       CallbackFailureReason = "access_denied"
+	CallbackFailureReasonProviderError      CallbackFailureReason = "provider_error"
+	CallbackFailureReasonCodeExchangeFailed CallbackFailureReason = "code_exchange_failed"
+	CallbackFailureReasonUserInfoFailed     CallbackFailureReason = "userinfo_failed"
+	CallbackFailureReasonUnauthorized       CallbackFailureReason = "unauthorized"
+	CallbackFailureReasonStepUpFailed       CallbackFailureReason = "step_up_failed"
+	CallbackFailureReasonInternal           CallbackFailureReason = "internal"
+)
+
+var (
+	loginRedirectsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "oauth2",
+		Subsystem: "login",
+		Name:      "redirects_total",
+		Help:      "Counts redirects of unauthenticated browsers to the identity provider",
+	}, []string{"provider"})
+	loginUnauthorizedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "oauth2",
+		Subsystem: "login",
+		Name:      "unauthorized_total",
+		Help:      "Counts non navigational requests rejected with 401",
+	}, []string{"provider"})
+	callbackCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "oauth2",
+		Subsystem: "callback",
+		Name:      "total",
+		Help:      "Counts login callbacks by result",
+	}, []string{"provider", "result"})
+	cookieDecodeFailuresCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "oauth2",
+		Subsystem: "cookie",
+		Name:      "decode_failures_total",
+		Help:      "Counts login cookies that failed validation by reason",
+	}, []string{"reason"})
+	providerRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "oauth2",
+		Subsystem: "provider",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of requests to the identity provider",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider", "operation", "result"})
+	rateLimitedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "oauth2",
+		Subsystem: "ratelimit",
+		Name:      "throttled_total",
+		Help:      "Counts requests rejected with 429 by endpoint",
+	}, []string{"endpoint"})
+	activeSessionsDesc = prometheus.NewDesc(
+		"oauth2_sessions_active",
+		"Number of sessions not expired",
+		[]string{"provider"},
+		nil,
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		loginRedirectsCounter,
+		loginUnauthorizedCounter,
+		callbackCounter,
+		cookieDecodeFailuresCounter,
+		providerRequestDuration,
+		rateLimitedCounter,
+	)
+}
+
+// Metrics records events of the login flow
+//
+//counterfeiter:generate -o ../mocks/metrics.go --fake-name Metrics . Metrics
+type Metrics interface {
+	LoginRedirect(provider string)
+	LoginUnauthorized(provider string)
+	CallbackSuccess(provider string)
+	CallbackFailure(provider string, reason CallbackFailureReason)
+	CookieDecodeFailure(reason TokenErrorReason)
+	ProviderRequest(provider string, operation string, duration time.Duration, err error)
+	RateLimited(endpoint string)
+}
+
+// NewMetrics records to the default prometheus registry
+func NewMetrics() Metrics {
+	return &metrics{}
+}
+
+type metrics struct{}
+
+func (m *metrics) LoginRedirect(provider string) {
+	loginRedirectsCounter.WithLabelValues(provider).Inc()
+}
+
+func (m *metrics) LoginUnauthorized(provider string) {
+	loginUnauthorizedCounter.WithLabelValues(provider).Inc()
+}
+
+func (m *metrics) CallbackSuccess(provider string) {
+	callbackCounter.WithLabelValues(provider, "success").Inc()
+}
+
+func (m *metrics) CallbackFailure(provider string, reason CallbackFailureReason) {
+	callbackCounter.WithLabelValues(provider, string(reason)).Inc()
+}
+
+func (m *metrics) CookieDecodeFailure(reason TokenErrorReason) {
+	cookieDecodeFailuresCounter.WithLabelValues(reason.String()).Inc()
+}
+
+func (m *metrics) ProviderRequest(provider string, operation string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	providerRequestDuration.WithLabelValues(provider, operation, result).Observe(duration.Seconds())
+}
+
+func (m *metrics) RateLimited(endpoint string) {
+	rateLimitedCounter.WithLabelValues(endpoint).Inc()
+}
+
+// NewActiveSessionsCollector reports the number of active sessions in the store by provider
+func NewActiveSessionsCollector(sessionStore SessionStore) prometheus.Collector {
+	return &activeSessionsCollector{
+		sessionStore: sessionStore,
+	}
+}
+
+type activeSessionsCollector struct {
+	sessionStore SessionStore
+}
+
+func (a *activeSessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeSessionsDesc
+}
+
+func (a *activeSessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	sessions, err := a.sessionStore.Sessions(context.Background())
+	if err != nil {
+		glog.Warningf("list sessions failed: %v", err)
+		return
+	}
+	counts := map[string]int{}
+	for _, session := range sessions {
+		counts[session.Provider]++
+	}
+	for provider, count := range counts {
+		ch <- prometheus.MustNewConstMetric(activeSessionsDesc, prometheus.GaugeValue, float64(count), provider)
+	}
+}
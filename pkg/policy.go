@@ -0,0 +1,174 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+)
+
+// Policy allows only the listed users to access the path prefix and the paths below it.
+// A user is allowed if any of the lists matches. A policy without lists
+// allows all users and only enforces its step-up requirements.
+type Policy struct {
+	PathPrefix string `yaml:"path_prefix"`
+	// AllowedUsers by exact name, e.g. jdoe@example.com or service-account:ci, see EmailProviders
+	AllowedUsers []string `yaml:"allowed_users"`
+	// AllowedDomains of the email of the user, see EmailProviders
+	AllowedDomains []string `yaml:"allowed_domains"`
+	// EmailProviders whose users are matched by AllowedUsers and AllowedDomains, defaults to google.
+	// GitHub does not enforce the domain of an email, its users only match if listed.
+	EmailProviders []string `yaml:"email_providers"`
+	// AllowedProviders the user logged in with
+	AllowedProviders []string `yaml:"allowed_providers"`
+	// AllowedGroups the user is a member of, see GroupsConfig
+	AllowedGroups []string `yaml:"allowed_groups"`
+	// StepUp requires a recent or strong login for the path
+	StepUp `yaml:",inline"`
+}
+
+// Validate the policy
+func (p Policy) Validate(ctx context.Context) error {
+	if !strings.HasPrefix(p.PathPrefix, "/") {
+		return errors.Errorf(ctx, "path_prefix '%s' must start with /", p.PathPrefix)
+	}
+	if p.allowsAll() && !p.StepUp.Required() {
+		return errors.Errorf(ctx, "path_prefix '%s' has no allowed_* rule and no step-up", p.PathPrefix)
+	}
+	if err := p.StepUp.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "path_prefix '%s' invalid", p.PathPrefix)
+	}
+	return nil
+}
+
+// Allows returns true if the identity may access paths of the policy
+func (p Policy) Allows(identity Identity) bool {
+	if p.allowsAll() {
+		return true
+	}
+	if identity.Provider != "" && slices.Contains(p.AllowedProviders, identity.Provider) {
+		return true
+	}
+	for _, group := range identity.Groups {
+		if slices.Contains(p.AllowedGroups, group) {
+			return true
+		}
+	}
+	if !p.matchesEmailOf(identity.Provider) {
+		return false
+	}
+	if slices.Contains(p.AllowedUsers, identity.User) {
+		return true
+	}
+	if pos := strings.LastIndex(identity.User, "@"); pos != -1 {
+		return slices.Contains(p.AllowedDomains, identity.User[pos+1:])
+	}
+	return false
+}
+
+// matchesEmailOf returns true if AllowedUsers and AllowedDomains apply to users of provider.
+// Identities without provider, e.g. service accounts and verified bearer ID tokens, are named by the gateway.
+func (p Policy) matchesEmailOf(provider string) bool {
+	if provider == "" {
+		return true
+	}
+	if len(p.EmailProviders) == 0 {
+		return provider == ProviderGoogle
+	}
+	return slices.Contains(p.EmailProviders, provider)
+}
+
+func (p Policy) allowsAll() bool {
+	return len(p.AllowedUsers) == 0 && len(p.AllowedDomains) == 0 && len(p.AllowedProviders) == 0 && len(p.AllowedGroups) == 0
+}
+
+// Policies by path prefix, the policy with the longest matching prefix applies
+type Policies []Policy
+
+// Validate all policies and ensure prefixes are unique
+func (p Policies) Validate(ctx context.Context) error {
+	prefixes := map[string]bool{}
+	for i, policy := range p {
+		if err := policy.Validate(ctx); err != nil {
+			return errors.Wrapf(ctx, err, "policy %d invalid", i)
+		}
+		if prefixes[policy.PathPrefix] {
+			return errors.Errorf(ctx, "policy %d: path_prefix '%s' defined twice", i, policy.PathPrefix)
+		}
+		prefixes[policy.PathPrefix] = true
+	}
+	return nil
+}
+
+// Find the policy for the path, prefixes match whole path segments
+func (p Policies) Find(path string) (Policy, bool) {
+	var result Policy
+	var found bool
+	for _, policy := range p {
+		if matchesPathPrefix(path, policy.PathPrefix) && len(policy.PathPrefix) >= len(result.PathPrefix) {
+			result = policy
+			found = true
+		}
+	}
+	return result, found
+}
+
+// matchesPathPrefix returns true if path is prefix or below it, e.g. /admin matches /admin/users but not /administrator
+func matchesPathPrefix(path string, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// Allows returns true if no policy matches the path or the matching policy allows the identity
+func (p Policies) Allows(path string, identity Identity) bool {
+	policy, ok := p.Find(path)
+	if !ok {
+		return true
+	}
+	return policy.Allows(identity)
+}
+
+// NewPolicyMiddleware rejects authenticated users not allowed by policies.
+// It must run after the login middleware, which adds the identity to the request context.
+func NewPolicyMiddleware(
+	policies Policies,
+	requestClassifier RequestClassifier,
+	auditLogger AuditLogger,
+	templates Templates,
+) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			identity, ok := IdentityFromContext(ctx)
+			if !ok || policies.Allows(req.URL.Path, *identity) {
+				handler.ServeHTTP(resp, req)
+				return
+			}
+			glog.V(2).Infof("policy denies %s access to %s", identity.User, req.URL.Path)
+			event := NewAuditEvent(req, AuditEventAccessDenied)
+			event.User = identity.User
+			event.Provider = identity.Provider
+			event.Origin = req.URL.Path
+			event.Reason = "policy"
+			auditLogger.Log(ctx, event)
+			if !requestClassifier.IsNavigational(req) {
+				resp.Header().Set("Content-Type", "application/json")
+				resp.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(resp).Encode(UnauthorizedResponse{Error: "forbidden"})
+				return
+			}
+			if err := templates.Render(ctx, resp, http.StatusForbidden, TemplateForbidden, "Access denied", LoginErrorPage{
+				Message:  "Your account is not allowed to access this page.",
+				RetryURL: "/",
+			}); err != nil {
+				glog.Warningf("render forbidden page failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("Policies", func() {
+	var policies pkg.Policies
+	BeforeEach(func() {
+		policies = pkg.Policies{
+			{PathPrefix: "/", AllowedDomains: []string{"example.com"}, AllowedProviders: []string{pkg.ProviderGitHub}},
+			{PathPrefix: "/admin", AllowedUsers: []string{"admin@example.com"}, AllowedGroups: []string{"admins@example.com"}},
+		}
+	})
+	DescribeTable("Allows",
+		func(path string, user string, provider string, groups []string, expected bool) {
+			Expect(policies.Allows(path, pkg.Identity{User: user, Provider: provider, Groups: groups})).To(Equal(expected))
+		},
+		Entry("domain", "/foo", "jdoe@example.com", pkg.ProviderGoogle, nil, true),
+		Entry("other domain", "/foo", "jdoe@example.org", pkg.ProviderGoogle, nil, false),
+		Entry("provider", "/foo", "jdoe@example.org", pkg.ProviderGitHub, nil, true),
+		Entry("longest prefix wins", "/admin/users", "jdoe@example.com", pkg.ProviderGoogle, nil, false),
+		Entry("user", "/admin/users", "admin@example.com", pkg.ProviderGoogle, nil, true),
+		Entry("group", "/admin/users", "jdoe@example.com", pkg.ProviderGoogle, []string{"admins@example.com"}, true),
+		Entry("service account", "/foo", "service-account:ci", "", nil, false),
+		Entry("user of github", "/admin/users", "admin@example.com", pkg.ProviderGitHub, nil, false),
+		Entry("bearer user without provider", "/admin/users", "admin@example.com", "", nil, true),
+		Entry("exact prefix", "/admin", "jdoe@example.com", pkg.ProviderGoogle, nil, false),
+		Entry("prefix followed by slash", "/admin/", "jdoe@example.com", pkg.ProviderGoogle, nil, false),
+		Entry("longer segment", "/administrator", "jdoe@example.com", pkg.ProviderGoogle, nil, true),
+		Entry("segment with suffix", "/admin-foo", "jdoe@example.org", pkg.ProviderGitHub, nil, true),
+	)
+	It("matches prefix with trailing slash below it only", func() {
+		policy, ok := pkg.Policies{{PathPrefix: "/api/", AllowedUsers: []string{"admin@example.com"}}}.Find("/api/users")
+		Expect(ok).To(BeTrue())
+		Expect(policy.PathPrefix).To(Equal("/api/"))
+		_, ok = pkg.Policies{{PathPrefix: "/api/", AllowedUsers: []string{"admin@example.com"}}}.Find("/apis")
+		Expect(ok).To(BeFalse())
+	})
+	It("matches domain of github users listed in email providers", func() {
+		policies := pkg.Policies{{PathPrefix: "/", AllowedDomains: []string{"example.com"}}}
+		Expect(policies.Allows("/foo", pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGitHub})).To(BeFalse())
+		policies[0].EmailProviders = []string{pkg.ProviderGoogle, pkg.ProviderGitHub}
+		Expect(policies.Allows("/foo", pkg.Identity{User: "jdoe@example.com", Provider: pkg.ProviderGitHub})).To(BeTrue())
+	})
+	It("allows all without matching policy", func() {
+		Expect(pkg.Policies{}.Allows("/foo", pkg.Identity{User: "jdoe@example.org"})).To(BeTrue())
+	})
+	Context("middleware", func() {
+		var recorder *httptest.ResponseRecorder
+		var auditLogger *mocks.AuditLogger
+		var called bool
+		var req *http.Request
+		BeforeEach(func() {
+			recorder = httptest.NewRecorder()
+			auditLogger = &mocks.AuditLogger{}
+			called = false
+			req = httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Accept", "text/html")
+			req = req.WithContext(pkg.WithIdentity(req.Context(), &pkg.Identity{User: "jdoe@example.com"}))
+		})
+		JustBeforeEach(func() {
+			templates, err := pkg.NewTemplates(context.Background(), "", pkg.Branding{})
+			Expect(err).To(BeNil())
+			middleware := pkg.NewPolicyMiddleware(policies, pkg.NewRequestClassifier(nil), auditLogger, templates)
+			middleware(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				called = true
+			})).ServeHTTP(recorder, req)
+		})
+		It("renders forbidden page", func() {
+			Expect(called).To(BeFalse())
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("text/html"))
+			_, event := auditLogger.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditEventAccessDenied))
+			Expect(event.User).To(Equal("jdoe@example.com"))
+		})
+		Context("allowed user", func() {
+			BeforeEach(func() {
+				req = req.WithContext(pkg.WithIdentity(req.Context(), &pkg.Identity{User: "admin@example.com"}))
+			})
+			It("calls handler", func() {
+				Expect(called).To(BeTrue())
+				Expect(auditLogger.LogCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
@@ -0,0 +1,203 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bborbe/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// ProviderGoogle identifies the Google identity provider
+	ProviderGoogle = "google"
+	// ProviderGitHub identifies the GitHub identity provider
+	ProviderGitHub = "github"
+	// ProviderUnknown is used in metrics and audit events if the provider of a request is not known
+	ProviderUnknown = "unknown"
+)
+
+// Provider is an identity provider users can login with
+//
+//counterfeiter:generate -o ../mocks/provider.go --fake-name Provider . Provider
+type Provider interface {
+	// ID stored in state and cookie, e.g. google
+	ID() string
+	// Name shown to users, e.g. Google
+	Name() string
+	// RedirectURL configured for the provider, used if the callback url is not derived from the request
+	RedirectURL() string
+	AuthCodeURL(state State, request AuthRequest) string
+	// StepUpSupported returns true if the provider can be asked to authenticate the user again
+	StepUpSupported() bool
+	// UserInfo exchanges the code and returns the user if allowed to login.
+	// redirectURL must match the one of the auth code url, empty uses the configured RedirectURL.
+	UserInfo(ctx context.Context, code Code, redirectURL string) (*UserInfo, error)
+	// RefreshToken returns a new token if token is expired, otherwise token
+	RefreshToken(ctx context.Context, token *oauth2.Token) (*oauth2.Token, error)
+}
+
+// AuthRequest contains the parameters of a single login
+type AuthRequest struct {
+	// LoginHint is the email of the previous login or empty
+	LoginHint string
+	// StepUp asks the provider to authenticate the user again, if supported
+	StepUp StepUp
+	// RedirectURL overrides the configured redirect url, e.g. derived from the request host
+	RedirectURL string
+}
+
+// AuthCodeOptions extend the authorization request of a provider
+type AuthCodeOptions struct {
+	// Scopes requested in addition to the scopes needed for the login,
+	// e.g. https://www.googleapis.com/auth/drive.readonly
+	Scopes []string `yaml:"scopes"`
+	// Prompt of the provider, e.g. consent or select_account
+	Prompt string `yaml:"prompt"`
+	// AccessType offline requests a refresh token from Google
+	AccessType string `yaml:"access_type"`
+	// IncludeGrantedScopes keeps scopes granted by earlier logins (Google incremental authorization)
+	IncludeGrantedScopes bool `yaml:"include_granted_scopes"`
+	// LoginHint pre-fills the account with the email of the previous login
+	LoginHint bool `yaml:"login_hint"`
+	// Params added to the authorization url
+	Params map[string]string `yaml:"params"`
+}
+
+// reservedAuthCodeParams are set by the OAuth flow and can not be overridden by params
+var reservedAuthCodeParams = []string{"client_id", "redirect_uri", "response_type", "scope", "state"}
+
+// Validate the options
+func (a AuthCodeOptions) Validate(ctx context.Context) error {
+	if a.AccessType != "" && a.AccessType != "online" && a.AccessType != "offline" {
+		return errors.Errorf(ctx, "access_type '%s' unknown, expected online or offline", a.AccessType)
+	}
+	for name := range a.Params {
+		if slices.Contains(reservedAuthCodeParams, name) {
+			return errors.Errorf(ctx, "params: '%s' is set by the login and can not be overridden", name)
+		}
+	}
+	return nil
+}
+
+// authCodeOptions returns the url parameters of the options, loginHintParam is the provider specific name of the hint
+func (a AuthCodeOptions) authCodeOptions(loginHintParam string, request AuthRequest) []oauth2.AuthCodeOption {
+	var result []oauth2.AuthCodeOption
+	if request.RedirectURL != "" {
+		result = append(result, oauth2.SetAuthURLParam("redirect_uri", request.RedirectURL))
+	}
+	if a.Prompt != "" {
+		result = append(result, oauth2.SetAuthURLParam("prompt", a.Prompt))
+	}
+	if a.AccessType != "" {
+		result = append(result, oauth2.SetAuthURLParam("access_type", a.AccessType))
+	}
+	if a.IncludeGrantedScopes {
+		result = append(result, oauth2.SetAuthURLParam("include_granted_scopes", "true"))
+	}
+	if a.LoginHint && request.LoginHint != "" {
+		result = append(result, oauth2.SetAuthURLParam(loginHintParam, request.LoginHint))
+	}
+	for name, value := range a.Params {
+		result = append(result, oauth2.SetAuthURLParam(name, value))
+	}
+	return result
+}
+
+// Providers configured, the first one is the default
+type Providers []Provider
+
+// Default provider used for a login without selection
+func (p Providers) Default() Provider {
+	return p[0]
+}
+
+// Find the provider with the given id. An empty id returns the default provider,
+// this keeps states and cookies issued before multiple providers were supported valid.
+func (p Providers) Find(id string) (Provider, bool) {
+	if id == "" {
+		return p.Default(), true
+	}
+	for _, provider := range p {
+		if provider.ID() == id {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// providerRequest runs fn in a span and records its duration
+func providerRequest(ctx context.Context, metrics Metrics, provider string, operation string, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	spanCtx, span := startSpan(ctx, provider+"."+operation, attribute.String("provider", provider))
+	err := fn(spanCtx)
+	endSpan(span, outcomeOf(err), err)
+	metrics.ProviderRequest(provider, operation, time.Since(start), err)
+	return err
+}
+
+// exchangeCode returns the token for the authorization code, errors wrap ErrCodeExchangeFailed.
+// A non empty redirectURL replaces the configured one, it must match the url of the authorization request.
+func exchangeCode(ctx context.Context, httpClient *http.Client, metrics Metrics, provider string, config oauth2.Config, code Code, redirectURL string) (*oauth2.Token, error) {
+	var opts []oauth2.AuthCodeOption
+	if redirectURL != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("redirect_uri", redirectURL))
+	}
+	var token *oauth2.Token
+	err := providerRequest(ctx, metrics, provider, "exchange", func(ctx context.Context) error {
+		var err error
+		token, err = config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, httpClient), code.String(), opts...)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(ctx, fmt.Errorf("%w: %w", ErrCodeExchangeFailed, err), "code exchange failed")
+	}
+	return token, nil
+}
+
+// refreshToken returns a valid token, refreshed with the refresh token if token is expired
+func refreshToken(ctx context.Context, httpClient *http.Client, metrics Metrics, provider string, config oauth2.Config, token *oauth2.Token) (*oauth2.Token, error) {
+	if token.Valid() {
+		return token, nil
+	}
+	var result *oauth2.Token
+	err := providerRequest(ctx, metrics, provider, "refresh", func(ctx context.Context) error {
+		var err error
+		result, err = config.TokenSource(context.WithValue(ctx, oauth2.HTTPClient, httpClient), token).Token()
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "refresh token failed")
+	}
+	return result, nil
+}
+
+// getJSON fetches url with the access token and decodes the JSON response into data.
+// Without token the request is sent as is, e.g. if httpClient authenticates itself.
+func getJSON(ctx context.Context, httpClient *http.Client, token *oauth2.Token, url string, data interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "build request failed")
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != nil {
+		token.SetAuthHeader(req)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "get %s failed", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf(ctx, "get %s failed with status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return errors.Wrapf(ctx, err, "decode json failed")
+	}
+	return nil
+}
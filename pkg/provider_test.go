@@ -0,0 +1,85 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("AuthCodeOptions", func() {
+	var options pkg.AuthCodeOptions
+	var loginHint string
+	var stepUp pkg.StepUp
+	var redirectURL string
+	var query url.Values
+	BeforeEach(func() {
+		options = pkg.AuthCodeOptions{}
+		loginHint = "jdoe@example.com"
+		stepUp = pkg.StepUp{}
+		redirectURL = ""
+	})
+	JustBeforeEach(func() {
+		provider := pkg.NewGoogleOAuth(http.DefaultClient, "id", "secret", "https://gateway.example.com/callback", "example.com", options, &mocks.Metrics{})
+		authCodeURL, err := url.Parse(provider.AuthCodeURL(pkg.State{}, pkg.AuthRequest{LoginHint: loginHint, StepUp: stepUp, RedirectURL: redirectURL}))
+		Expect(err).To(BeNil())
+		query = authCodeURL.Query()
+	})
+	It("requests profile and email only", func() {
+		Expect(query.Get("scope")).To(Equal("openid https://www.googleapis.com/auth/userinfo.profile https://www.googleapis.com/auth/userinfo.email"))
+		Expect(query.Get("hd")).To(Equal("example.com"))
+		Expect(query.Has("prompt")).To(BeFalse())
+		Expect(query.Has("login_hint")).To(BeFalse())
+		Expect(query.Has("max_age")).To(BeFalse())
+		Expect(query.Get("redirect_uri")).To(Equal("https://gateway.example.com/callback"))
+	})
+	Context("redirect url of request", func() {
+		BeforeEach(func() {
+			redirectURL = "https://app1.example.com/callback"
+		})
+		It("replaces configured redirect url", func() {
+			Expect(query.Get("redirect_uri")).To(Equal("https://app1.example.com/callback"))
+		})
+	})
+	Context("step-up", func() {
+		BeforeEach(func() {
+			stepUp = pkg.StepUp{MaxAuthAge: 5 * time.Minute, AcceptedACR: []string{"urn:mfa"}}
+		})
+		It("requests new authentication", func() {
+			Expect(query.Get("max_age")).To(Equal("300"))
+			Expect(query.Get("acr_values")).To(Equal("urn:mfa"))
+		})
+	})
+	Context("all options set", func() {
+		BeforeEach(func() {
+			options = pkg.AuthCodeOptions{
+				Scopes:               []string{"https://www.googleapis.com/auth/drive.readonly"},
+				Prompt:               "consent",
+				AccessType:           "offline",
+				IncludeGrantedScopes: true,
+				LoginHint:            true,
+				Params:               map[string]string{"foo": "bar"},
+			}
+		})
+		It("adds them to the url", func() {
+			Expect(query.Get("scope")).To(HaveSuffix(" https://www.googleapis.com/auth/drive.readonly"))
+			Expect(query.Get("prompt")).To(Equal("consent"))
+			Expect(query.Get("access_type")).To(Equal("offline"))
+			Expect(query.Get("include_granted_scopes")).To(Equal("true"))
+			Expect(query.Get("login_hint")).To(Equal("jdoe@example.com"))
+			Expect(query.Get("foo")).To(Equal("bar"))
+		})
+	})
+	It("validates", func() {
+		ctx := context.Background()
+		Expect(pkg.AuthCodeOptions{AccessType: "offline", Params: map[string]string{"foo": "bar"}}.Validate(ctx)).To(BeNil())
+		Expect(pkg.AuthCodeOptions{AccessType: "banana"}.Validate(ctx)).NotTo(BeNil())
+		Expect(pkg.AuthCodeOptions{Params: map[string]string{"redirect_uri": "https://evil.example.com"}}.Validate(ctx)).NotTo(BeNil())
+	})
+})
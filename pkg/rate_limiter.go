@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+)
+
+// RateLimitConfig throttles login redirects and callbacks per client IP
+type RateLimitConfig struct {
+	// RequestsPerMinute of each client IP, zero disables rate limiting
+	RequestsPerMinute float64 `yaml:"requests_per_minute"`
+	// Burst of requests allowed at once, defaults to RequestsPerMinute
+	Burst int `yaml:"burst"`
+}
+
+// Validate the rate limit config
+func (r RateLimitConfig) Validate(ctx context.Context) error {
+	if r.RequestsPerMinute < 0 {
+		return errors.Errorf(ctx, "requests_per_minute must not be negative")
+	}
+	if r.Burst < 0 {
+		return errors.Errorf(ctx, "burst must not be negative")
+	}
+	return nil
+}
+
+// RateLimiter throttles requests
+//
+//counterfeiter:generate -o ../mocks/rate-limiter.go --fake-name RateLimiter . RateLimiter
+type RateLimiter interface {
+	// Allow returns false and the time to wait if the client of the request exceeded its limit
+	Allow(req *http.Request) (time.Duration, bool)
+}
+
+// RateLimiterFunc allows to use a function as RateLimiter
+type RateLimiterFunc func(req *http.Request) (time.Duration, bool)
+
+// Allow the request
+func (r RateLimiterFunc) Allow(req *http.Request) (time.Duration, bool) {
+	return r(req)
+}
+
+// NewNoRateLimiter allows all requests
+func NewNoRateLimiter() RateLimiter {
+	return RateLimiterFunc(func(req *http.Request) (time.Duration, bool) {
+		return 0, true
+	})
+}
+
+// NewIPRateLimiter returns a token bucket per client IP, refilled with config.RequestsPerMinute.
+// The client IP is resolved by ClientIP.
+func NewIPRateLimiter(config RateLimitConfig) RateLimiter {
+	burst := float64(config.Burst)
+	if burst == 0 {
+		burst = math.Max(1, config.RequestsPerMinute)
+	}
+	return &ipRateLimiter{
+		ratePerSecond: config.RequestsPerMinute / 60,
+		burst:         burst,
+		buckets:       map[string]*tokenBucket{},
+	}
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type ipRateLimiter struct {
+	ratePerSecond float64
+	burst         float64
+
+	mux       sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func (i *ipRateLimiter) Allow(req *http.Request) (time.Duration, bool) {
+	ip := ClientIP(req)
+	now := time.Now()
+
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	i.sweep(now)
+	bucket, ok := i.buckets[ip]
+	if !ok {
+		bucket = &tokenBucket{tokens: i.burst, updated: now}
+		i.buckets[ip] = bucket
+	}
+	bucket.tokens = math.Min(i.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*i.ratePerSecond)
+	bucket.updated = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0, true
+	}
+	glog.V(2).Infof("rate limit of %s exceeded", ip)
+	return time.Duration((1 - bucket.tokens) / i.ratePerSecond * float64(time.Second)), false
+}
+
+// sweep removes full buckets once a minute, must be called with lock held
+func (i *ipRateLimiter) sweep(now time.Time) {
+	if now.Sub(i.lastSweep) < time.Minute {
+		return
+	}
+	i.lastSweep = now
+	refill := time.Duration(i.burst / i.ratePerSecond * float64(time.Second))
+	for ip, bucket := range i.buckets {
+		if now.Sub(bucket.updated) >= refill {
+			delete(i.buckets, ip)
+		}
+	}
+}
+
+// NewRateLimitMiddleware answers requests exceeding the limit of rateLimiter with 429,
+// endpoint labels the throttled requests in the metrics
+func NewRateLimitMiddleware(rateLimiter RateLimiter, metrics Metrics, endpoint string) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			if retryAfter, ok := rateLimiter.Allow(req); !ok {
+				metrics.RateLimited(endpoint)
+				WriteTooManyRequests(resp, retryAfter)
+				return
+			}
+			handler.ServeHTTP(resp, req)
+		})
+	}
+}
+
+// WriteTooManyRequests answers with 429 and the seconds to wait in Retry-After
+func WriteTooManyRequests(resp http.ResponseWriter, retryAfter time.Duration) {
+	resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(resp, "too many requests", http.StatusTooManyRequests)
+}
@@ -0,0 +1,68 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("IPRateLimiter", func() {
+	var rateLimiter pkg.RateLimiter
+	var trustedProxies pkg.TrustedProxies
+	allow := func(remoteAddr string, forwardedFor string) bool {
+		req := httptest.NewRequest(http.MethodGet, "/callback", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		var ok bool
+		pkg.NewTrustedProxyMiddleware(trustedProxies)(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			_, ok = rateLimiter.Allow(req)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		return ok
+	}
+	BeforeEach(func() {
+		var err error
+		trustedProxies, err = pkg.ParseTrustedProxies(context.Background(), []string{"10.0.0.0/8"})
+		Expect(err).To(BeNil())
+		rateLimiter = pkg.NewIPRateLimiter(pkg.RateLimitConfig{RequestsPerMinute: 1, Burst: 2})
+	})
+	It("allows burst and throttles afterwards", func() {
+		Expect(allow("192.0.2.1:1234", "")).To(BeTrue())
+		Expect(allow("192.0.2.1:1234", "")).To(BeTrue())
+		req := httptest.NewRequest(http.MethodGet, "/callback", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		retryAfter, ok := rateLimiter.Allow(req)
+		Expect(ok).To(BeFalse())
+		Expect(retryAfter.Seconds()).To(BeNumerically("~", 60, 1))
+	})
+	It("limits clients behind trusted proxy separately", func() {
+		Expect(allow("10.0.0.1:1234", "192.0.2.1")).To(BeTrue())
+		Expect(allow("10.0.0.1:1234", "192.0.2.1")).To(BeTrue())
+		Expect(allow("10.0.0.1:1234", "192.0.2.2")).To(BeTrue())
+		Expect(allow("10.0.0.2:1234", "192.0.2.1")).To(BeFalse())
+	})
+	It("ignores X-Forwarded-For of untrusted clients", func() {
+		Expect(allow("192.0.2.1:1234", "198.51.100.1")).To(BeTrue())
+		Expect(allow("192.0.2.1:1234", "198.51.100.1")).To(BeTrue())
+		Expect(allow("192.0.2.1:1234", "198.51.100.2")).To(BeFalse())
+	})
+})
+
+var _ = Describe("RateLimitMiddleware", func() {
+	It("counts throttled requests", func() {
+		rateLimiter := &mocks.RateLimiter{}
+		rateLimiter.AllowReturns(0, false)
+		metrics := &mocks.Metrics{}
+		recorder := httptest.NewRecorder()
+		pkg.NewRateLimitMiddleware(rateLimiter, metrics, "callback")(http.NotFoundHandler()).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/callback", nil))
+		Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(metrics.RateLimitedArgsForCall(0)).To(Equal("callback"))
+	})
+})
@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+)
+
+// ReadinessCheck returns an error if a dependency needed to complete logins is not available
+//
+//counterfeiter:generate -o ../mocks/readiness-check.go --fake-name ReadinessCheck . ReadinessCheck
+type ReadinessCheck interface {
+	Check(ctx context.Context) error
+}
+
+// ReadinessCheckFunc allows to use a function as ReadinessCheck
+type ReadinessCheckFunc func(ctx context.Context) error
+
+// Check calls the function
+func (r ReadinessCheckFunc) Check(ctx context.Context) error {
+	return r(ctx)
+}
+
+// ReadinessChecks by name
+type ReadinessChecks map[string]ReadinessCheck
+
+// NewSigningKeyReadinessCheck fails if no signing key is configured
+func NewSigningKeyReadinessCheck(signingKey []byte) ReadinessCheck {
+	return ReadinessCheckFunc(func(ctx context.Context) error {
+		if len(signingKey) == 0 {
+			return errors.Errorf(ctx, "signing key missing")
+		}
+		return nil
+	})
+}
+
+// ProviderReadinessURLs are fetched to check if a provider is reachable
+var ProviderReadinessURLs = map[string]string{
+	ProviderGoogle: "https://accounts.google.com/.well-known/openid-configuration",
+	ProviderGitHub: "https://api.github.com/meta",
+}
+
+// NewProviderReadinessCheck fails if url can not be fetched. A successful check is reused for maxAge,
+// this keeps frequent readiness probes below the rate limits of the provider.
+func NewProviderReadinessCheck(httpClient *http.Client, url string, maxAge time.Duration) ReadinessCheck {
+	return &providerReadinessCheck{
+		httpClient: httpClient,
+		url:        url,
+		maxAge:     maxAge,
+	}
+}
+
+type providerReadinessCheck struct {
+	httpClient *http.Client
+	url        string
+	maxAge     time.Duration
+
+	mux       sync.Mutex
+	checkedAt time.Time
+}
+
+func (p *providerReadinessCheck) Check(ctx context.Context) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if !p.checkedAt.IsZero() && time.Since(p.checkedAt) < p.maxAge {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "build request failed")
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "get %s failed", p.url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf(ctx, "get %s failed with status %d", p.url, resp.StatusCode)
+	}
+	p.checkedAt = time.Now()
+	return nil
+}
+
+// ReadinessStatus is the result of a single check
+type ReadinessStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ReadinessResponse is returned by the readiness handler
+type ReadinessResponse struct {
+	Status string                     `json:"status"`
+	Checks map[string]ReadinessStatus `json:"checks"`
+}
+
+const (
+	readinessStatusOK   = "ok"
+	readinessStatusFail = "fail"
+)
+
+// NewReadinessHandler runs all checks and answers 200 if all passed, otherwise 503.
+// The status of each check is reported as JSON.
+func NewReadinessHandler(checks ReadinessChecks, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+
+		names := make([]string, 0, len(checks))
+		for name := range checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		response := ReadinessResponse{
+			Status: readinessStatusOK,
+			Checks: make(map[string]ReadinessStatus, len(checks)),
+		}
+		for _, name := range names {
+			if err := checks[name].Check(ctx); err != nil {
+				glog.V(1).Infof("readiness check %s failed: %v", name, err)
+				response.Status = readinessStatusFail
+				response.Checks[name] = ReadinessStatus{Status: readinessStatusFail, Error: err.Error()}
+				continue
+			}
+			response.Checks[name] = ReadinessStatus{Status: readinessStatusOK}
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		if response.Status != readinessStatusOK {
+			resp.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(resp).Encode(response); err != nil {
+			glog.Warningf("write readiness response failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package pkg_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("ReadinessHandler", func() {
+	var sessionStore *mocks.SessionStore
+	var recorder *httptest.ResponseRecorder
+	var signingKey []byte
+	var response pkg.ReadinessResponse
+	BeforeEach(func() {
+		sessionStore = &mocks.SessionStore{}
+		recorder = httptest.NewRecorder()
+		signingKey = []byte("secret")
+	})
+	JustBeforeEach(func() {
+		handler := pkg.NewReadinessHandler(pkg.ReadinessChecks{
+			"signing_key":   pkg.NewSigningKeyReadinessCheck(signingKey),
+			"session_store": sessionStore,
+		}, time.Second)
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readiness", nil))
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(BeNil())
+	})
+	It("returns ok", func() {
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(response.Status).To(Equal("ok"))
+		Expect(response.Checks).To(HaveKeyWithValue("session_store", pkg.ReadinessStatus{Status: "ok"}))
+	})
+	Context("signing key missing", func() {
+		BeforeEach(func() {
+			signingKey = nil
+		})
+		It("returns unavailable", func() {
+			Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(response.Status).To(Equal("fail"))
+			Expect(response.Checks["signing_key"].Status).To(Equal("fail"))
+			Expect(response.Checks["session_store"].Status).To(Equal("ok"))
+		})
+	})
+	Context("session store unreachable", func() {
+		BeforeEach(func() {
+			sessionStore.CheckReturns(context.DeadlineExceeded)
+		})
+		It("reports error", func() {
+			Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(response.Checks["session_store"].Error).To(ContainSubstring("deadline"))
+		})
+	})
+})
+
+var _ = Describe("ProviderReadinessCheck", func() {
+	var ctx context.Context
+	var server *httptest.Server
+	var status int
+	var requests int
+	var check pkg.ReadinessCheck
+	BeforeEach(func() {
+		ctx = context.Background()
+		status = http.StatusOK
+		requests = 0
+		server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			requests++
+			resp.WriteHeader(status)
+		}))
+		check = pkg.NewProviderReadinessCheck(server.Client(), server.URL+"/.well-known/openid-configuration", time.Minute)
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	It("returns nil if provider is reachable", func() {
+		Expect(check.Check(ctx)).To(BeNil())
+	})
+	It("reuses successful check", func() {
+		Expect(check.Check(ctx)).To(BeNil())
+		Expect(check.Check(ctx)).To(BeNil())
+		Expect(requests).To(Equal(1))
+	})
+	Context("provider fails", func() {
+		BeforeEach(func() {
+			status = http.StatusServiceUnavailable
+		})
+		It("returns error", func() {
+			Expect(check.Check(ctx)).To(MatchError(ContainSubstring("status 503")))
+		})
+		It("checks again", func() {
+			Expect(check.Check(ctx)).NotTo(BeNil())
+			Expect(check.Check(ctx)).NotTo(BeNil())
+			Expect(requests).To(Equal(2))
+		})
+	})
+	Context("provider unreachable", func() {
+		BeforeEach(func() {
+			server.Close()
+		})
+		It("returns error", func() {
+			Expect(check.Check(ctx)).NotTo(BeNil())
+		})
+	})
+})
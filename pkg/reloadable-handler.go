@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/bborbe/errors"
+)
+
+// HandlerFactory creates the handler serving requests for a config
+type HandlerFactory func(ctx context.Context, config Config) (http.Handler, error)
+
+// ReloadableHandler serves requests with the handler of the last applied config
+//
+//counterfeiter:generate -o ../mocks/reloadable-handler.go --fake-name ReloadableHandler . ReloadableHandler
+type ReloadableHandler interface {
+	http.Handler
+	// Apply creates a handler for config and switches to it. On error the current handler is kept.
+	Apply(ctx context.Context, config Config) error
+	// Config currently applied
+	Config() Config
+}
+
+// NewReloadableHandler returns a handler answering 503 until the first config is applied.
+// Requests in flight complete with the handler they started with.
+func NewReloadableHandler(factory HandlerFactory) ReloadableHandler {
+	return &reloadableHandler{
+		factory: factory,
+	}
+}
+
+type reloadableHandler struct {
+	factory HandlerFactory
+	current atomic.Pointer[appliedConfig]
+}
+
+type appliedConfig struct {
+	config  Config
+	handler http.Handler
+}
+
+func (r *reloadableHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	current := r.current.Load()
+	if current == nil {
+		http.Error(resp, "config not loaded", http.StatusServiceUnavailable)
+		return
+	}
+	current.handler.ServeHTTP(resp, req)
+}
+
+func (r *reloadableHandler) Apply(ctx context.Context, config Config) error {
+	if err := config.Validate(ctx); err != nil {
+		return errors.Wrapf(ctx, err, "validate config failed")
+	}
+	handler, err := r.factory(ctx, config)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "create handler failed")
+	}
+	r.current.Store(&appliedConfig{
+		config:  config,
+		handler: handler,
+	})
+	return nil
+}
+
+func (r *reloadableHandler) Config() Config {
+	current := r.current.Load()
+	if current == nil {
+		return Config{}
+	}
+	return current.config
+}
@@ -0,0 +1,92 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("ReloadableHandler", func() {
+	var ctx context.Context
+	var handler pkg.ReloadableHandler
+	var config pkg.Config
+	BeforeEach(func() {
+		ctx = context.Background()
+		handler = pkg.NewReloadableHandler(func(ctx context.Context, config pkg.Config) (http.Handler, error) {
+			return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				_, _ = resp.Write([]byte(config.SigningKey))
+			}), nil
+		})
+		config = pkg.Config{
+			SigningKey: "first",
+			Providers: []pkg.ProviderConfig{
+				{ID: pkg.ProviderGoogle, ClientID: "id", ClientSecret: "secret", RedirectURL: "https://gateway.example.com/callback"},
+			},
+		}
+	})
+	serve := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		return recorder
+	}
+	It("is unavailable before the first config", func() {
+		Expect(serve().Code).To(Equal(http.StatusServiceUnavailable))
+	})
+	It("switches to applied config", func() {
+		Expect(handler.Apply(ctx, config)).To(BeNil())
+		Expect(serve().Body.String()).To(Equal("first"))
+		config.SigningKey = "second"
+		Expect(handler.Apply(ctx, config)).To(BeNil())
+		Expect(serve().Body.String()).To(Equal("second"))
+		Expect(handler.Config().SigningKey).To(Equal("second"))
+	})
+	It("keeps running config if new config is invalid", func() {
+		Expect(handler.Apply(ctx, config)).To(BeNil())
+		Expect(handler.Apply(ctx, pkg.Config{})).NotTo(BeNil())
+		Expect(serve().Body.String()).To(Equal("first"))
+	})
+	Context("ConfigWatcher", func() {
+		var path string
+		var cancel context.CancelFunc
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "config.yaml")
+			Expect(os.WriteFile(path, []byte("signing_key: first\n"), 0600)).To(BeNil())
+			Expect(handler.Apply(ctx, config)).To(BeNil())
+			var watchCtx context.Context
+			watchCtx, cancel = context.WithCancel(ctx)
+			watcher := pkg.NewConfigWatcher(path, 10*time.Millisecond, func(ctx context.Context) (pkg.Config, error) {
+				fileConfig, err := pkg.ReadConfig(ctx, path)
+				if err != nil {
+					return pkg.Config{}, err
+				}
+				fileConfig.Providers = config.Providers
+				return fileConfig, nil
+			}, handler)
+			go func() {
+				defer GinkgoRecover()
+				Expect(watcher.Run(watchCtx)).To(BeNil())
+			}()
+		})
+		AfterEach(func() {
+			cancel()
+		})
+		It("applies changed file", func() {
+			time.Sleep(20 * time.Millisecond)
+			Expect(os.WriteFile(path, []byte("signing_key: second\n"), 0600)).To(BeNil())
+			Eventually(func() string { return serve().Body.String() }).Should(Equal("second"))
+		})
+		It("ignores invalid file", func() {
+			time.Sleep(20 * time.Millisecond)
+			Expect(os.WriteFile(path, []byte("signing_key: [\n"), 0600)).To(BeNil())
+			Consistently(func() string { return serve().Body.String() }, 100*time.Millisecond).Should(Equal("first"))
+		})
+	})
+})
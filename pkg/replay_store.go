@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ReplayStore remembers used one-time codes until they expire
+//
+//counterfeiter:generate -o ../mocks/replay-store.go --fake-name ReplayStore . ReplayStore
+type ReplayStore interface {
+	// Use marks the code with id as used until expiresAt, false if it was used before
+	Use(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
+
+// NewMemoryReplayStore returns a ReplayStore of a single instance, it forgets used codes on restart.
+// Replicas do not share it, each of them accepts a code once.
+func NewMemoryReplayStore() ReplayStore {
+	return &memoryReplayStore{
+		used: map[string]time.Time{},
+	}
+}
+
+type memoryReplayStore struct {
+	mux  sync.Mutex
+	used map[string]time.Time
+}
+
+func (m *memoryReplayStore) Use(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	now := time.Now()
+	for usedID, usedExpiresAt := range m.used {
+		if now.After(usedExpiresAt) {
+			delete(m.used, usedID)
+		}
+	}
+	if _, ok := m.used[id]; ok {
+		return false, nil
+	}
+	m.used[id] = expiresAt
+	return true, nil
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequestClassifier decides how to answer an unauthenticated request
+//
+//counterfeiter:generate -o ../mocks/request-classifier.go --fake-name RequestClassifier . RequestClassifier
+type RequestClassifier interface {
+	// IsNavigational returns true if the request is a browser navigation that can follow a login redirect
+	IsNavigational(req *http.Request) bool
+}
+
+// NewRequestClassifier treats requests as non navigational if they are not GET or HEAD,
+// do not accept text/html, are sent by XHR or fetch, carry an Authorization or API key
+// header or match one of the given path prefixes.
+func NewRequestClassifier(apiPathPrefixes []string) RequestClassifier {
+	return &requestClassifier{
+		apiPathPrefixes: apiPathPrefixes,
+	}
+}
+
+type requestClassifier struct {
+	apiPathPrefixes []string
+}
+
+func (r *requestClassifier) IsNavigational(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.Header.Get("X-Requested-With") != "" || req.Header.Get("Authorization") != "" || req.Header.Get(APIKeyHeaderName) != "" {
+		return false
+	}
+	if mode := req.Header.Get("Sec-Fetch-Mode"); mode != "" && mode != "navigate" {
+		return false
+	}
+	if !strings.Contains(req.Header.Get("Accept"), "text/html") {
+		return false
+	}
+	for _, prefix := range r.apiPathPrefixes {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
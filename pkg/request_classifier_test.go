@@ -0,0 +1,45 @@
+package pkg_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("RequestClassifier", func() {
+	var requestClassifier = pkg.NewRequestClassifier([]string{"/api/"})
+	var req *http.Request
+	BeforeEach(func() {
+		var err error
+		req, err = http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+		Expect(err).To(BeNil())
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+		req.Header.Set("Sec-Fetch-Mode", "navigate")
+	})
+	It("accepts browser navigation", func() {
+		Expect(requestClassifier.IsNavigational(req)).To(BeTrue())
+	})
+	It("rejects requests not accepting html", func() {
+		req.Header.Set("Accept", "*/*")
+		Expect(requestClassifier.IsNavigational(req)).To(BeFalse())
+	})
+	It("rejects xhr", func() {
+		req.Header.Set("X-Requested-With", "XMLHttpRequest")
+		Expect(requestClassifier.IsNavigational(req)).To(BeFalse())
+	})
+	It("rejects fetch", func() {
+		req.Header.Set("Sec-Fetch-Mode", "cors")
+		Expect(requestClassifier.IsNavigational(req)).To(BeFalse())
+	})
+	It("rejects post", func() {
+		req.Method = http.MethodPost
+		Expect(requestClassifier.IsNavigational(req)).To(BeFalse())
+	})
+	It("rejects api path prefix", func() {
+		req.URL.Path = "/api/users"
+		Expect(requestClassifier.IsNavigational(req)).To(BeFalse())
+	})
+})
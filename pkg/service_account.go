@@ -0,0 +1,176 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+)
+
+// APIKeyHeaderName is the header service accounts send their API key in
+const APIKeyHeaderName = "X-API-Key"
+
+const apiKeyHashPrefix = "sha256:"
+
+// ServiceAccount allows non-human clients to authenticate with a static API key
+type ServiceAccount struct {
+	Name string `json:"name"`
+	// KeyHash of the API key, see HashAPIKey
+	KeyHash string `json:"key_hash"`
+	// AllowedPaths restricts the account to the given path prefixes matching whole path segments, empty allows all
+	AllowedPaths []string `json:"allowed_paths,omitempty"`
+	// Expires the account at the given time, zero never expires
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// User is the synthetic identity of the service account
+func (s ServiceAccount) User() string {
+	return "service-account:" + s.Name
+}
+
+// Validate the service account
+func (s ServiceAccount) Validate(ctx context.Context) error {
+	if s.Name == "" {
+		return errors.Errorf(ctx, "name missing")
+	}
+	if !strings.HasPrefix(s.KeyHash, apiKeyHashPrefix) {
+		return errors.Errorf(ctx, "key_hash of '%s' must start with '%s'", s.Name, apiKeyHashPrefix)
+	}
+	if _, err := hex.DecodeString(strings.TrimPrefix(s.KeyHash, apiKeyHashPrefix)); err != nil {
+		return errors.Wrapf(ctx, err, "key_hash of '%s' is not hex", s.Name)
+	}
+	return nil
+}
+
+// ServiceAccounts is a list of service accounts
+type ServiceAccounts []ServiceAccount
+
+// Validate all service accounts and ensure names are unique
+func (s ServiceAccounts) Validate(ctx context.Context) error {
+	names := map[string]bool{}
+	for i, account := range s {
+		if err := account.Validate(ctx); err != nil {
+			return errors.Wrapf(ctx, err, "service account %d invalid", i)
+		}
+		if names[account.Name] {
+			return errors.Errorf(ctx, "service account '%s' defined twice", account.Name)
+		}
+		names[account.Name] = true
+	}
+	return nil
+}
+
+// ReadServiceAccounts from the given JSON file
+func ReadServiceAccounts(ctx context.Context, path string) (ServiceAccounts, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "read service accounts file '%s' failed", path)
+	}
+	var accounts ServiceAccounts
+	if err := json.Unmarshal(content, &accounts); err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse service accounts file '%s' failed", path)
+	}
+	if err := accounts.Validate(ctx); err != nil {
+		return nil, errors.Wrapf(ctx, err, "validate service accounts file '%s' failed", path)
+	}
+	return accounts, nil
+}
+
+// HashAPIKey returns the hash of an API key as stored in ServiceAccount.KeyHash
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return apiKeyHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// GenerateAPIKey returns a new random API key
+func GenerateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// ServiceAccountAuthenticator authenticates service accounts by API key
+//
+//counterfeiter:generate -o ../mocks/service-account-authenticator.go --fake-name ServiceAccountAuthenticator . ServiceAccountAuthenticator
+type ServiceAccountAuthenticator interface {
+	Authenticator
+	// LastUsed returns when the service account was last authenticated successfully
+	LastUsed(name string) (time.Time, bool)
+}
+
+// NewServiceAccountAuthenticator accepts the API key in the X-API-Key header
+// or as password of Basic auth with the account name as user.
+func NewServiceAccountAuthenticator(accounts ServiceAccounts) ServiceAccountAuthenticator {
+	return &serviceAccountAuthenticator{
+		accounts: accounts,
+		lastUsed: map[string]time.Time{},
+	}
+}
+
+type serviceAccountAuthenticator struct {
+	accounts ServiceAccounts
+
+	mux      sync.Mutex
+	lastUsed map[string]time.Time
+}
+
+func (s *serviceAccountAuthenticator) Authenticate(ctx context.Context, req *http.Request) (*Identity, error) {
+	name, key, ok := req.BasicAuth()
+	if !ok {
+		key = req.Header.Get(APIKeyHeaderName)
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	account, ok := s.find(name, HashAPIKey(key))
+	if !ok {
+		return nil, errors.Errorf(ctx, "invalid api key")
+	}
+	if !account.Expires.IsZero() && time.Now().After(account.Expires) {
+		return nil, errors.Errorf(ctx, "service account '%s' expired", account.Name)
+	}
+	if !hasPathPrefix(req.URL.Path, account.AllowedPaths) {
+		return nil, errors.Errorf(ctx, "service account '%s' not allowed to access '%s'", account.Name, req.URL.Path)
+	}
+	s.mux.Lock()
+	s.lastUsed[account.Name] = time.Now()
+	s.mux.Unlock()
+	glog.V(2).Infof("service account %s authenticated", account.Name)
+	return &Identity{
+		User: account.User(),
+	}, nil
+}
+
+// find compares the hash against all accounts in constant time
+func (s *serviceAccountAuthenticator) find(name string, hash string) (ServiceAccount, bool) {
+	var result ServiceAccount
+	var found bool
+	for _, account := range s.accounts {
+		match := subtle.ConstantTimeCompare([]byte(account.KeyHash), []byte(hash)) == 1
+		if match && (name == "" || name == account.Name) && !found {
+			result = account
+			found = true
+		}
+	}
+	return result, found
+}
+
+func (s *serviceAccountAuthenticator) LastUsed(name string) (time.Time, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	lastUsed, ok := s.lastUsed[name]
+	return lastUsed, ok
+}
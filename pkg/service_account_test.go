@@ -0,0 +1,121 @@
+package pkg_test
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("ServiceAccountAuthenticator", func() {
+	var ctx context.Context
+	var req *http.Request
+	var accounts pkg.ServiceAccounts
+	var authenticator pkg.ServiceAccountAuthenticator
+	var identity *pkg.Identity
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		req, err = http.NewRequest(http.MethodGet, "http://example.com/api/jobs", nil)
+		Expect(err).To(BeNil())
+		accounts = pkg.ServiceAccounts{
+			{
+				Name:         "ci",
+				KeyHash:      pkg.HashAPIKey("secret"),
+				AllowedPaths: []string{"/api/"},
+			},
+		}
+	})
+	JustBeforeEach(func() {
+		authenticator = pkg.NewServiceAccountAuthenticator(accounts)
+		identity, err = authenticator.Authenticate(ctx, req)
+	})
+	Context("without key", func() {
+		It("returns ErrNoCredentials", func() {
+			Expect(stderrors.Is(err, pkg.ErrNoCredentials)).To(BeTrue())
+		})
+	})
+	Context("with api key header", func() {
+		BeforeEach(func() {
+			req.Header.Set(pkg.APIKeyHeaderName, "secret")
+		})
+		It("returns synthetic identity", func() {
+			Expect(err).To(BeNil())
+			Expect(identity.User).To(Equal("service-account:ci"))
+		})
+		It("tracks last used", func() {
+			lastUsed, ok := authenticator.LastUsed("ci")
+			Expect(ok).To(BeTrue())
+			Expect(lastUsed).To(BeTemporally("~", time.Now(), time.Second))
+		})
+	})
+	Context("with basic auth", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("ci", "secret")
+		})
+		It("returns synthetic identity", func() {
+			Expect(err).To(BeNil())
+			Expect(identity.User).To(Equal("service-account:ci"))
+		})
+	})
+	Context("with basic auth of other account", func() {
+		BeforeEach(func() {
+			req.SetBasicAuth("other", "secret")
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("with wrong key", func() {
+		BeforeEach(func() {
+			req.Header.Set(pkg.APIKeyHeaderName, "wrong")
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+			Expect(stderrors.Is(err, pkg.ErrNoCredentials)).To(BeFalse())
+		})
+	})
+	Context("with path not allowed", func() {
+		BeforeEach(func() {
+			req.Header.Set(pkg.APIKeyHeaderName, "secret")
+			req.URL.Path = "/admin"
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("with path sharing the prefix", func() {
+		BeforeEach(func() {
+			req.Header.Set(pkg.APIKeyHeaderName, "secret")
+			accounts[0].AllowedPaths = []string{"/api"}
+			req.URL.Path = "/apifoo"
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("with path below prefix without trailing slash", func() {
+		BeforeEach(func() {
+			req.Header.Set(pkg.APIKeyHeaderName, "secret")
+			accounts[0].AllowedPaths = []string{"/api"}
+		})
+		It("returns synthetic identity", func() {
+			Expect(err).To(BeNil())
+			Expect(identity.User).To(Equal("service-account:ci"))
+		})
+	})
+	Context("expired", func() {
+		BeforeEach(func() {
+			req.Header.Set(pkg.APIKeyHeaderName, "secret")
+			accounts[0].Expires = time.Now().Add(-time.Minute)
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
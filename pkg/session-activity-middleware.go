@@ -0,0 +1,24 @@
+package pkg
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// NewSessionActivityMiddleware records the last request of the session of the identity,
+// see SessionStore.TouchSession. It must run after the login middleware.
+func NewSessionActivityMiddleware(sessionStore SessionStore) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			if identity, ok := IdentityFromContext(ctx); ok && identity.SessionID != "" {
+				if err := sessionStore.TouchSession(ctx, identity.SessionID, time.Now()); err != nil {
+					glog.V(3).Infof("touch session %s failed: %v", identity.SessionID, err)
+				}
+			}
+			handler.ServeHTTP(resp, req)
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+	"golang.org/x/oauth2"
+)
+
+// AccessToken is a personal access token a user created to authenticate CLI clients
+type AccessToken struct {
+	ID           string    `json:"id"`
+	User         string    `json:"user"`
+	Name         string    `json:"name"`
+	Hash         string    `json:"hash"`
+	PathPrefixes []string  `json:"path_prefixes,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	// Provider the user logged in with when creating the token
+	Provider string `json:"provider,omitempty"`
+}
+
+// Expired returns true if the token is no longer valid at the given time
+func (a AccessToken) Expired(now time.Time) bool {
+	return !a.ExpiresAt.IsZero() && now.After(a.ExpiresAt)
+}
+
+// Session of a user created by a successful login, identified by the cookie ID (jti)
+type Session struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user"`
+	Provider  string    `json:"provider"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Subject of the user at the provider, the sub claim of the ID token
+	Subject string `json:"subject,omitempty"`
+	// ProviderSessionID of the login at the provider, the sid claim of the ID token
+	ProviderSessionID string `json:"provider_session_id,omitempty"`
+	// IP and UserAgent of the login
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	// LastSeen is the time of the last request of the session, updated every sessionTouchInterval
+	LastSeen time.Time `json:"last_seen,omitempty"`
+	// Token issued by the provider at login, refreshed by UpstreamTokens
+	Token *oauth2.Token `json:"token,omitempty"`
+}
+
+// SessionStore holds server side state of users
+//
+//counterfeiter:generate -o ../mocks/session-store.go --fake-name SessionStore . SessionStore
+type SessionStore interface {
+	SaveSession(ctx context.Context, session Session) error
+	// Session returns ErrNotFound if the session does not exist or is expired
+	Session(ctx context.Context, id string) (*Session, error)
+	// Sessions not expired yet
+	Sessions(ctx context.Context) ([]Session, error)
+	// DeleteSession revokes the session, the cookie of the session is no longer accepted
+	DeleteSession(ctx context.Context, id string) error
+	// SessionRevoked returns true if the session was deleted before it expired
+	SessionRevoked(ctx context.Context, id string) (bool, error)
+	// TouchSession records a request of the session at now, returns ErrNotFound if the session does not exist
+	TouchSession(ctx context.Context, id string, now time.Time) error
+	SaveAccessToken(ctx context.Context, token AccessToken) error
+	// AccessTokens of the given user ordered by creation
+	AccessTokens(ctx context.Context, user string) ([]AccessToken, error)
+	// AccessTokenByHash returns ErrNotFound if no token with the hash exists
+	AccessTokenByHash(ctx context.Context, hash string) (*AccessToken, error)
+	DeleteAccessToken(ctx context.Context, user string, id string) error
+	// Check returns an error if the store can not persist changes
+	Check(ctx context.Context) error
+}
+
+// ErrNotFound is returned if a requested entry does not exist
+var ErrNotFound = stderrors.New("not found")
+
+// sessionTouchInterval limits how often the last seen time of a session is written
+const sessionTouchInterval = time.Minute
+
+// NewMemorySessionStore returns a SessionStore that loses its content on restart
+func NewMemorySessionStore() SessionStore {
+	return &sessionStore{
+		data: newSessionStoreData(),
+	}
+}
+
+// NewFileSessionStore returns a SessionStore that persists its content as JSON to the given path
+func NewFileSessionStore(ctx context.Context, path string) (SessionStore, error) {
+	store := &sessionStore{
+		path: path,
+		data: newSessionStoreData(),
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, errors.Wrapf(ctx, err, "read session store '%s' failed", path)
+	}
+	if err := json.Unmarshal(content, &store.data); err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse session store '%s' failed", path)
+	}
+	if store.data.Sessions == nil {
+		store.data.Sessions = map[string]Session{}
+	}
+	if store.data.AccessTokens == nil {
+		store.data.AccessTokens = map[string]AccessToken{}
+	}
+	if store.data.RevokedSessions == nil {
+		store.data.RevokedSessions = map[string]time.Time{}
+	}
+	return store, nil
+}
+
+type sessionStoreData struct {
+	Sessions     map[string]Session     `json:"sessions"`
+	AccessTokens map[string]AccessToken `json:"access_tokens"`
+	// RevokedSessions by id with the expiry of the session
+	RevokedSessions map[string]time.Time `json:"revoked_sessions,omitempty"`
+}
+
+func newSessionStoreData() sessionStoreData {
+	return sessionStoreData{
+		Sessions:        map[string]Session{},
+		AccessTokens:    map[string]AccessToken{},
+		RevokedSessions: map[string]time.Time{},
+	}
+}
+
+type sessionStore struct {
+	path string
+
+	mux  sync.Mutex
+	data sessionStoreData
+}
+
+func (s *sessionStore) SaveSession(ctx context.Context, session Session) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.removeExpiredSessions(time.Now())
+	s.data.Sessions[session.ID] = session
+	return s.persist(ctx)
+}
+
+func (s *sessionStore) Sessions(ctx context.Context) ([]Session, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	now := time.Now()
+	result := make([]Session, 0, len(s.data.Sessions))
+	for _, session := range s.data.Sessions {
+		if now.Before(session.ExpiresAt) {
+			result = append(result, session)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (s *sessionStore) Session(ctx context.Context, id string) (*Session, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	session, ok := s.data.Sessions[id]
+	if !ok || !time.Now().Before(session.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return &session, nil
+}
+
+func (s *sessionStore) DeleteSession(ctx context.Context, id string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	session, ok := s.data.Sessions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.data.Sessions, id)
+	s.data.RevokedSessions[id] = session.ExpiresAt
+	return s.persist(ctx)
+}
+
+func (s *sessionStore) TouchSession(ctx context.Context, id string, now time.Time) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	session, ok := s.data.Sessions[id]
+	if !ok || !now.Before(session.ExpiresAt) {
+		return ErrNotFound
+	}
+	if now.Sub(session.LastSeen) < sessionTouchInterval {
+		return nil
+	}
+	session.LastSeen = now
+	s.data.Sessions[id] = session
+	return s.persist(ctx)
+}
+
+func (s *sessionStore) SessionRevoked(ctx context.Context, id string) (bool, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	expiresAt, ok := s.data.RevokedSessions[id]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+// removeExpiredSessions must be called with lock held
+func (s *sessionStore) removeExpiredSessions(now time.Time) {
+	for id, session := range s.data.Sessions {
+		if !now.Before(session.ExpiresAt) {
+			delete(s.data.Sessions, id)
+		}
+	}
+	for id, expiresAt := range s.data.RevokedSessions {
+		if !now.Before(expiresAt) {
+			delete(s.data.RevokedSessions, id)
+		}
+	}
+}
+
+func (s *sessionStore) SaveAccessToken(ctx context.Context, token AccessToken) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.data.AccessTokens[token.ID] = token
+	return s.persist(ctx)
+}
+
+func (s *sessionStore) AccessTokens(ctx context.Context, user string) ([]AccessToken, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	var result []AccessToken
+	for _, token := range s.data.AccessTokens {
+		if token.User == user {
+			result = append(result, token)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (s *sessionStore) AccessTokenByHash(ctx context.Context, hash string) (*AccessToken, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, token := range s.data.AccessTokens {
+		if token.Hash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *sessionStore) DeleteAccessToken(ctx context.Context, user string, id string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	token, ok := s.data.AccessTokens[id]
+	if !ok || token.User != user {
+		return ErrNotFound
+	}
+	delete(s.data.AccessTokens, id)
+	return s.persist(ctx)
+}
+
+func (s *sessionStore) Check(ctx context.Context) error {
+	if s.path == "" {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return errors.Wrapf(ctx, err, "session store directory not writable")
+	}
+	tmp.Close()
+	if err := os.Remove(tmp.Name()); err != nil {
+		return errors.Wrapf(ctx, err, "remove temp file failed")
+	}
+	return nil
+}
+
+// persist writes the data atomically to path, must be called with lock held
+func (s *sessionStore) persist(ctx context.Context) error {
+	if s.path == "" {
+		return nil
+	}
+	content, err := json.Marshal(s.data)
+	if err != nil {
+		return errors.Wrapf(ctx, err, "marshal session store failed")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return errors.Wrapf(ctx, err, "create temp file failed")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return errors.Wrapf(ctx, err, "write session store failed")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(ctx, err, "close session store failed")
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.Wrapf(ctx, err, "rename session store failed")
+	}
+	glog.V(4).Infof("session store persisted to %s", s.path)
+	return nil
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	libhttp "github.com/bborbe/http"
+)
+
+// SignInPath serves the sign-in page if enabled
+const SignInPath = "/sign-in"
+
+// NewSignInHandler renders a page with a button per provider. A click starts the login
+// with the selected provider and returns to the origin passed in the rd parameter.
+// If skipSingleProvider is set and only one provider exists the login starts immediately.
+func NewSignInHandler(
+	stateGenerator StateGenerator,
+	providers Providers,
+	metrics Metrics,
+	auditLogger AuditLogger,
+	templates Templates,
+	skipSingleProvider bool,
+	callbackHosts CallbackHosts,
+) libhttp.WithError {
+	return libhttp.WithErrorFunc(func(ctx context.Context, resp http.ResponseWriter, req *http.Request) error {
+		origin := SafeOrigin(req.URL.Query().Get("rd"), RequestHost(req))
+		providerID := req.URL.Query().Get("provider")
+		if providerID == "" && skipSingleProvider && len(providers) == 1 {
+			providerID = providers.Default().ID()
+		}
+		if providerID != "" {
+			provider, ok := providers.Find(providerID)
+			if !ok {
+				http.Error(resp, "unknown provider", http.StatusBadRequest)
+				return nil
+			}
+			return startLogin(ctx, resp, req, stateGenerator, provider, callbackHosts, metrics, auditLogger, origin, nil)
+		}
+
+		page := SignInPage{
+			Origin: origin,
+		}
+		for _, provider := range providers {
+			page.Providers = append(page.Providers, SignInProvider{
+				Name: provider.Name(),
+				URL:  req.URL.Path + "?" + url.Values{"provider": {provider.ID()}, "rd": {origin}}.Encode(),
+			})
+		}
+		return templates.Render(ctx, resp, http.StatusOK, TemplateSignIn, "Sign in", page)
+	})
+}
+
+// SafeOrigin returns origin if it is a path or an url on host, otherwise "/".
+// This prevents the sign-in page from redirecting to other sites.
+func SafeOrigin(origin string, host string) string {
+	// browsers remove tabs and newlines from urls, /\t/evil.com is opened as //evil.com
+	if strings.ContainsFunc(origin, unicode.IsControl) {
+		return "/"
+	}
+	if strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://") {
+		originURL, err := url.Parse(origin)
+		if err != nil || originURL.Host != host || originURL.User != nil {
+			return "/"
+		}
+		return origin
+	}
+	if !strings.HasPrefix(origin, "/") || strings.HasPrefix(origin, "//") || strings.HasPrefix(origin, "/\\") {
+		return "/"
+	}
+	if originURL, err := url.Parse(origin); err != nil || originURL.Scheme != "" || originURL.Host != "" {
+		return "/"
+	}
+	return origin
+}
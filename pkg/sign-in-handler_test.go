@@ -0,0 +1,95 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("SignInHandler", func() {
+	var ctx context.Context
+	var stateGenerator *mocks.StateGenerator
+	var provider *mocks.Provider
+	var metrics *mocks.Metrics
+	var auditLogger *mocks.AuditLogger
+	var recorder *httptest.ResponseRecorder
+	var target string
+	var skipSingleProvider bool
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		stateGenerator = &mocks.StateGenerator{}
+		provider = &mocks.Provider{}
+		provider.IDReturns(pkg.ProviderGoogle)
+		provider.NameReturns("Google")
+		provider.AuthCodeURLReturns("https://accounts.example.com/auth")
+		metrics = &mocks.Metrics{}
+		auditLogger = &mocks.AuditLogger{}
+		recorder = httptest.NewRecorder()
+		target = "/sign-in?rd=%2Ffoo%3Fbar%3D1"
+		skipSingleProvider = false
+	})
+	JustBeforeEach(func() {
+		templates, templatesErr := pkg.NewTemplates(ctx, "", pkg.Branding{})
+		Expect(templatesErr).To(BeNil())
+		handler := pkg.NewSignInHandler(stateGenerator, pkg.Providers{provider}, metrics, auditLogger, templates, skipSingleProvider, nil)
+		err = handler.ServeHTTP(ctx, recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	})
+	It("renders provider buttons", func() {
+		Expect(err).To(BeNil())
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring(`href="/sign-in?provider=google&amp;rd=%2Ffoo%3Fbar%3D1"`))
+		Expect(stateGenerator.GenerateCallCount()).To(Equal(0))
+	})
+	Context("provider selected", func() {
+		BeforeEach(func() {
+			target = "/sign-in?provider=google&rd=%2Ffoo"
+		})
+		It("redirects to provider with origin in state", func() {
+			Expect(recorder.Code).To(Equal(http.StatusTemporaryRedirect))
+			Expect(recorder.Header().Get("Location")).To(Equal("https://accounts.example.com/auth"))
+			_, origin, providerID, _ := stateGenerator.GenerateArgsForCall(0)
+			Expect(origin).To(Equal("/foo"))
+			Expect(providerID).To(Equal(pkg.ProviderGoogle))
+			Expect(metrics.LoginRedirectCallCount()).To(Equal(1))
+		})
+	})
+	Context("unknown provider", func() {
+		BeforeEach(func() {
+			target = "/sign-in?provider=banana"
+		})
+		It("returns bad request", func() {
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+	Context("skip single provider", func() {
+		BeforeEach(func() {
+			skipSingleProvider = true
+		})
+		It("redirects to provider", func() {
+			Expect(recorder.Code).To(Equal(http.StatusTemporaryRedirect))
+		})
+	})
+	DescribeTable("SafeOrigin",
+		func(origin string, expected string) {
+			Expect(pkg.SafeOrigin(origin, "example.com")).To(Equal(expected))
+		},
+		Entry("path", "/foo?bar=1", "/foo?bar=1"),
+		Entry("empty", "", "/"),
+		Entry("absolute url", "https://evil.example.com/", "/"),
+		Entry("absolute url of host", "https://example.com/foo", "https://example.com/foo"),
+		Entry("absolute url with user", "https://evil.example.com@example.com/", "/"),
+		Entry("protocol relative", "//evil.example.com/", "/"),
+		Entry("backslash", "/\\evil.example.com/", "/"),
+		Entry("tab", "/\t/evil.example.com/", "/"),
+		Entry("newline", "/\n/evil.example.com/", "/"),
+		Entry("carriage return", "/\r\\evil.example.com/", "/"),
+		Entry("absolute url with tab", "https://example.com\t/foo", "/"),
+	)
+})
@@ -13,7 +13,12 @@ import (
 // State stores a requests state for passing through the oauth2 flow,
 // ensuring CSRF protection and a fluent experience by passing the origin url.
 type State struct {
-	Origin string `json:"origin"`
+	// Type is always TokenTypeState
+	Type     TokenType `json:"typ"`
+	Origin   string    `json:"origin"`
+	Provider string    `json:"provider,omitempty"`
+	// StepUp the login must satisfy, nil for a regular login
+	StepUp *StepUp `json:"step_up,omitempty"`
 	jwt.RegisteredClaims
 
 	token string
@@ -27,7 +32,7 @@ func (s State) String() string {
 //
 //counterfeiter:generate -o ../mocks/state-generator.go --fake-name StateGenerator . StateGenerator
 type StateGenerator interface {
-	Generate(ctx context.Context, originURL string) (State, error)
+	Generate(ctx context.Context, originURL string, provider string, stepUp *StepUp) (State, error)
 	Decode(ctx context.Context, token string) (State, error)
 }
 
@@ -41,7 +46,7 @@ type stateGenerator struct {
 }
 
 // Generate a signed state
-func (s *stateGenerator) Generate(ctx context.Context, originURL string) (State, error) {
+func (s *stateGenerator) Generate(ctx context.Context, originURL string, provider string, stepUp *StepUp) (State, error) {
 	issuedAt := time.Now().UTC()
 	generateUUID, err := uuid.NewUUID()
 	if err != nil {
@@ -49,7 +54,10 @@ func (s *stateGenerator) Generate(ctx context.Context, originURL string) (State,
 	}
 
 	state := State{
-		Origin: originURL,
+		Type:     TokenTypeState,
+		Origin:   originURL,
+		Provider: provider,
+		StepUp:   stepUp,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   generateUUID.String(),
 			IssuedAt:  jwt.NewNumericDate(issuedAt),
@@ -80,8 +88,11 @@ func (s *stateGenerator) Decode(ctx context.Context, state string) (State, error
 	}
 
 	if claims, ok := token.Claims.(*State); ok && token.Valid {
+		if claims.Type != TokenTypeState {
+			return State{}, fmt.Errorf("%w: %s", ErrWrongTokenType, claims.Type)
+		}
 		if len(claims.Subject) < 1 {
-			return State{}, errors.New("token invalid")
+			return State{}, ErrSubjectMissing
 		}
 		return *claims, nil
 	}
@@ -91,7 +102,7 @@ func (s *stateGenerator) Decode(ctx context.Context, state string) (State, error
 
 func (s *stateGenerator) keyFunc(token *jwt.Token) (interface{}, error) {
 	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		return nil, fmt.Errorf("%w: %v", ErrUnexpectedSigningMethod, token.Header["alg"])
 	}
 
 	return s.key, nil
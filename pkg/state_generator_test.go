@@ -20,7 +20,7 @@ var _ = Describe("StateGenerator", func() {
 	})
 	It("generates complete token", func() {
 		origin := "https://test.localhost/foo"
-		state, err := stateGenerator.Generate(ctx, origin)
+		state, err := stateGenerator.Generate(ctx, origin, pkg.ProviderGitHub, nil)
 		Expect(err).To(BeNil())
 		Expect(state.Origin).To(BeEquivalentTo(origin))
 		Expect(state.Subject).NotTo(BeEmpty())
@@ -31,12 +31,13 @@ var _ = Describe("StateGenerator", func() {
 	})
 	It("generates valid token", func() {
 		origin := "https://test.localhost/foo"
-		state, err := stateGenerator.Generate(ctx, origin)
+		state, err := stateGenerator.Generate(ctx, origin, pkg.ProviderGitHub, nil)
 		Expect(err).To(BeNil())
 		Expect(state.String()).NotTo(BeEmpty())
 		state, err = stateGenerator.Decode(ctx, state.String())
 		Expect(err).To(BeNil())
 		Expect(state.Origin).To(BeEquivalentTo(origin))
+		Expect(state.Provider).To(Equal(pkg.ProviderGitHub))
 		Expect(state.Subject).NotTo(BeEmpty())
 		Expect(state.IssuedAt.Time).To(BeTemporally(">=", time.Unix(time.Now().Unix(), 0)))
 		Expect(state.NotBefore.Time).To(BeTemporally(">=", time.Unix(time.Now().Unix(), 0)))
@@ -44,7 +45,7 @@ var _ = Describe("StateGenerator", func() {
 	})
 	It("returns error when decoding outdated token", func() {
 		origin := "https://test.localhost/foo"
-		state, err := stateGenerator.Generate(ctx, origin)
+		state, err := stateGenerator.Generate(ctx, origin, pkg.ProviderGitHub, nil)
 		Expect(err).To(BeNil())
 		Expect(state.String()).NotTo(BeEmpty())
 
@@ -60,6 +61,12 @@ var _ = Describe("StateGenerator", func() {
 		state, err = stateGenerator.Decode(ctx, token)
 		Expect(err).NotTo(BeNil())
 	})
+	It("rejects cookie signed with the same key", func() {
+		cookie, err := pkg.NewCookieGenerator(signingKey, pkg.CookieOptions{}).Generate(ctx, pkg.Login{User: "jdoe@example.com"})
+		Expect(err).To(BeNil())
+		_, err = stateGenerator.Decode(ctx, cookie.String())
+		Expect(err).To(MatchError(pkg.ErrWrongTokenType))
+	})
 	It("returns error when decoding invalid string", func() {
 		raw := "0123456789"
 		state, err := stateGenerator.Decode(ctx, raw)
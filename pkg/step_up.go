@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// StepUp requires a recent or strong login. If an authenticated user does not satisfy it,
+// the login middleware starts a new login asking the provider to authenticate the user again.
+type StepUp struct {
+	// MaxAuthAge of the login, zero allows any age
+	MaxAuthAge time.Duration `yaml:"max_auth_age" json:"max_auth_age,omitempty"`
+	// AcceptedACR values of the ID token, empty accepts any
+	AcceptedACR []string `yaml:"accepted_acr" json:"accepted_acr,omitempty"`
+	// RequiredAMR values of the ID token, e.g. mfa
+	RequiredAMR []string `yaml:"required_amr" json:"required_amr,omitempty"`
+}
+
+// Required returns true if any requirement is set
+func (s StepUp) Required() bool {
+	return s.MaxAuthAge > 0 || len(s.AcceptedACR) > 0 || len(s.RequiredAMR) > 0
+}
+
+// Validate the step-up requirements
+func (s StepUp) Validate(ctx context.Context) error {
+	if s.MaxAuthAge < 0 {
+		return errors.Errorf(ctx, "max_auth_age must not be negative")
+	}
+	return nil
+}
+
+// Check returns an error if the login described by authTime, acr and amr does not satisfy the requirements
+func (s StepUp) Check(ctx context.Context, now time.Time, authTime time.Time, acr string, amr []string) error {
+	if s.MaxAuthAge > 0 && authTime.IsZero() {
+		return errors.Errorf(ctx, "auth time of login unknown")
+	}
+	if s.MaxAuthAge > 0 && now.Sub(authTime) > s.MaxAuthAge {
+		return errors.Errorf(ctx, "login at %s older than %s", authTime.Format(time.RFC3339), s.MaxAuthAge)
+	}
+	if len(s.AcceptedACR) > 0 && !slices.Contains(s.AcceptedACR, acr) {
+		return errors.Errorf(ctx, "acr '%s' not accepted", acr)
+	}
+	for _, method := range s.RequiredAMR {
+		if !slices.Contains(amr, method) {
+			return errors.Errorf(ctx, "amr %v misses %s", amr, method)
+		}
+	}
+	return nil
+}
+
+// idTokenClaims returns the claims of the ID token in the token response, empty claims if there is none.
+// The signature is not verified, the token was received directly from the token endpoint of the
+// provider over TLS (OpenID Connect Core 3.1.3.7).
+func idTokenClaims(ctx context.Context, token *oauth2.Token) (IDTokenClaims, error) {
+	var claims IDTokenClaims
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return claims, nil
+	}
+	if _, _, err := jwt.NewParser().ParseUnverified(idToken, &claims); err != nil {
+		return IDTokenClaims{}, errors.Wrapf(ctx, err, "parse id token failed")
+	}
+	return claims, nil
+}
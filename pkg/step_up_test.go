@@ -0,0 +1,48 @@
+package pkg_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("StepUp", func() {
+	var ctx context.Context
+	var now time.Time
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Now()
+	})
+	It("is not required without requirements", func() {
+		Expect(pkg.StepUp{}.Required()).To(BeFalse())
+	})
+	It("accepts recent login", func() {
+		stepUp := pkg.StepUp{MaxAuthAge: time.Minute}
+		Expect(stepUp.Check(ctx, now, now.Add(-30*time.Second), "", nil)).To(BeNil())
+	})
+	It("rejects old login", func() {
+		stepUp := pkg.StepUp{MaxAuthAge: time.Minute}
+		Expect(stepUp.Check(ctx, now, now.Add(-time.Hour), "", nil)).NotTo(BeNil())
+	})
+	It("rejects login without auth time", func() {
+		stepUp := pkg.StepUp{MaxAuthAge: time.Minute}
+		Expect(stepUp.Check(ctx, now, time.Time{}, "", nil)).NotTo(BeNil())
+	})
+	It("rejects not accepted acr", func() {
+		stepUp := pkg.StepUp{AcceptedACR: []string{"urn:example:mfa"}}
+		Expect(stepUp.Check(ctx, now, now, "urn:example:pwd", nil)).NotTo(BeNil())
+		Expect(stepUp.Check(ctx, now, now, "urn:example:mfa", nil)).To(BeNil())
+	})
+	It("requires all amr", func() {
+		stepUp := pkg.StepUp{RequiredAMR: []string{"pwd", "mfa"}}
+		Expect(stepUp.Check(ctx, now, now, "", []string{"pwd"})).NotTo(BeNil())
+		Expect(stepUp.Check(ctx, now, now, "", []string{"mfa", "pwd"})).To(BeNil())
+	})
+	It("rejects negative max age", func() {
+		Expect(pkg.StepUp{MaxAuthAge: -time.Minute}.Validate(ctx)).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,14 @@
+package pkg
+
+import "strings"
+
+// SplitList splits a comma separated list and drops empty elements
+func SplitList(value string) []string {
+	var result []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			result = append(result, element)
+		}
+	}
+	return result
+}
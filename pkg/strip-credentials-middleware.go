@@ -0,0 +1,29 @@
+package pkg
+
+import "net/http"
+
+// NewStripCredentialsMiddleware removes the credentials the gateway authenticated the request with,
+// the login cookie, the Authorization and X-API-Key header, and a forwarded access token sent by the client.
+// It wraps the upstream proxies, so upstreams can not replay them against the gateway.
+func NewStripCredentialsMiddleware() func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			req.Header.Del("Authorization")
+			req.Header.Del(APIKeyHeaderName)
+			req.Header.Del(ForwardedAccessTokenHeaderName)
+			removeCookie(req, LoginCookieName)
+			handler.ServeHTTP(resp, req)
+		})
+	}
+}
+
+// removeCookie removes all cookies with name from the Cookie header of req
+func removeCookie(req *http.Request, name string) {
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			req.AddCookie(cookie)
+		}
+	}
+}
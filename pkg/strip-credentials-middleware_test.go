@@ -0,0 +1,36 @@
+package pkg_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("StripCredentialsMiddleware", func() {
+	var forwarded http.Header
+	BeforeEach(func() {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Basic c2VjcmV0")
+		req.Header.Set(pkg.APIKeyHeaderName, "api-key")
+		req.Header.Set(pkg.ForwardedAccessTokenHeaderName, "spoofed")
+		req.Header.Set("X-Custom", "value")
+		req.AddCookie(&http.Cookie{Name: pkg.LoginCookieName, Value: "session-cookie"})
+		req.AddCookie(&http.Cookie{Name: "app", Value: "value"})
+		pkg.NewStripCredentialsMiddleware()(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			forwarded = req.Header.Clone()
+		})).ServeHTTP(httptest.NewRecorder(), req)
+	})
+	It("removes credentials of the gateway", func() {
+		Expect(forwarded.Get("Authorization")).To(BeEmpty())
+		Expect(forwarded.Get(pkg.APIKeyHeaderName)).To(BeEmpty())
+		Expect(forwarded.Get(pkg.ForwardedAccessTokenHeaderName)).To(BeEmpty())
+	})
+	It("keeps other cookies and headers", func() {
+		Expect(forwarded.Get("Cookie")).To(Equal("app=value"))
+		Expect(forwarded.Get("X-Custom")).To(Equal("value"))
+	})
+})
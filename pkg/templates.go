@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/bborbe/errors"
+)
+
+//go:embed templates/*.html
+var defaultTemplates embed.FS
+
+// TemplateName is the file name of a page template
+type TemplateName string
+
+const (
+	TemplateSignIn    TemplateName = "sign_in.html"
+	TemplateSignedOut TemplateName = "signed_out.html"
+	TemplateForbidden TemplateName = "forbidden.html"
+	TemplateError     TemplateName = "error.html"
+)
+
+// TemplateNames of all pages
+var TemplateNames = []TemplateName{
+	TemplateSignIn,
+	TemplateSignedOut,
+	TemplateForbidden,
+	TemplateError,
+}
+
+// Branding is available in all templates as .Branding
+type Branding struct {
+	Title           string
+	LogoURL         string
+	PrimaryColor    string
+	BackgroundColor string
+}
+
+// DefaultBranding is used for fields not set by the operator
+var DefaultBranding = Branding{
+	Title:           "Sign in",
+	PrimaryColor:    "#1a73e8",
+	BackgroundColor: "#f1f3f4",
+}
+
+// TemplateData is passed to each template, page specific values are in Data
+type TemplateData struct {
+	Title    string
+	Branding Branding
+	Data     interface{}
+}
+
+// SignInProvider is a login option of the sign-in page
+type SignInProvider struct {
+	Name string
+	URL  string
+}
+
+// SignInPage lists the providers a user can sign in with
+type SignInPage struct {
+	Origin    string
+	Providers []SignInProvider
+}
+
+// SignedOutPage is shown after logout
+type SignedOutPage struct {
+	User      string
+	SignInURL string
+}
+
+// Templates renders the user facing pages
+//
+//counterfeiter:generate -o ../mocks/templates.go --fake-name Templates . Templates
+type Templates interface {
+	Execute(ctx context.Context, writer io.Writer, name TemplateName, title string, data interface{}) error
+	Render(ctx context.Context, resp http.ResponseWriter, status int, name TemplateName, title string, data interface{}) error
+}
+
+// NewTemplates returns the built-in templates, overridden by files with the same name in dir.
+// dir may also contain additional templates used by the overrides. Empty fields of branding use DefaultBranding.
+func NewTemplates(ctx context.Context, dir string, branding Branding) (Templates, error) {
+	tmpl, err := template.ParseFS(defaultTemplates, "templates/*.html")
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "parse default templates failed")
+	}
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, errors.Wrapf(ctx, err, "template dir %s not found", dir)
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, "*.html"))
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "list templates in %s failed", dir)
+		}
+		if len(matches) > 0 {
+			if tmpl, err = tmpl.ParseFiles(matches...); err != nil {
+				return nil, errors.Wrapf(ctx, err, "parse templates in %s failed", dir)
+			}
+		}
+	}
+	if branding.Title == "" {
+		branding.Title = DefaultBranding.Title
+	}
+	if branding.PrimaryColor == "" {
+		branding.PrimaryColor = DefaultBranding.PrimaryColor
+	}
+	if branding.BackgroundColor == "" {
+		branding.BackgroundColor = DefaultBranding.BackgroundColor
+	}
+	return &templates{
+		template: tmpl,
+		branding: branding,
+	}, nil
+}
+
+type templates struct {
+	template *template.Template
+	branding Branding
+}
+
+func (t *templates) Execute(ctx context.Context, writer io.Writer, name TemplateName, title string, data interface{}) error {
+	err := t.template.ExecuteTemplate(writer, string(name), TemplateData{
+		Title:    title,
+		Branding: t.branding,
+		Data:     data,
+	})
+	if err != nil {
+		return errors.Wrapf(ctx, err, "execute template %s failed", name)
+	}
+	return nil
+}
+
+// Render executes the template into a buffer first, so a broken template does not produce a partial page
+func (t *templates) Render(ctx context.Context, resp http.ResponseWriter, status int, name TemplateName, title string, data interface{}) error {
+	buf := &bytes.Buffer{}
+	if err := t.Execute(ctx, buf, name, title, data); err != nil {
+		return err
+	}
+	resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+	resp.WriteHeader(status)
+	if _, err := buf.WriteTo(resp); err != nil {
+		return errors.Wrapf(ctx, err, "write %s failed", name)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package pkg_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("Templates", func() {
+	var ctx context.Context
+	var dir string
+	var buf *bytes.Buffer
+	BeforeEach(func() {
+		ctx = context.Background()
+		dir = ""
+		buf = &bytes.Buffer{}
+	})
+	It("renders all built-in templates", func() {
+		templates, err := pkg.NewTemplates(ctx, dir, pkg.Branding{Title: "Acme", PrimaryColor: "#ff0000"})
+		Expect(err).To(BeNil())
+		Expect(templates.Execute(ctx, buf, pkg.TemplateSignIn, "Sign in", pkg.SignInPage{Providers: []pkg.SignInProvider{{Name: "Google", URL: "/login/google"}}})).To(BeNil())
+		Expect(buf.String()).To(ContainSubstring(`href="/login/google"`))
+		buf.Reset()
+		Expect(templates.Execute(ctx, buf, pkg.TemplateForbidden, "Access denied", pkg.LoginErrorPage{CorrelationID: "abc"})).To(BeNil())
+		Expect(buf.String()).To(ContainSubstring("Correlation ID: abc"))
+		buf.Reset()
+		Expect(templates.Execute(ctx, buf, pkg.TemplateSignedOut, "Signed out", pkg.SignedOutPage{User: "jdoe@example.com"})).To(BeNil())
+		Expect(buf.String()).To(ContainSubstring("Acme"))
+		Expect(buf.String()).To(ContainSubstring("#ff0000"))
+		Expect(buf.String()).To(ContainSubstring("jdoe@example.com has been signed out"))
+	})
+	It("overrides template from dir", func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, string(pkg.TemplateError)), []byte(`custom {{.Data.CorrelationID}} {{.Branding.Title}}`), 0600)).To(BeNil())
+		templates, err := pkg.NewTemplates(ctx, dir, pkg.Branding{})
+		Expect(err).To(BeNil())
+		Expect(templates.Execute(ctx, buf, pkg.TemplateError, "Error", pkg.LoginErrorPage{CorrelationID: "abc"})).To(BeNil())
+		Expect(buf.String()).To(Equal("custom abc " + pkg.DefaultBranding.Title))
+	})
+	It("rejects broken template", func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, string(pkg.TemplateError)), []byte(`{{.Data`), 0600)).To(BeNil())
+		_, err := pkg.NewTemplates(ctx, dir, pkg.Branding{})
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,131 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrUnexpectedSigningMethod is returned if a token is not signed with HMAC
+	ErrUnexpectedSigningMethod = errors.New("unexpected signing method")
+	// ErrSubjectMissing is returned if a token has no subject
+	ErrSubjectMissing = errors.New("subject missing")
+	// ErrWrongTokenType is returned if a token of another kind signed with the same key is decoded
+	ErrWrongTokenType = errors.New("wrong token type")
+)
+
+// TokenType is stored in the typ claim of the tokens signed with the signing key.
+// Decode rejects tokens of other types, e.g. a state can not be used as session cookie.
+type TokenType string
+
+const (
+	TokenTypeSession TokenType = "session"
+	TokenTypeState   TokenType = "state"
+	TokenTypeHandoff TokenType = "handoff"
+)
+
+// TokenErrorReason describes why a token failed validation
+type TokenErrorReason string
+
+const (
+	TokenErrorReasonExpired          TokenErrorReason = "expired"
+	TokenErrorReasonNotValidYet      TokenErrorReason = "not_valid_yet"
+	TokenErrorReasonUsedBeforeIssued TokenErrorReason = "used_before_issued"
+	TokenErrorReasonBadSignature     TokenErrorReason = "bad_signature"
+	TokenErrorReasonWrongAlgorithm   TokenErrorReason = "wrong_algorithm"
+	TokenErrorReasonMalformed        TokenErrorReason = "malformed"
+	TokenErrorReasonSubjectMissing   TokenErrorReason = "subject_missing"
+	TokenErrorReasonInvalidAudience  TokenErrorReason = "invalid_audience"
+	TokenErrorReasonInvalidIssuer    TokenErrorReason = "invalid_issuer"
+	TokenErrorReasonInvalidClaims    TokenErrorReason = "invalid_claims"
+	TokenErrorReasonRevoked          TokenErrorReason = "revoked"
+	TokenErrorReasonWrongType        TokenErrorReason = "wrong_type"
+	TokenErrorReasonUnknown          TokenErrorReason = "unknown"
+)
+
+func (t TokenErrorReason) String() string {
+	return string(t)
+}
+
+// TokenErrorReasonOf classifies an error returned while decoding a token
+func TokenErrorReasonOf(err error) TokenErrorReason {
+	switch {
+	case errors.Is(err, ErrUnexpectedSigningMethod):
+		return TokenErrorReasonWrongAlgorithm
+	case errors.Is(err, ErrSessionRevoked):
+		return TokenErrorReasonRevoked
+	case errors.Is(err, ErrWrongTokenType):
+		return TokenErrorReasonWrongType
+	case errors.Is(err, ErrSubjectMissing):
+		return TokenErrorReasonSubjectMissing
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return TokenErrorReasonExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return TokenErrorReasonNotValidYet
+	case errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return TokenErrorReasonUsedBeforeIssued
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return TokenErrorReasonBadSignature
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return TokenErrorReasonMalformed
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return TokenErrorReasonInvalidAudience
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return TokenErrorReasonInvalidIssuer
+	case errors.Is(err, jwt.ErrTokenInvalidClaims):
+		return TokenErrorReasonInvalidClaims
+	default:
+		return TokenErrorReasonUnknown
+	}
+}
+
+// TokenInspection is the result of inspecting a token
+type TokenInspection struct {
+	Valid  bool                   `json:"valid"`
+	Reason TokenErrorReason       `json:"reason,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+	Header map[string]interface{} `json:"header,omitempty"`
+	Claims jwt.MapClaims          `json:"claims,omitempty"`
+}
+
+// InspectToken validates the token with the given decode func and returns
+// its header and claims even if the validation failed.
+func InspectToken(ctx context.Context, token string, decode func(ctx context.Context, token string) error) TokenInspection {
+	var inspection TokenInspection
+	claims := jwt.MapClaims{}
+	if parsed, _, err := jwt.NewParser().ParseUnverified(token, claims); err == nil {
+		inspection.Header = parsed.Header
+		inspection.Claims = claims
+	}
+	if err := decode(ctx, token); err != nil {
+		inspection.Reason = TokenErrorReasonOf(err)
+		inspection.Error = err.Error()
+		return inspection
+	}
+	inspection.Valid = true
+	return inspection
+}
+
+// InspectCookie validates the cookie token and returns its claims
+func InspectCookie(ctx context.Context, cookieGenerator CookieGenerator, token string) TokenInspection {
+	return InspectToken(ctx, token, func(ctx context.Context, token string) error {
+		_, err := cookieGenerator.Decode(ctx, token)
+		return err
+	})
+}
+
+// InspectState validates the state token and returns its claims
+func InspectState(ctx context.Context, stateGenerator StateGenerator, token string) TokenInspection {
+	return InspectToken(ctx, token, func(ctx context.Context, token string) error {
+		_, err := stateGenerator.Decode(ctx, token)
+		return err
+	})
+}
+
+// MarshalIndent returns the inspection as indented JSON
+func (t TokenInspection) MarshalIndent() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
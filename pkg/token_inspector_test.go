@@ -0,0 +1,77 @@
+package pkg_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("InspectCookie", func() {
+	var signingKey = []byte("test-key")
+	var cookieGenerator = pkg.NewCookieGenerator(signingKey, pkg.CookieOptions{})
+	var ctx context.Context
+	var user string
+	BeforeEach(func() {
+		ctx = context.Background()
+		user = "jdoe@example.com"
+	})
+	It("returns claims of valid token", func() {
+		cookie, err := cookieGenerator.Generate(ctx, pkg.Login{User: user, Provider: pkg.ProviderGoogle})
+		Expect(err).To(BeNil())
+		inspection := pkg.InspectCookie(ctx, cookieGenerator, cookie.String())
+		Expect(inspection.Valid).To(BeTrue())
+		Expect(inspection.Reason).To(BeEmpty())
+		Expect(inspection.Claims["sub"]).To(Equal(user))
+		Expect(inspection.Header["alg"]).To(Equal("HS256"))
+	})
+	It("reports expired token with claims", func() {
+		cookie, err := cookieGenerator.Generate(ctx, pkg.Login{User: user, Provider: pkg.ProviderGoogle})
+		Expect(err).To(BeNil())
+		cookie.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, cookie).SignedString(signingKey)
+		Expect(err).To(BeNil())
+		inspection := pkg.InspectCookie(ctx, cookieGenerator, token)
+		Expect(inspection.Valid).To(BeFalse())
+		Expect(inspection.Reason).To(Equal(pkg.TokenErrorReasonExpired))
+		Expect(inspection.Claims["sub"]).To(Equal(user))
+	})
+	It("reports bad signature", func() {
+		cookie, err := pkg.NewCookieGenerator([]byte("other-key"), pkg.CookieOptions{}).Generate(ctx, pkg.Login{User: user, Provider: pkg.ProviderGoogle})
+		Expect(err).To(BeNil())
+		inspection := pkg.InspectCookie(ctx, cookieGenerator, cookie.String())
+		Expect(inspection.Valid).To(BeFalse())
+		Expect(inspection.Reason).To(Equal(pkg.TokenErrorReasonBadSignature))
+	})
+	It("reports wrong algorithm", func() {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{Subject: user}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		Expect(err).To(BeNil())
+		inspection := pkg.InspectCookie(ctx, cookieGenerator, token)
+		Expect(inspection.Valid).To(BeFalse())
+		Expect(inspection.Reason).To(Equal(pkg.TokenErrorReasonWrongAlgorithm))
+	})
+	It("reports missing subject", func() {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, pkg.Cookie{Type: pkg.TokenTypeSession}).SignedString(signingKey)
+		Expect(err).To(BeNil())
+		inspection := pkg.InspectCookie(ctx, cookieGenerator, token)
+		Expect(inspection.Valid).To(BeFalse())
+		Expect(inspection.Reason).To(Equal(pkg.TokenErrorReasonSubjectMissing))
+	})
+	It("reports token without type", func() {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: user}).SignedString(signingKey)
+		Expect(err).To(BeNil())
+		inspection := pkg.InspectCookie(ctx, cookieGenerator, token)
+		Expect(inspection.Valid).To(BeFalse())
+		Expect(inspection.Reason).To(Equal(pkg.TokenErrorReasonWrongType))
+	})
+	It("reports malformed token", func() {
+		inspection := pkg.InspectCookie(ctx, cookieGenerator, "0123456789")
+		Expect(inspection.Valid).To(BeFalse())
+		Expect(inspection.Reason).To(Equal(pkg.TokenErrorReasonMalformed))
+		Expect(inspection.Claims).To(BeNil())
+	})
+})
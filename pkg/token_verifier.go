@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+)
+
+// ErrSessionRevoked is returned for tokens of a session deleted by logout
+var ErrSessionRevoked = stderrors.New("session revoked")
+
+// TokenVerifier verifies a bearer token and returns the identity it belongs to
+//
+//counterfeiter:generate -o ../mocks/token-verifier.go --fake-name TokenVerifier . TokenVerifier
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Identity, error)
+}
+
+// TokenVerifierFunc allows to use a func as TokenVerifier
+type TokenVerifierFunc func(ctx context.Context, token string) (*Identity, error)
+
+// Verify the token
+func (t TokenVerifierFunc) Verify(ctx context.Context, token string) (*Identity, error) {
+	return t(ctx, token)
+}
+
+// NewSessionTokenVerifier accepts session tokens issued by the given CookieGenerator
+// unless their session was revoked in sessionStore. The groups of the user are looked up
+// with groupResolver on every request, so removed members lose access once its cache expires.
+// A nil groupResolver keeps the groups of the login.
+func NewSessionTokenVerifier(cookieGenerator CookieGenerator, sessionStore SessionStore, groupResolver GroupResolver) TokenVerifier {
+	return TokenVerifierFunc(func(ctx context.Context, token string) (*Identity, error) {
+		cookie, err := cookieGenerator.Decode(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		revoked, err := sessionStore.SessionRevoked(ctx, cookie.ID)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "check session %s failed", cookie.ID)
+		}
+		if revoked {
+			return nil, errors.Wrapf(ctx, ErrSessionRevoked, "session %s of %s", cookie.ID, cookie.Subject)
+		}
+		// unknown auth time stays zero, it never satisfies a max_auth_age
+		var authTime time.Time
+		if cookie.AuthTime != nil {
+			authTime = cookie.AuthTime.Time
+		}
+		groups := cookie.Groups
+		if groupResolver != nil {
+			groups, err = groupResolver.Groups(ctx, cookie.Provider, cookie.Subject)
+			if err != nil {
+				// without groups the user keeps access not depending on them
+				glog.Warningf("get groups of %s failed: %v", cookie.Subject, err)
+				groups = nil
+			}
+		}
+		return &Identity{
+			User:      cookie.Subject,
+			Provider:  cookie.Provider,
+			SessionID: cookie.ID,
+			Groups:    groups,
+			AuthTime:  authTime,
+			ACR:       cookie.ACR,
+			AMR:       cookie.AMR,
+		}, nil
+	})
+}
@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/bborbe/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/bborbe/sample_oauth2/pkg"
+
+const (
+	// TraceExporterNone disables tracing
+	TraceExporterNone = ""
+	// TraceExporterStdout prints spans to stdout, useful for local testing
+	TraceExporterStdout = "stdout"
+	// TraceExporterOTLP sends spans via OTLP/HTTP, configured by the OTEL_EXPORTER_OTLP_* env vars
+	TraceExporterOTLP = "otlp"
+)
+
+// SetupTracing installs a global tracer provider using the given exporter and
+// the W3C trace context propagator. The returned func flushes and stops the exporter.
+func SetupTracing(ctx context.Context, exporterName string, serviceName string) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterName {
+	case TraceExporterNone:
+		return func(ctx context.Context) error { return nil }, nil
+	case TraceExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case TraceExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, errors.Errorf(ctx, "unknown trace exporter '%s'", exporterName)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "create %s trace exporter failed", exporterName)
+	}
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	return tracerProvider.Shutdown, nil
+}
+
+func startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// endSpan records the outcome and error of the span and ends it
+func endSpan(span trace.Span, outcome string, err error) {
+	span.SetAttributes(attribute.String("outcome", outcome))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func outcomeOf(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// extractTraceContext continues the trace of the incoming request
+func extractTraceContext(ctx context.Context, req *http.Request) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+// injectTraceContext sets the traceparent header so proxied upstreams continue the trace
+func injectTraceContext(ctx context.Context, req *http.Request) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
@@ -0,0 +1,228 @@
+package pkg
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/bborbe/errors"
+)
+
+// TrustedProxies are the addresses of load balancers whose forwarding headers are trusted
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies from CIDRs or single IPs
+func ParseTrustedProxies(ctx context.Context, values []string) (TrustedProxies, error) {
+	result := make(TrustedProxies, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, errors.Wrapf(ctx, err, "parse ip '%s' failed", value)
+			}
+			result = append(result, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, errors.Wrapf(ctx, err, "parse cidr '%s' failed", value)
+		}
+		result = append(result, prefix.Masked())
+	}
+	return result, nil
+}
+
+// Contains returns true if ip is the address of a trusted proxy
+func (t TrustedProxies) Contains(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForwardedRequest describes the request as sent by the client
+type ForwardedRequest struct {
+	ClientIP string
+	// Scheme the client used, http or https
+	Scheme string
+	// Host the client requested
+	Host string
+}
+
+// Forwarded returns client IP, scheme and host of the request. If the request was sent by a trusted
+// proxy, the RFC 7239 Forwarded header or else the X-Forwarded-For, -Proto and -Host headers are
+// followed from right to left up to the first untrusted address.
+func (t TrustedProxies) Forwarded(req *http.Request) ForwardedRequest {
+	result := ForwardedRequest{
+		ClientIP: RemoteIP(req),
+		Scheme:   "http",
+		Host:     req.Host,
+	}
+	if req.TLS != nil {
+		result.Scheme = "https"
+	}
+	if !t.Contains(result.ClientIP) {
+		return result
+	}
+	if hops := forwardedHeaderHops(req); len(hops) > 0 {
+		return t.follow(result, hops)
+	}
+	return t.follow(result, xForwardedHops(req))
+}
+
+// follow the hops from right to left up to the first untrusted address
+func (t TrustedProxies) follow(result ForwardedRequest, hops []forwardedHop) ForwardedRequest {
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := hops[i]
+		if hop.ip == "" {
+			// obfuscated or unknown node, the client can not be identified beyond it
+			break
+		}
+		result.ClientIP = hop.ip
+		if hop.scheme == "http" || hop.scheme == "https" {
+			result.Scheme = hop.scheme
+		}
+		if validForwardedHost(hop.host) {
+			result.Host = hop.host
+		}
+		if !t.Contains(hop.ip) {
+			break
+		}
+	}
+	return result
+}
+
+// ClientIP returns the IP of the client, see Forwarded
+func (t TrustedProxies) ClientIP(req *http.Request) string {
+	return t.Forwarded(req).ClientIP
+}
+
+// forwardedHop is a proxy passed by the request, the scheme and host are the ones the proxy received
+type forwardedHop struct {
+	ip     string
+	scheme string
+	host   string
+}
+
+// forwardedHeaderHops parses the RFC 7239 Forwarded header
+func forwardedHeaderHops(req *http.Request) []forwardedHop {
+	var result []forwardedHop
+	for _, value := range req.Header.Values("Forwarded") {
+		for _, element := range strings.Split(value, ",") {
+			var hop forwardedHop
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				value = strings.Trim(value, `"`)
+				switch strings.ToLower(key) {
+				case "for":
+					hop.ip = forwardedNodeIP(value)
+				case "proto":
+					hop.scheme = strings.ToLower(value)
+				case "host":
+					hop.host = value
+				}
+			}
+			result = append(result, hop)
+		}
+	}
+	return result
+}
+
+// forwardedNodeIP returns the IP of a node like 192.0.2.1:80 or [2001:db8::1]:80, empty if obfuscated or unknown
+func forwardedNodeIP(node string) string {
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	addr, err := netip.ParseAddr(strings.Trim(node, "[]"))
+	if err != nil {
+		return ""
+	}
+	return addr.Unmap().String()
+}
+
+// xForwardedHops parses X-Forwarded-For, -Proto and -Host. Each proxy appends an entry to the headers,
+// so their entries are aligned from the right. A proxy overwriting -Proto or -Host sets the nearest hop.
+// Entries left of the first X-Forwarded-For entry were sent by the client and are ignored.
+func xForwardedHops(req *http.Request) []forwardedHop {
+	ips := headerList(req, "X-Forwarded-For")
+	result := make([]forwardedHop, len(ips))
+	for i, ip := range ips {
+		result[i].ip = forwardedNodeIP(ip)
+	}
+	schemes := headerList(req, "X-Forwarded-Proto")
+	for i := 1; i <= len(schemes) && i <= len(result); i++ {
+		result[len(result)-i].scheme = strings.ToLower(schemes[len(schemes)-i])
+	}
+	hosts := headerList(req, "X-Forwarded-Host")
+	for i := 1; i <= len(hosts) && i <= len(result); i++ {
+		result[len(result)-i].host = hosts[len(hosts)-i]
+	}
+	return result
+}
+
+// headerList returns the comma separated values of all headers with the name
+func headerList(req *http.Request, name string) []string {
+	var result []string
+	for _, value := range strings.Split(strings.Join(req.Header.Values(name), ","), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func validForwardedHost(host string) bool {
+	return host != "" && !strings.ContainsAny(host, "/\\@ ")
+}
+
+type clientIPContextKey struct{}
+
+// ClientIP returns the IP of the client as set by NewTrustedProxyMiddleware, the remote address otherwise
+func ClientIP(req *http.Request) string {
+	if ip, ok := req.Context().Value(clientIPContextKey{}).(string); ok {
+		return ip
+	}
+	return RemoteIP(req)
+}
+
+// IsHTTPS returns true if the client sent the request over https
+func IsHTTPS(req *http.Request) bool {
+	return req.TLS != nil || req.URL.Scheme == "https"
+}
+
+// RequestHost returns the host the client requested
+func RequestHost(req *http.Request) string {
+	if req.URL.Host != "" {
+		return req.URL.Host
+	}
+	return req.Host
+}
+
+// NewTrustedProxyMiddleware sets scheme and host of the request URL to the ones the client used
+// and makes the client IP available by ClientIP, see TrustedProxies.Forwarded.
+// The Host header passed to upstreams is not changed.
+func NewTrustedProxyMiddleware(trustedProxies TrustedProxies) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			forwarded := trustedProxies.Forwarded(req)
+			req = req.WithContext(context.WithValue(req.Context(), clientIPContextKey{}, forwarded.ClientIP))
+			requestURL := *req.URL
+			requestURL.Scheme = forwarded.Scheme
+			requestURL.Host = forwarded.Host
+			req.URL = &requestURL
+			handler.ServeHTTP(resp, req)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("TrustedProxies", func() {
+	var trustedProxies pkg.TrustedProxies
+	var req *http.Request
+	var forwarded pkg.ForwardedRequest
+	BeforeEach(func() {
+		var err error
+		trustedProxies, err = pkg.ParseTrustedProxies(context.Background(), []string{"10.0.0.0/8", "192.0.2.10"})
+		Expect(err).To(BeNil())
+		req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+		req.Host = "gateway.internal"
+		req.RemoteAddr = "10.0.0.1:1234"
+	})
+	JustBeforeEach(func() {
+		forwarded = trustedProxies.Forwarded(req)
+	})
+	Context("with X-Forwarded headers", func() {
+		BeforeEach(func() {
+			req.Header.Set("X-Forwarded-For", "203.0.113.7, 198.51.100.1, 192.0.2.10")
+			req.Header.Set("X-Forwarded-Proto", "https")
+			req.Header.Set("X-Forwarded-Host", "app.example.com")
+		})
+		It("returns first untrusted address", func() {
+			Expect(forwarded.ClientIP).To(Equal("198.51.100.1"))
+		})
+		It("returns scheme and host of the client", func() {
+			Expect(forwarded.Scheme).To(Equal("https"))
+			Expect(forwarded.Host).To(Equal("app.example.com"))
+		})
+		Context("appended by the proxy", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Forwarded-For", "203.0.113.7, 198.51.100.1")
+				req.Header.Set("X-Forwarded-Proto", "http, https")
+				req.Header.Set("X-Forwarded-Host", "evil.example.com, app.example.com")
+			})
+			It("returns values of the nearest trusted hop", func() {
+				Expect(forwarded.ClientIP).To(Equal("198.51.100.1"))
+				Expect(forwarded.Scheme).To(Equal("https"))
+				Expect(forwarded.Host).To(Equal("app.example.com"))
+			})
+		})
+		Context("sent by the client beyond the first hop", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Forwarded-For", "198.51.100.1")
+				req.Header.Set("X-Forwarded-Proto", "https, http")
+				req.Header.Set("X-Forwarded-Host", "evil.example.com, app.example.com")
+			})
+			It("ignores values of the client", func() {
+				Expect(forwarded.Scheme).To(Equal("http"))
+				Expect(forwarded.Host).To(Equal("app.example.com"))
+			})
+		})
+		Context("from untrusted client", func() {
+			BeforeEach(func() {
+				req.RemoteAddr = "198.51.100.99:1234"
+			})
+			It("ignores headers", func() {
+				Expect(forwarded.ClientIP).To(Equal("198.51.100.99"))
+				Expect(forwarded.Scheme).To(Equal("http"))
+				Expect(forwarded.Host).To(Equal("gateway.internal"))
+			})
+		})
+	})
+	Context("with Forwarded header", func() {
+		BeforeEach(func() {
+			req.Header.Set("Forwarded", `for="[2001:db8::1]:4711";proto=https;host=app.example.com, for=10.0.0.5;proto=http`)
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		})
+		It("prefers Forwarded", func() {
+			Expect(forwarded.ClientIP).To(Equal("2001:db8::1"))
+			Expect(forwarded.Scheme).To(Equal("https"))
+			Expect(forwarded.Host).To(Equal("app.example.com"))
+		})
+	})
+	It("rejects invalid cidr", func() {
+		_, err := pkg.ParseTrustedProxies(context.Background(), []string{"10.0.0.0/99"})
+		Expect(err).NotTo(BeNil())
+	})
+	It("makes request url absolute", func() {
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		var requestURL string
+		var clientIP string
+		pkg.NewTrustedProxyMiddleware(trustedProxies)(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			requestURL = req.URL.String()
+			clientIP = pkg.ClientIP(req)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		Expect(requestURL).To(Equal("https://gateway.internal/foo"))
+		Expect(clientIP).To(Equal("203.0.113.7"))
+	})
+})
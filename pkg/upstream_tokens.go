@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bborbe/errors"
+	"github.com/golang/glog"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// ForwardAccessTokenAuthorization forwards the provider access token as `Authorization: Bearer` header
+	ForwardAccessTokenAuthorization = "authorization"
+	// ForwardAccessTokenHeader forwards the provider access token as X-Forwarded-Access-Token header
+	ForwardAccessTokenHeader = "header"
+	// ForwardedAccessTokenHeaderName contains the provider access token if forwarded by ForwardAccessTokenHeader
+	ForwardedAccessTokenHeaderName = "X-Forwarded-Access-Token"
+)
+
+// upstreamTokenRefreshLeeway refreshes tokens before they expire, so upstreams do not receive a token expiring in flight
+const upstreamTokenRefreshLeeway = time.Minute
+
+// ErrNoUpstreamToken is returned if the session has no provider token
+var ErrNoUpstreamToken = stderrors.New("no upstream token")
+
+// UpstreamTokens returns the provider token of a session
+//
+//counterfeiter:generate -o ../mocks/upstream-tokens.go --fake-name UpstreamTokens . UpstreamTokens
+type UpstreamTokens interface {
+	// Token of the session, refreshed and saved if it is about to expire
+	Token(ctx context.Context, sessionID string) (*oauth2.Token, error)
+}
+
+// NewUpstreamTokens returns the tokens stored with the sessions, refreshes are audited
+func NewUpstreamTokens(sessionStore SessionStore, providers Providers, auditLogger AuditLogger) UpstreamTokens {
+	return &upstreamTokens{
+		sessionStore: sessionStore,
+		providers:    providers,
+		auditLogger:  auditLogger,
+		locks:        map[string]*sessionLock{},
+	}
+}
+
+type upstreamTokens struct {
+	sessionStore SessionStore
+	providers    Providers
+	auditLogger  AuditLogger
+
+	// mux guards locks
+	mux sync.Mutex
+	// locks prevent concurrent requests of a session from refreshing the same token twice,
+	// requests of other sessions do not wait for the refresh
+	locks map[string]*sessionLock
+}
+
+// sessionLock is removed from locks once no request of the session holds or waits for it
+type sessionLock struct {
+	mux   sync.Mutex
+	users int
+}
+
+func (u *upstreamTokens) lock(sessionID string) func() {
+	u.mux.Lock()
+	lock, ok := u.locks[sessionID]
+	if !ok {
+		lock = &sessionLock{}
+		u.locks[sessionID] = lock
+	}
+	lock.users++
+	u.mux.Unlock()
+
+	lock.mux.Lock()
+	return func() {
+		lock.mux.Unlock()
+		u.mux.Lock()
+		lock.users--
+		if lock.users == 0 {
+			delete(u.locks, sessionID)
+		}
+		u.mux.Unlock()
+	}
+}
+
+func (u *upstreamTokens) Token(ctx context.Context, sessionID string) (*oauth2.Token, error) {
+	unlock := u.lock(sessionID)
+	defer unlock()
+
+	session, err := u.sessionStore.Session(ctx, sessionID)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "get session %s failed", sessionID)
+	}
+	if session.Token == nil || session.Token.AccessToken == "" {
+		return nil, errors.Wrapf(ctx, ErrNoUpstreamToken, "session %s has no token", sessionID)
+	}
+	if session.Token.Expiry.IsZero() || time.Now().Add(upstreamTokenRefreshLeeway).Before(session.Token.Expiry) {
+		return session.Token, nil
+	}
+	provider, ok := u.providers.Find(session.Provider)
+	if !ok {
+		return nil, errors.Errorf(ctx, "provider '%s' of session %s not configured", session.Provider, sessionID)
+	}
+	// expire the token early, otherwise the oauth2 package returns it unchanged until it expired
+	expiring := *session.Token
+	expiring.Expiry = time.Now().Add(-time.Second)
+	token, err := provider.RefreshToken(ctx, &expiring)
+	if err != nil {
+		return nil, errors.Wrapf(ctx, err, "refresh token of session %s failed", sessionID)
+	}
+	session.Token = token
+	if err := u.sessionStore.SaveSession(ctx, *session); err != nil {
+		return nil, errors.Wrapf(ctx, err, "save session %s failed", sessionID)
+	}
+	u.auditLogger.Log(ctx, AuditEvent{
+		Time:      time.Now().UTC(),
+		Type:      AuditEventSessionRefreshed,
+		User:      session.User,
+		Provider:  session.Provider,
+		SessionID: session.ID,
+	})
+	glog.V(2).Infof("token of session %s refreshed", sessionID)
+	return token, nil
+}
+
+// NewForwardAccessTokenMiddleware passes the provider access token of the session to the upstream
+// as configured by mode. Headers of the client with the same name are removed. If no token is available
+// the request is forwarded without it. It must run after the login middleware and wrap only the upstream
+// proxies inside NewStripCredentialsMiddleware, handlers of the gateway rely on the headers of the client.
+func NewForwardAccessTokenMiddleware(upstreamTokens UpstreamTokens, mode string) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			req.Header.Del(ForwardedAccessTokenHeaderName)
+			identity, ok := IdentityFromContext(ctx)
+			if !ok || identity.SessionID == "" {
+				handler.ServeHTTP(resp, req)
+				return
+			}
+			token, err := upstreamTokens.Token(ctx, identity.SessionID)
+			if stderrors.Is(err, ErrNoUpstreamToken) {
+				handler.ServeHTTP(resp, req)
+				return
+			}
+			if err != nil {
+				glog.Warningf("forward access token of %s failed: %v", identity.User, err)
+				handler.ServeHTTP(resp, req)
+				return
+			}
+			switch mode {
+			case ForwardAccessTokenAuthorization:
+				req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+			case ForwardAccessTokenHeader:
+				req.Header.Set(ForwardedAccessTokenHeaderName, token.AccessToken)
+			}
+			handler.ServeHTTP(resp, req)
+		})
+	}
+}
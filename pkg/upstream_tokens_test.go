@@ -0,0 +1,170 @@
+package pkg_test
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
+
+	"github.com/bborbe/sample_oauth2/mocks"
+	"github.com/bborbe/sample_oauth2/pkg"
+)
+
+var _ = Describe("UpstreamTokens", func() {
+	var ctx context.Context
+	var sessionStore pkg.SessionStore
+	var provider *mocks.Provider
+	var upstreamTokens pkg.UpstreamTokens
+	var auditLogger *mocks.AuditLogger
+	var session pkg.Session
+	var token *oauth2.Token
+	var err error
+	BeforeEach(func() {
+		ctx = context.Background()
+		sessionStore = pkg.NewMemorySessionStore()
+		provider = &mocks.Provider{}
+		provider.IDReturns(pkg.ProviderGoogle)
+		provider.RefreshTokenReturns(&oauth2.Token{AccessToken: "refreshed", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}, nil)
+		auditLogger = &mocks.AuditLogger{}
+		upstreamTokens = pkg.NewUpstreamTokens(sessionStore, pkg.Providers{provider}, auditLogger)
+		session = pkg.Session{
+			ID:        "session-id",
+			User:      "jdoe@example.com",
+			Provider:  pkg.ProviderGoogle,
+			ExpiresAt: time.Now().Add(time.Hour),
+			Token:     &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)},
+		}
+	})
+	JustBeforeEach(func() {
+		Expect(sessionStore.SaveSession(ctx, session)).To(BeNil())
+		token, err = upstreamTokens.Token(ctx, "session-id")
+	})
+	It("returns stored token", func() {
+		Expect(err).To(BeNil())
+		Expect(token.AccessToken).To(Equal("access"))
+		Expect(provider.RefreshTokenCallCount()).To(Equal(0))
+		Expect(auditLogger.LogCallCount()).To(Equal(0))
+	})
+	Context("token about to expire", func() {
+		BeforeEach(func() {
+			session.Token.Expiry = time.Now().Add(30 * time.Second)
+		})
+		It("refreshes and saves token", func() {
+			Expect(err).To(BeNil())
+			Expect(token.AccessToken).To(Equal("refreshed"))
+			_, expiring := provider.RefreshTokenArgsForCall(0)
+			Expect(expiring.RefreshToken).To(Equal("refresh"))
+			stored, err := sessionStore.Session(ctx, "session-id")
+			Expect(err).To(BeNil())
+			Expect(stored.Token.AccessToken).To(Equal("refreshed"))
+		})
+		It("audits the refresh", func() {
+			Expect(auditLogger.LogCallCount()).To(Equal(1))
+			_, event := auditLogger.LogArgsForCall(0)
+			Expect(event.Type).To(Equal(pkg.AuditEventSessionRefreshed))
+			Expect(event.User).To(Equal("jdoe@example.com"))
+			Expect(event.Provider).To(Equal(pkg.ProviderGoogle))
+			Expect(event.SessionID).To(Equal("session-id"))
+		})
+	})
+	Context("refresh failed", func() {
+		BeforeEach(func() {
+			session.Token.Expiry = time.Now().Add(-time.Minute)
+			provider.RefreshTokenReturns(nil, stderrors.New("banana"))
+		})
+		It("returns error", func() {
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("session without token", func() {
+		BeforeEach(func() {
+			session.Token = nil
+		})
+		It("returns ErrNoUpstreamToken", func() {
+			Expect(stderrors.Is(err, pkg.ErrNoUpstreamToken)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("UpstreamTokens concurrent", func() {
+	It("refreshes the token of a session once", func() {
+		ctx := context.Background()
+		sessionStore := pkg.NewMemorySessionStore()
+		provider := &mocks.Provider{}
+		provider.IDReturns(pkg.ProviderGoogle)
+		provider.RefreshTokenStub = func(ctx context.Context, token *oauth2.Token) (*oauth2.Token, error) {
+			time.Sleep(10 * time.Millisecond)
+			return &oauth2.Token{AccessToken: "refreshed", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}, nil
+		}
+		Expect(sessionStore.SaveSession(ctx, pkg.Session{
+			ID:        "session-id",
+			User:      "jdoe@example.com",
+			Provider:  pkg.ProviderGoogle,
+			ExpiresAt: time.Now().Add(time.Hour),
+			Token:     &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: time.Now().Add(30 * time.Second)},
+		})).To(BeNil())
+		upstreamTokens := pkg.NewUpstreamTokens(sessionStore, pkg.Providers{provider}, &mocks.AuditLogger{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				token, err := upstreamTokens.Token(ctx, "session-id")
+				Expect(err).To(BeNil())
+				Expect(token.AccessToken).To(Equal("refreshed"))
+			}()
+		}
+		wg.Wait()
+		Expect(provider.RefreshTokenCallCount()).To(Equal(1))
+	})
+})
+
+var _ = Describe("ForwardAccessTokenMiddleware", func() {
+	var upstreamTokens *mocks.UpstreamTokens
+	var mode string
+	var req *http.Request
+	var forwarded http.Header
+	BeforeEach(func() {
+		upstreamTokens = &mocks.UpstreamTokens{}
+		upstreamTokens.TokenReturns(&oauth2.Token{AccessToken: "access"}, nil)
+		mode = pkg.ForwardAccessTokenHeader
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(pkg.ForwardedAccessTokenHeaderName, "spoofed")
+		req = req.WithContext(pkg.WithIdentity(req.Context(), &pkg.Identity{User: "jdoe@example.com", SessionID: "session-id"}))
+	})
+	JustBeforeEach(func() {
+		pkg.NewForwardAccessTokenMiddleware(upstreamTokens, mode)(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			forwarded = req.Header.Clone()
+		})).ServeHTTP(httptest.NewRecorder(), req)
+	})
+	It("forwards token as header", func() {
+		Expect(forwarded.Get(pkg.ForwardedAccessTokenHeaderName)).To(Equal("access"))
+		_, sessionID := upstreamTokens.TokenArgsForCall(0)
+		Expect(sessionID).To(Equal("session-id"))
+	})
+	Context("authorization", func() {
+		BeforeEach(func() {
+			mode = pkg.ForwardAccessTokenAuthorization
+		})
+		It("forwards token as bearer", func() {
+			Expect(forwarded.Get("Authorization")).To(Equal("Bearer access"))
+			Expect(forwarded.Get(pkg.ForwardedAccessTokenHeaderName)).To(BeEmpty())
+		})
+	})
+	Context("no token", func() {
+		BeforeEach(func() {
+			upstreamTokens.TokenReturns(nil, pkg.ErrNoUpstreamToken)
+		})
+		It("removes header of client", func() {
+			Expect(forwarded.Get(pkg.ForwardedAccessTokenHeaderName)).To(BeEmpty())
+		})
+	})
+})